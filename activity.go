@@ -0,0 +1,87 @@
+package todo
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	ActivityCreated  = "created"
+	ActivityUpdated  = "updated"
+	ActivityDeleted  = "deleted"
+	ActivityAdded    = "added"
+	ActivityRemoved  = "removed"
+	ActivityRestored = "restored"
+)
+
+const (
+	ActivityEntityList   = "list"
+	ActivityEntityItem   = "item"
+	ActivityEntityMember = "member"
+)
+
+// Activity records a change made to a list, one of its items or its members.
+// EntityId is the id of the list, the item or the member's user. Changes holds the
+// fields of created and deleted entities and the changed fields of updated ones; for
+// items added to or removed from a list it holds the list_id.
+type Activity struct {
+	Id         int       `json:"id" db:"id"`
+	ListId     int       `json:"list_id" db:"list_id"`
+	ActorId    int       `json:"actor_id" db:"actor_id"`
+	ActorName  string    `json:"actor_name" db:"actor_name"`
+	Action     string    `json:"action" db:"action"`
+	EntityType string    `json:"entity_type" db:"entity_type"`
+	EntityId   int       `json:"entity_id" db:"entity_id"`
+	Changes    Changes   `json:"changes,omitempty" db:"changes"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// FieldChange is the value of a field before and after a change. Before is null
+// for created entities and After for deleted ones.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Changes maps field names to their changes. It is stored as JSON.
+type Changes map[string]FieldChange
+
+func (c Changes) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (c *Changes) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(src), c)
+	case []byte:
+		return json.Unmarshal(src, c)
+	}
+
+	return fmt.Errorf("cannot scan %v into changes", src)
+}
+
+// ActivityQuery selects one page of a list's activity, latest first. Cursor is the
+// opaque next_cursor value of the previous page.
+type ActivityQuery struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+}
+
+func (q ActivityQuery) Validate() error {
+	return PageQuery{Limit: q.Limit, Cursor: q.Cursor}.Validate()
+}
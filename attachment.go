@@ -0,0 +1,39 @@
+package todo
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const MaxAttachmentNameLength = 255
+
+// Attachment is the metadata of a file attached to an item. The content lives in the
+// blob store under StorageKey; ContentType is sniffed from the content on upload.
+type Attachment struct {
+	Id          int       `json:"id" db:"id"`
+	ItemId      int       `json:"item_id" db:"item_id"`
+	UserId      int       `json:"user_id" db:"user_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	Size        int64     `json:"size" db:"size"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	SHA256      string    `json:"sha256" db:"sha256"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// AttachmentName strips the directories a client may send along with a file name.
+func AttachmentName(filename string) (string, error) {
+	name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("%w: file name must not be empty", ErrValidation)
+	}
+
+	if utf8.RuneCountInString(name) > MaxAttachmentNameLength {
+		return "", fmt.Errorf("%w: file name must be at most %d characters", ErrValidation, MaxAttachmentNameLength)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,101 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operations of a batch on the items of a list. CompleteAll marks every open item of
+// the list done, adding the next occurrences of recurring ones, and ClearCompleted
+// moves the done ones to the trash.
+const (
+	BatchCreate         = "create"
+	BatchUpdate         = "update"
+	BatchDelete         = "delete"
+	BatchCompleteAll    = "complete_all"
+	BatchClearCompleted = "clear_completed"
+)
+
+// Batch modes. An atomic batch is rolled back as a whole when an operation fails; a best
+// effort batch only undoes the failed operation and goes on with the next one.
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+)
+
+// Statuses of the result of a batch operation.
+const (
+	BatchOk     = "ok"
+	BatchFailed = "failed"
+)
+
+// MaxBatchOperations caps the size of a batch, which holds its transaction open until the end.
+const MaxBatchOperations = 100
+
+// BatchInput is a batch of operations on the items of a list. Mode defaults to atomic.
+type BatchInput struct {
+	Mode       string           `json:"mode"`
+	Operations []BatchOperation `json:"operations" binding:"required"`
+}
+
+// BatchOperation creates Item, applies Update to the item ItemId, deletes the item ItemId
+// or runs one of the list-wide operations. With Version set an update or a delete only
+// applies to that version of the item.
+type BatchOperation struct {
+	Op      string           `json:"op"`
+	ItemId  int              `json:"id"`
+	Item    *TodoItem        `json:"item"`
+	Update  *UpdateItemInput `json:"update"`
+	Version *int             `json:"version"`
+}
+
+// BatchResult is the outcome of the operation with the same index. Id is the item created,
+// updated or deleted and Count the number of items a list-wide operation changed.
+type BatchResult struct {
+	Op     string `json:"op"`
+	Id     int    `json:"id,omitempty"`
+	Count  int    `json:"count,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (i BatchInput) Validate() error {
+	if i.Mode != "" && i.Mode != BatchAtomic && i.Mode != BatchBestEffort {
+		return fmt.Errorf("%w: mode must be %s or %s", ErrValidation, BatchAtomic, BatchBestEffort)
+	}
+
+	if len(i.Operations) == 0 || len(i.Operations) > MaxBatchOperations {
+		return fmt.Errorf("%w: a batch holds 1 to %d operations", ErrValidation, MaxBatchOperations)
+	}
+
+	for index, op := range i.Operations {
+		if err := op.Validate(); err != nil {
+			return fmt.Errorf("operation %d: %w", index, err)
+		}
+	}
+
+	return nil
+}
+
+func (o BatchOperation) Validate() error {
+	switch o.Op {
+	case BatchCreate:
+		if o.Item == nil || strings.TrimSpace(o.Item.Title) == "" {
+			return fmt.Errorf("%w: create needs an item with a title", ErrValidation)
+		}
+	case BatchUpdate:
+		if o.ItemId <= 0 || o.Update == nil {
+			return fmt.Errorf("%w: update needs an id and an update", ErrValidation)
+		}
+		return o.Update.Validate()
+	case BatchDelete:
+		if o.ItemId <= 0 {
+			return fmt.Errorf("%w: delete needs an id", ErrValidation)
+		}
+	case BatchCompleteAll, BatchClearCompleted:
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrValidation, o.Op)
+	}
+
+	return nil
+}
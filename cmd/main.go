@@ -6,10 +6,12 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"os"
+	"time"
 	todo "todo-app"
 	"todo-app/pkg/handler"
 	"todo-app/pkg/repository"
 	"todo-app/pkg/service"
+	"todo-app/pkg/storage"
 )
 
 func main() {
@@ -35,15 +37,92 @@ func main() {
 	}
 
 	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
+	var keys []service.KeyConfig
+	if err := viper.UnmarshalKey("auth.keys", &keys); err != nil {
+		logrus.Fatalf("error reading jwt keys config: %s", err.Error())
+	}
+
+	services, err := service.NewService(repos, service.Config{
+		Password: service.PasswordConfig{
+			Algorithm:     viper.GetString("auth.password.algorithm"),
+			BcryptCost:    viper.GetInt("auth.password.bcrypt_cost"),
+			Argon2Time:    viper.GetUint32("auth.password.argon2_time"),
+			Argon2Memory:  viper.GetUint32("auth.password.argon2_memory"),
+			Argon2Threads: uint8(viper.GetUint("auth.password.argon2_threads")),
+		},
+		Token: service.TokenConfig{
+			AccessTTL:  viper.GetDuration("auth.access_token_ttl"),
+			RefreshTTL: viper.GetDuration("auth.refresh_token_ttl"),
+			Issuer:     viper.GetString("auth.issuer"),
+			Audience:   viper.GetString("auth.audience"),
+			Leeway:     viper.GetDuration("auth.leeway"),
+			Keys:       keys,
+		},
+		Trash: service.TrashConfig{
+			Retention: viper.GetDuration("trash.retention"),
+		},
+		Attachments: service.AttachmentConfig{
+			MaxSize: viper.GetInt64("attachments.max_size"),
+			Quota:   viper.GetInt64("attachments.quota"),
+		},
+		Storage: storageConfig(),
+	})
+	if err != nil {
+		logrus.Fatalf("failed to initialize services: %s", err.Error())
+	}
 	handlers := handler.NewHandler(services)
 
+	go purgeTrash(services.Trash, services.Attachment, viper.GetDuration("trash.purge_interval"))
+
 	srv := new(todo.Server)
 	if err := srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil {
 		logrus.Fatalf("error occured while running http server: %s", err.Error())
 	}
 }
 
+// purgeTrash permanently removes what has outlived the trash retention and the
+// attachments of what it removed, once at start and then every interval.
+func purgeTrash(trash service.Trash, attachments service.Attachment, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := trash.Purge()
+		if err != nil {
+			logrus.Errorf("error purging trash: %s", err.Error())
+		} else if purged > 0 {
+			logrus.Infof("purged %d lists and items from trash", purged)
+		}
+
+		removed, err := attachments.DeleteDetached()
+		if err != nil {
+			logrus.Errorf("error removing detached attachments: %s", err.Error())
+		} else if removed > 0 {
+			logrus.Infof("removed %d detached attachments", removed)
+		}
+
+		<-ticker.C
+	}
+}
+
+func storageConfig() storage.Config {
+	return storage.Config{
+		Driver: viper.GetString("storage.driver"),
+		Dir:    viper.GetString("storage.dir"),
+		S3: storage.S3Config{
+			Endpoint:  viper.GetString("storage.s3.endpoint"),
+			Region:    viper.GetString("storage.s3.region"),
+			Bucket:    viper.GetString("storage.s3.bucket"),
+			AccessKey: viper.GetString("storage.s3.access_key"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		},
+	}
+}
+
 func initConfig() error {
 	viper.AddConfigPath("configs")
 	viper.SetConfigName("config")
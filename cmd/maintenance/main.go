@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"os"
+	"todo-app/pkg/repository"
+	"todo-app/pkg/service"
+	"todo-app/pkg/storage"
+)
+
+// The maintenance command reports the lists no user has access to and the items in
+// no list. With -clean it removes them as well, along with the attachments of every
+// removed item.
+func main() {
+	clean := flag.Bool("clean", false, "remove the orphaned lists and items")
+	flag.Parse()
+
+	logrus.SetFormatter(new(logrus.JSONFormatter))
+	if err := initConfig(); err != nil {
+		logrus.Fatalf("error initializing configs: %s", err.Error())
+	}
+
+	if err := godotenv.Load(); err != nil {
+		logrus.Fatalf("error loading env variables: %s", err.Error())
+	}
+
+	db, err := repository.NewPostgresDB(repository.Config{
+		Host:     viper.GetString("db.host"),
+		Port:     viper.GetString("db.port"),
+		Username: viper.GetString("db.username"),
+		DBName:   viper.GetString("db.dbname"),
+		SSLMode:  viper.GetString("db.sslmode"),
+		Password: os.Getenv("DB_PASSWORD"),
+	})
+	if err != nil {
+		logrus.Fatalf("failed to initialize db: %s", err.Error())
+	}
+	defer db.Close()
+
+	orphans := service.NewOrphanService(repository.NewOrphanPostgres(db))
+
+	found, err := orphans.Count()
+	if err != nil {
+		logrus.Fatalf("error counting orphans: %s", err.Error())
+	}
+	logrus.Infof("found %d orphaned lists and %d orphaned items", found.Lists, found.Items)
+
+	if !*clean {
+		return
+	}
+
+	removed, err := orphans.Delete()
+	if err != nil {
+		logrus.Fatalf("error removing orphans: %s", err.Error())
+	}
+	logrus.Infof("removed %d orphaned lists and %d orphaned items", removed.Lists, removed.Items)
+
+	blobs, err := storage.NewBlobStore(storageConfig())
+	if err != nil {
+		logrus.Fatalf("failed to initialize blob store: %s", err.Error())
+	}
+
+	attachments := service.NewAttachmentService(repository.NewAttachmentPostgres(db), repository.NewListMemberPostgres(db),
+		blobs, service.AttachmentConfig{})
+
+	detached, err := attachments.DeleteDetached()
+	if err != nil {
+		logrus.Fatalf("error removing detached attachments: %s", err.Error())
+	}
+	logrus.Infof("removed %d detached attachments", detached)
+}
+
+func storageConfig() storage.Config {
+	return storage.Config{
+		Driver: viper.GetString("storage.driver"),
+		Dir:    viper.GetString("storage.dir"),
+		S3: storage.S3Config{
+			Endpoint:  viper.GetString("storage.s3.endpoint"),
+			Region:    viper.GetString("storage.s3.region"),
+			Bucket:    viper.GetString("storage.s3.bucket"),
+			AccessKey: viper.GetString("storage.s3.access_key"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		},
+	}
+}
+
+func initConfig() error {
+	viper.AddConfigPath("configs")
+	viper.SetConfigName("config")
+	return viper.ReadInConfig()
+}
@@ -0,0 +1,38 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const MaxCommentLength = 4000
+
+// Comment is a note a list member left on an item. UpdatedAt is set once the author
+// edits the comment.
+type Comment struct {
+	Id        int        `json:"id" db:"id"`
+	ItemId    int        `json:"item_id" db:"item_id"`
+	UserId    int        `json:"user_id" db:"user_id"`
+	Author    string     `json:"author" db:"author"`
+	Body      string     `json:"body" db:"body"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+type CommentInput struct {
+	Body string `json:"body" binding:"required"`
+}
+
+func (i CommentInput) Validate() error {
+	if strings.TrimSpace(i.Body) == "" {
+		return fmt.Errorf("%w: comment body must not be empty", ErrValidation)
+	}
+
+	if utf8.RuneCountInString(i.Body) > MaxCommentLength {
+		return fmt.Errorf("%w: comment body must be at most %d characters", ErrValidation, MaxCommentLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package todo
+
+import "errors"
+
+// Domain errors shared by every layer. Repositories and services wrap them with
+// fmt.Errorf("%w: ...") to add detail; handlers pick the HTTP status with errors.Is.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrForbidden  = errors.New("forbidden")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrTooLarge   = errors.New("too large")
+	// ErrPreconditionFailed means the resource changed since the version the client sent.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
@@ -0,0 +1,55 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const DefaultLabelColor = "#808080"
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Label is a user's own tag for items. Labels are private: on a shared list every
+// member only sees the labels they attached themselves.
+type Label struct {
+	Id    int    `json:"id" db:"id"`
+	Name  string `json:"name" db:"name" binding:"required"`
+	Color string `json:"color" db:"color"`
+}
+
+func (l Label) Validate() error {
+	if l.Color != "" {
+		return validateLabelColor(l.Color)
+	}
+
+	return nil
+}
+
+type UpdateLabelInput struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+func (i UpdateLabelInput) Validate() error {
+	if i.Name == nil && i.Color == nil {
+		return fmt.Errorf("%w: update structure has no values", ErrValidation)
+	}
+
+	if i.Name != nil && *i.Name == "" {
+		return fmt.Errorf("%w: label name must not be empty", ErrValidation)
+	}
+
+	if i.Color != nil {
+		return validateLabelColor(*i.Color)
+	}
+
+	return nil
+}
+
+func validateLabelColor(color string) error {
+	if !labelColorPattern.MatchString(color) {
+		return fmt.Errorf("%w: color must be a hex value like #1e90ff", ErrValidation)
+	}
+
+	return nil
+}
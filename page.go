@@ -0,0 +1,80 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// SortFields are the columns collections can be ordered by. A leading "-" in the
+// sort query parameter reverses the order, e.g. sort=-created_at. Collections are
+// in their manual order, by position, unless sorted otherwise.
+var SortFields = []string{"position", "id", "title", "created_at"}
+
+// ItemSortFields are the SortFields of items. Sorting by priority puts the most
+// urgent items first and, within a priority, the ones due soonest.
+var ItemSortFields = append(SortFields[:len(SortFields):len(SortFields)], "priority")
+
+// PageQuery selects one page of a collection. Cursor is the opaque next_cursor
+// value of the previous page and is only valid together with the same Sort.
+type PageQuery struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+	Sort   string `form:"sort"`
+}
+
+func (q PageQuery) Validate() error {
+	return q.validate(SortFields)
+}
+
+func (q PageQuery) validate(sortFields []string) error {
+	if q.Limit < 0 || q.Limit > MaxPageLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxPageLimit)
+	}
+
+	if q.Sort == "" {
+		return nil
+	}
+
+	field := strings.TrimPrefix(q.Sort, "-")
+	for _, sortField := range sortFields {
+		if field == sortField {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: sort must be one of %s", ErrValidation, strings.Join(sortFields, ", "))
+}
+
+// ListsQuery selects the user's lists, or their templates with Template set.
+type ListsQuery struct {
+	PageQuery
+	Title    string `form:"title"`
+	Template bool   `form:"-"`
+}
+
+// AssignedToMe is the only value of ItemsQuery.Assigned: items assigned to the user.
+const AssignedToMe = "me"
+
+// ItemsQuery selects items of a list. With Tree set, filters and pages apply to
+// top-level items, which come with their whole subtree of subtasks.
+type ItemsQuery struct {
+	PageQuery
+	Title    string `form:"title"`
+	Done     *bool  `form:"done"`
+	Label    int    `form:"label"`
+	Tree     bool   `form:"tree"`
+	Assigned string `form:"assigned"`
+}
+
+func (q ItemsQuery) Validate() error {
+	if q.Assigned != "" && q.Assigned != AssignedToMe {
+		return fmt.Errorf("%w: assigned must be me", ErrValidation)
+	}
+
+	return q.PageQuery.validate(ItemSortFields)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	todo "todo-app"
+)
+
+type getActivityResponse struct {
+	Data       []todo.Activity `json:"data"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func (h *Handler) getListActivity(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, "user unauthorized")
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid id param")
+		return
+	}
+
+	var input todo.ActivityQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query params")
+		return
+	}
+
+	activity, next, err := h.services.Activity.GetAll(userId, listId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getActivityResponse{
+		Data:       activity,
+		NextCursor: next,
+	})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestHandler_getListActivity(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockActivity, userId int)
+
+	createdAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		path                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			path:   "/api/lists/2/activity?limit=1",
+			mockBehavior: func(s *mock_service.MockActivity, userId int) {
+				s.EXPECT().GetAll(userId, 2, todo.ActivityQuery{Limit: 1}).Return([]todo.Activity{
+					{
+						Id: 9, ListId: 2, ActorId: 1, ActorName: "Alice", Action: todo.ActivityUpdated,
+						EntityType: todo.ActivityEntityItem, EntityId: 7, CreatedAt: createdAt,
+						Changes: todo.Changes{"done": {Before: false, After: true}},
+					},
+				}, "next", nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"data":[{"id":9,"list_id":2,"actor_id":1,"actor_name":"Alice","action":"updated",` +
+				`"entity_type":"item","entity_id":7,"changes":{"done":{"before":false,"after":true}},` +
+				`"created_at":"2023-05-01T10:00:00Z"}],"next_cursor":"next"}`,
+		},
+		{
+			name:                "Invalid Id",
+			userId:              1,
+			path:                "/api/lists/abc/activity",
+			mockBehavior:        func(s *mock_service.MockActivity, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+		{
+			name:                "Invalid Query",
+			userId:              1,
+			path:                "/api/lists/2/activity?limit=abc",
+			mockBehavior:        func(s *mock_service.MockActivity, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid query params"}`,
+		},
+		{
+			name:   "Forbidden",
+			userId: 1,
+			path:   "/api/lists/2/activity",
+			mockBehavior: func(s *mock_service.MockActivity, userId int) {
+				s.EXPECT().GetAll(userId, 2, todo.ActivityQuery{}).Return(nil, "", todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:                "No Principal",
+			path:                "/api/lists/2/activity",
+			mockBehavior:        func(s *mock_service.MockActivity, userId int) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			activity := mock_service.NewMockActivity(c)
+			testCase.mockBehavior(activity, testCase.userId)
+
+			services := &service.Service{Activity: activity}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/lists/:id/activity", setPrincipal(testCase.userId), handler.getListActivity)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.path, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
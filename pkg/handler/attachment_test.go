@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestAttachment_UploadAttachment(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAttachment, userId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		field               string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			field:  "file",
+			mockBehavior: func(s *mock_service.MockAttachment, userId int) {
+				s.EXPECT().Upload(userId, 2, "shot.png", int64(5), gomock.Any()).
+					DoAndReturn(func(userId, itemId int, filename string, size int64, r io.Reader) (int, error) {
+						content, err := io.ReadAll(r)
+						assert.NoError(t, err)
+						assert.Equal(t, "hello", string(content))
+						return 3, nil
+					})
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":3}`,
+		},
+		{
+			name:                "No File",
+			userId:              1,
+			field:               "upload",
+			mockBehavior:        func(s *mock_service.MockAttachment, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:   "Quota Exceeded",
+			userId: 1,
+			field:  "file",
+			mockBehavior: func(s *mock_service.MockAttachment, userId int) {
+				s.EXPECT().Upload(userId, 2, "shot.png", int64(5), gomock.Any()).
+					Return(0, fmt.Errorf("%w: storage quota of 4 bytes exceeded", todo.ErrTooLarge))
+			},
+			expectedStatusCode:  413,
+			expectedRequestBody: `{"message":"too large: storage quota of 4 bytes exceeded"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			attachments := mock_service.NewMockAttachment(c)
+			testCase.mockBehavior(attachments, testCase.userId)
+
+			services := &service.Service{Attachment: attachments}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/items/:id/attachments", setPrincipal(testCase.userId), handler.uploadAttachment)
+
+			// Test Request
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, _ := form.CreateFormFile(testCase.field, "shot.png")
+			part.Write([]byte("hello"))
+			form.Close()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/items/2/attachments", &body)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestAttachment_DownloadAttachment(t *testing.T) {
+	// Init Deps
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	attachments := mock_service.NewMockAttachment(c)
+	attachments.EXPECT().Get(1, 3).Return(todo.Attachment{Id: 3, Filename: "report final.pdf", Size: 5,
+		ContentType: "application/pdf"}, io.NopCloser(strings.NewReader("%PDF-")), nil)
+
+	services := &service.Service{Attachment: attachments}
+	handler := NewHandler(services)
+
+	// Test Server
+	r := gin.New()
+	r.GET("/api/attachments/:id", setPrincipal(1), handler.downloadAttachment)
+
+	// Test Request
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/attachments/3", nil)
+
+	// Perform Request
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "%PDF-", w.Body.String())
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="report final.pdf"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+}
@@ -110,10 +110,11 @@ func TestHandler_signIn(t *testing.T) {
 				Password: "qwerty",
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, input signInInput) {
-				s.EXPECT().GenerateToken(input.Username, input.Password).Return("token", nil)
+				s.EXPECT().GenerateToken(input.Username, input.Password).
+					Return(todo.Tokens{AccessToken: "token", RefreshToken: "refresh"}, nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `{"token":"token"}`,
+			expectedRequestBody: `{"token":"token","refresh_token":"refresh"}`,
 		},
 		{
 			name:      "Incorrect password",
@@ -124,7 +125,7 @@ func TestHandler_signIn(t *testing.T) {
 			},
 			mockBehavior: func(s *mock_service.MockAuthorization, input signInInput) {
 				s.EXPECT().GenerateToken(input.Username, input.Password).
-					Return("", errors.New("incorrect login or password"))
+					Return(todo.Tokens{}, service.ErrIncorrectCredentials)
 			},
 			expectedStatusCode:  401,
 			expectedRequestBody: `{"message":"incorrect login or password"}`,
@@ -136,20 +137,20 @@ func TestHandler_signIn(t *testing.T) {
 			expectedStatusCode:  400,
 			expectedRequestBody: `{"message":"invalid input body"}`,
 		},
-		//{
-		//	name:      "Service Failure",
-		//	inputBody: `{"username":"test", "password":"qwerty"}`,
-		//	inputUser: signInInput{
-		//		Username: "test",
-		//		Password: "qwerty",
-		//	},
-		//	mockBehavior: func(s *mock_service.MockAuthorization, input signInInput) {
-		//		s.EXPECT().GenerateToken(input.Username, input.Password).
-		//			Return("", errors.New("service failure"))
-		//	},
-		//	expectedStatusCode:  500,
-		//	expectedRequestBody: `{"message":"service failure"}`,
-		//},
+		{
+			name:      "Repository Failure",
+			inputBody: `{"username":"test", "password":"qwerty"}`,
+			inputUser: signInInput{
+				Username: "test",
+				Password: "qwerty",
+			},
+			mockBehavior: func(s *mock_service.MockAuthorization, input signInInput) {
+				s.EXPECT().GenerateToken(input.Username, input.Password).
+					Return(todo.Tokens{}, errors.New("connection refused"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"connection refused"}`,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -181,3 +182,218 @@ func TestHandler_signIn(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_refresh(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAuthorization, refreshToken string)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		refreshToken        string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:         "OK",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(refreshToken).
+					Return(todo.Tokens{AccessToken: "token", RefreshToken: "next"}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"token":"token","refresh_token":"next"}`,
+		},
+		{
+			name:         "Reused Token",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().RefreshToken(refreshToken).
+					Return(todo.Tokens{}, errors.New("refresh token reuse detected, session revoked"))
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"refresh token reuse detected, session revoked"}`,
+		},
+		{
+			name:                "Empty Fields",
+			inputBody:           `{}`,
+			mockBehavior:        func(s *mock_service.MockAuthorization, refreshToken string) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, testCase.refreshToken)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/auth/refresh", handler.refresh)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/auth/refresh", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedRequestBody)
+		})
+	}
+}
+
+func TestHandler_logout(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAuthorization, refreshToken string)
+
+	testTable := []struct {
+		name                string
+		inputBody           string
+		refreshToken        string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:         "OK",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().Logout(refreshToken).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:         "Unknown Token",
+			inputBody:    `{"refresh_token":"refresh"}`,
+			refreshToken: "refresh",
+			mockBehavior: func(s *mock_service.MockAuthorization, refreshToken string) {
+				s.EXPECT().Logout(refreshToken).Return(errors.New("invalid refresh token"))
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"invalid refresh token"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, testCase.refreshToken)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/auth/logout", handler.logout)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/auth/logout", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedRequestBody)
+		})
+	}
+}
+
+func TestHandler_logoutAll(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockAuthorization, userId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 3,
+			mockBehavior: func(s *mock_service.MockAuthorization, userId int) {
+				s.EXPECT().LogoutAll(userId).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "No Principal",
+			mockBehavior:        func(s *mock_service.MockAuthorization, userId int) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"unauthorized user"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			auth := mock_service.NewMockAuthorization(c)
+			testCase.mockBehavior(auth, testCase.userId)
+
+			services := &service.Service{Authorization: auth}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/auth/logout-all", setPrincipal(testCase.userId), handler.logoutAll)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/auth/logout-all", nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, w.Code, testCase.expectedStatusCode)
+			assert.Equal(t, w.Body.String(), testCase.expectedRequestBody)
+		})
+	}
+}
+
+func TestHandler_jwks(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	auth := mock_service.NewMockAuthorization(c)
+	auth.EXPECT().JWKS().Return(todo.JSONWebKeySet{Keys: []todo.JSONWebKey{
+		{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "key"},
+	}})
+
+	handler := NewHandler(&service.Service{Authorization: auth})
+
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", handler.jwks)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, w.Code, 200)
+	assert.Equal(t, w.Body.String(), `{"keys":[{"kty":"OKP","kid":"ed","use":"sig","alg":"EdDSA","crv":"Ed25519","x":"key"}]}`)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestItem_BatchItems(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, listId int, input todo.BatchInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		listId              int
+		input               todo.BatchInput
+		inputString         string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			listId: 3,
+			input: todo.BatchInput{Mode: todo.BatchBestEffort, Operations: []todo.BatchOperation{
+				{Op: todo.BatchCreate, Item: &todo.TodoItem{Title: "buy milk"}},
+				{Op: todo.BatchDelete, ItemId: 8},
+				{Op: todo.BatchClearCompleted},
+			}},
+			inputString: `{"mode":"best_effort","operations":[{"op":"create","item":{"title":"buy milk"}},` +
+				`{"op":"delete","id":8},{"op":"clear_completed"}]}`,
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.BatchInput) {
+				s.EXPECT().Batch(userId, listId, input).Return([]todo.BatchResult{
+					{Op: todo.BatchCreate, Id: 9, Status: todo.BatchOk},
+					{Op: todo.BatchDelete, Id: 8, Status: todo.BatchFailed, Error: "not found"},
+					{Op: todo.BatchClearCompleted, Count: 2, Status: todo.BatchOk},
+				}, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"data":[{"op":"create","id":9,"status":"ok"},{"op":"delete","id":8,"status":"failed","error":"not found"},` +
+				`{"op":"clear_completed","count":2,"status":"ok"}]}`,
+		},
+		{
+			name:                "No Header",
+			mockBehavior:        func(s *mock_service.MockTodoItem, userId, listId int, input todo.BatchInput) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"unauthorized user"}`,
+		},
+		{
+			name:                "No Operations",
+			userId:              1,
+			listId:              3,
+			inputString:         `{"mode":"atomic"}`,
+			mockBehavior:        func(s *mock_service.MockTodoItem, userId, listId int, input todo.BatchInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:   "Operation Failed",
+			userId: 1,
+			listId: 3,
+			input: todo.BatchInput{Operations: []todo.BatchOperation{
+				{Op: todo.BatchDelete, ItemId: 8},
+			}},
+			inputString: `{"operations":[{"op":"delete","id":8}]}`,
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.BatchInput) {
+				s.EXPECT().Batch(userId, listId, input).Return(nil, fmt.Errorf("operation 0: %w", todo.ErrNotFound))
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"operation 0: not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.listId, testCase.input)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/lists/:id/items/batch", setPrincipal(testCase.userId), handler.batchItems)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/lists/%d/items/batch", testCase.listId),
+				bytes.NewBufferString(testCase.inputString))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestComment_CreateComment(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockComment, userId int, input todo.CommentInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		path                string
+		inputBody           string
+		input               todo.CommentInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			path:      "/api/items/2/comments",
+			inputBody: `{"body":"on it"}`,
+			input:     todo.CommentInput{Body: "on it"},
+			mockBehavior: func(s *mock_service.MockComment, userId int, input todo.CommentInput) {
+				s.EXPECT().Create(userId, 2, input).Return(5, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":5}`,
+		},
+		{
+			name:                "Empty Body",
+			userId:              1,
+			path:                "/api/items/2/comments",
+			inputBody:           `{}`,
+			mockBehavior:        func(s *mock_service.MockComment, userId int, input todo.CommentInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Blank Body",
+			userId:    1,
+			path:      "/api/items/2/comments",
+			inputBody: `{"body":"  "}`,
+			input:     todo.CommentInput{Body: "  "},
+			mockBehavior: func(s *mock_service.MockComment, userId int, input todo.CommentInput) {
+				s.EXPECT().Create(userId, 2, input).Return(0, input.Validate())
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: comment body must not be empty"}`,
+		},
+		{
+			name:      "Item Not Found",
+			userId:    1,
+			path:      "/api/items/2/comments",
+			inputBody: `{"body":"on it"}`,
+			input:     todo.CommentInput{Body: "on it"},
+			mockBehavior: func(s *mock_service.MockComment, userId int, input todo.CommentInput) {
+				s.EXPECT().Create(userId, 2, input).Return(0, todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+		{
+			name:                "Invalid Id",
+			userId:              1,
+			path:                "/api/items/abc/comments",
+			inputBody:           `{"body":"on it"}`,
+			mockBehavior:        func(s *mock_service.MockComment, userId int, input todo.CommentInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			comments := mock_service.NewMockComment(c)
+			testCase.mockBehavior(comments, testCase.userId, testCase.input)
+
+			services := &service.Service{Comment: comments}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/items/:id/comments", setPrincipal(testCase.userId), handler.createComment)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", testCase.path, bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestComment_DeleteComment(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockComment, userId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockComment, userId int) {
+				s.EXPECT().Delete(userId, 5).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:   "Not Author Or Owner",
+			userId: 3,
+			mockBehavior: func(s *mock_service.MockComment, userId int) {
+				s.EXPECT().Delete(userId, 5).Return(todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:                "No Principal",
+			mockBehavior:        func(s *mock_service.MockComment, userId int) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			comments := mock_service.NewMockComment(c)
+			testCase.mockBehavior(comments, testCase.userId)
+
+			services := &service.Service{Comment: comments}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.DELETE("/api/comments/:id", setPrincipal(testCase.userId), handler.deleteComment)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/api/comments/5", nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
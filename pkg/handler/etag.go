@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// notModified tags the response with the version of the resource as its ETag and answers
+// 304 when the client's If-None-Match already holds that version.
+func notModified(c *gin.Context, version int) bool {
+	etag := fmt.Sprintf(`"%d"`, version)
+	c.Header("ETag", etag)
+
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		// If-None-Match compares weakly, so W/"3" matches "3" as well
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatchVersion returns the version in the If-Match header, or nil without one or for *.
+// It answers 412 and returns false when the header holds no version it can match.
+func ifMatchVersion(c *gin.Context) (*int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	quoted := len(header) > 1 && strings.HasPrefix(header, `"`) && strings.HasSuffix(header, `"`)
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if !quoted || err != nil {
+		newErrorResponse(c, http.StatusPreconditionFailed, "precondition failed")
+		return nil, false
+	}
+
+	return &version, true
+}
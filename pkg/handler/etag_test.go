@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestList_GetListById_ETag(t *testing.T) {
+	testTable := []struct {
+		name                string
+		ifNoneMatch         string
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:                "Changed",
+			ifNoneMatch:         `"2"`,
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":1,"title":"title","description":"","version":3}`,
+		},
+		{
+			name:               "Not Modified",
+			ifNoneMatch:        `"2", W/"3"`,
+			expectedStatusCode: 304,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoList := mock_service.NewMockTodoList(c)
+			todoList.EXPECT().GetById(7, 1).Return(todo.TodoList{Id: 1, Title: "title", Version: 3}, nil)
+
+			services := &service.Service{TodoList: todoList}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/lists/:id", setPrincipal(7), handler.getListById)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/lists/1", nil)
+			req.Header.Set("If-None-Match", testCase.ifNoneMatch)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestItem_GetItemById_ETagAfterLabel(t *testing.T) {
+	// Init Deps
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	todoItem := mock_service.NewMockTodoItem(c)
+	label := mock_service.NewMockLabel(c)
+
+	// attaching a label bumps the version of the item, which is its ETag
+	gomock.InOrder(
+		todoItem.EXPECT().GetById(7, 2).Return(todo.TodoItem{Id: 2, Title: "title", Version: 3}, nil),
+		label.EXPECT().Attach(7, 2, 4).Return(nil),
+		todoItem.EXPECT().GetById(7, 2).
+			Return(todo.TodoItem{Id: 2, Title: "title", Version: 4, Labels: []todo.Label{{Id: 4, Name: "home"}}}, nil),
+	)
+
+	services := &service.Service{TodoItem: todoItem, Label: label}
+	handler := NewHandler(services)
+
+	// Test Server
+	r := gin.New()
+	r.GET("/api/items/:id", setPrincipal(7), handler.getItemById)
+	r.PUT("/api/items/:id/labels/:label_id", setPrincipal(7), handler.attachLabel)
+
+	// Test Request
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/items/2", nil))
+	etag := w.Header().Get("ETag")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/api/items/2/labels/4", nil))
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/items/2", nil)
+	req.Header.Set("If-None-Match", etag)
+
+	// Perform Request
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, `"3"`, etag)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+	assert.Equal(t, `{"id":2,"title":"title","description":"","done":false,"comment_count":0,"version":4,`+
+		`"labels":[{"id":4,"name":"home","color":""}]}`, w.Body.String())
+}
+
+func TestItem_UpdateItem_IfMatch(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem)
+
+	testTable := []struct {
+		name                string
+		ifMatch             string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			ifMatch: `"3"`,
+			mockBehavior: func(s *mock_service.MockTodoItem) {
+				s.EXPECT().Update(7, 2, todo.UpdateItemInput{Title: stringPointer("title"), Version: intPointer(3)}).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:    "Any Version",
+			ifMatch: "*",
+			mockBehavior: func(s *mock_service.MockTodoItem) {
+				s.EXPECT().Update(7, 2, todo.UpdateItemInput{Title: stringPointer("title")}).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:    "Stale Version",
+			ifMatch: `"3"`,
+			mockBehavior: func(s *mock_service.MockTodoItem) {
+				s.EXPECT().Update(7, 2, todo.UpdateItemInput{Title: stringPointer("title"), Version: intPointer(3)}).
+					Return(fmt.Errorf("%w: version is 4", todo.ErrPreconditionFailed))
+			},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"message":"precondition failed: version is 4"}`,
+		},
+		{
+			name:                "Weak Tag",
+			ifMatch:             `W/"3"`,
+			mockBehavior:        func(s *mock_service.MockTodoItem) {},
+			expectedStatusCode:  412,
+			expectedRequestBody: `{"message":"precondition failed"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.PUT("/api/items/:id", setPrincipal(7), handler.updateItem)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/api/items/2", bytes.NewBufferString(`{"title":"title"}`))
+			req.Header.Set("If-Match", testCase.ifMatch)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestList_DeleteList_IfMatch(t *testing.T) {
+	// Init Deps
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	todoList := mock_service.NewMockTodoList(c)
+	todoList.EXPECT().Delete(7, 1, intPointer(5)).Return(nil)
+
+	services := &service.Service{TodoList: todoList}
+	handler := NewHandler(services)
+
+	// Test Server
+	r := gin.New()
+	r.DELETE("/api/lists/:id", setPrincipal(7), handler.deleteList)
+
+	// Test Request
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/api/lists/1", nil)
+	req.Header.Set("If-Match", `"5"`)
+
+	// Perform Request
+	r.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+}
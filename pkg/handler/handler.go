@@ -18,34 +18,127 @@ func (h *Handler) InitRoutes() *gin.Engine {
 
 	//router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	router.GET("/.well-known/jwks.json", h.jwks)
+
 	auth := router.Group("/auth")
 	{
 		auth.POST("/sign-up", h.signUp)
 		auth.POST("/sign-in", h.signIn)
+		auth.POST("/refresh", h.refresh)
+		auth.POST("/logout", h.logout)
+		auth.POST("/logout-all", h.userIdentity, h.logoutAll)
 	}
 
 	api := router.Group("/api", h.userIdentity)
 	{
+		api.GET("/search", h.search)
+		api.GET("/due/:window", h.getDueItems)
+
+		me := api.Group("/me")
+		{
+			me.GET("/assigned", h.getAssignedItems)
+		}
+
 		lists := api.Group("/lists")
 		{
 			lists.POST("/", h.createList)
 			lists.GET("/", h.getAllLists)
 			lists.GET("/:id", h.getListById)
 			lists.PUT("/:id", h.updateList)
+			lists.POST("/:id/move", h.moveList)
 			lists.DELETE("/:id", h.deleteList)
+			lists.GET("/:id/activity", h.getListActivity)
+			lists.POST("/:id/clone", h.cloneList)
 
 			items := lists.Group("/:id/items")
 			{
 				items.POST("/", h.createItem)
 				items.GET("/", h.getAllItems)
+				items.POST("/batch", h.batchItems)
+			}
+
+			members := lists.Group("/:id/members")
+			{
+				members.GET("/", h.getAllMembers)
+				members.POST("/", h.addMember)
+				members.PUT("/:user_id", h.updateMember)
+				members.DELETE("/:user_id", h.removeMember)
 			}
 		}
 
+		templates := api.Group("/templates")
+		{
+			templates.GET("/", h.getAllTemplates)
+			templates.POST("/:id/instantiate", h.instantiateTemplate)
+		}
+
 		items := api.Group("/items")
 		{
 			items.GET("/:id", h.getItemById)
 			items.PUT("/:id", h.updateItem)
+			items.POST("/:id/move", h.moveItem)
+			items.POST("/:id/transfer", h.transferItem)
+			items.POST("/:id/copy", h.copyItem)
 			items.DELETE("/:id", h.deleteItem)
+
+			subtasks := items.Group("/:id/subtasks")
+			{
+				subtasks.POST("/", h.createSubtask)
+				subtasks.GET("/", h.getSubtasks)
+			}
+
+			itemLists := items.Group("/:id/lists")
+			{
+				itemLists.GET("/", h.getItemLists)
+				itemLists.PUT("/:list_id", h.linkItem)
+				itemLists.DELETE("/:list_id", h.unlinkItem)
+			}
+
+			itemLabels := items.Group("/:id/labels")
+			{
+				itemLabels.PUT("/:label_id", h.attachLabel)
+				itemLabels.DELETE("/:label_id", h.detachLabel)
+			}
+
+			itemComments := items.Group("/:id/comments")
+			{
+				itemComments.POST("/", h.createComment)
+				itemComments.GET("/", h.getAllComments)
+			}
+
+			itemAttachments := items.Group("/:id/attachments")
+			{
+				itemAttachments.POST("/", h.uploadAttachment)
+				itemAttachments.GET("/", h.getAllAttachments)
+			}
+		}
+
+		comments := api.Group("/comments")
+		{
+			comments.PUT("/:id", h.updateComment)
+			comments.DELETE("/:id", h.deleteComment)
+		}
+
+		attachments := api.Group("/attachments")
+		{
+			attachments.GET("/:id", h.downloadAttachment)
+			attachments.DELETE("/:id", h.deleteAttachment)
+		}
+
+		labels := api.Group("/labels")
+		{
+			labels.POST("/", h.createLabel)
+			labels.GET("/", h.getAllLabels)
+			labels.GET("/:id", h.getLabelById)
+			labels.PUT("/:id", h.updateLabel)
+			labels.DELETE("/:id", h.deleteLabel)
+			labels.GET("/:id/items", h.getLabelItems)
+		}
+
+		trash := api.Group("/trash")
+		{
+			trash.GET("/", h.getTrash)
+			trash.POST("/:type/:id/restore", h.restoreFromTrash)
 		}
 	}
 
@@ -7,9 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
-	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	todo "todo-app"
 	"todo-app/pkg/service"
 	mock_service "todo-app/pkg/service/mocks"
@@ -20,7 +20,6 @@ func TestItem_CreateItem(t *testing.T) {
 
 	testTable := []struct {
 		name                string
-		headerValue         string
 		userId              int
 		listId              int
 		item                todo.TodoItem
@@ -30,10 +29,9 @@ func TestItem_CreateItem(t *testing.T) {
 		expectedRequestBody string
 	}{
 		{
-			name:        "OK",
-			headerValue: "12",
-			userId:      12,
-			listId:      14,
+			name:   "OK",
+			userId: 12,
+			listId: 14,
 			item: todo.TodoItem{
 				Title:       "test title",
 				Description: "test description",
@@ -54,7 +52,6 @@ func TestItem_CreateItem(t *testing.T) {
 		},
 		{
 			name:                "Empty Fields",
-			headerValue:         "12",
 			userId:              12,
 			listId:              14,
 			itemBody:            `{"title":"","description":"test description","done":false}`,
@@ -63,10 +60,9 @@ func TestItem_CreateItem(t *testing.T) {
 			expectedRequestBody: `{"message":"invalid input body"}`,
 		},
 		{
-			name:        "Service Failure",
-			headerValue: "12",
-			userId:      12,
-			listId:      14,
+			name:   "Service Failure",
+			userId: 12,
+			listId: 14,
 			item: todo.TodoItem{
 				Title:       "test title",
 				Description: "test description",
@@ -95,12 +91,92 @@ func TestItem_CreateItem(t *testing.T) {
 
 			// Test Server
 			r := gin.New()
-			r.POST("/api/lists/:id/items", handler.createItem)
+			r.POST("/api/lists/:id/items", setPrincipal(testCase.userId), handler.createItem)
 
 			// Test Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("POST", fmt.Sprintf("/api/lists/%d/items", testCase.listId), bytes.NewBufferString(testCase.itemBody))
-			req.AddCookie(&http.Cookie{Name: userCtx, Value: testCase.headerValue})
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestItem_CreateSubtask(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, parentId int, item todo.TodoItem)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		path                string
+		parentId            int
+		item                todo.TodoItem
+		itemBody            string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:     "OK",
+			userId:   12,
+			path:     "/api/items/3/subtasks",
+			parentId: 3,
+			item:     todo.TodoItem{Title: "step"},
+			itemBody: `{"title":"step"}`,
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, parentId int, item todo.TodoItem) {
+				s.EXPECT().CreateSubtask(userId, parentId, item).Return(4, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":4}`,
+		},
+		{
+			name:                "Invalid Id",
+			userId:              12,
+			path:                "/api/items/step/subtasks",
+			itemBody:            `{"title":"step"}`,
+			mockBehavior:        func(s *mock_service.MockTodoItem, userId, parentId int, item todo.TodoItem) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+		{
+			name:     "Foreign Parent",
+			userId:   12,
+			path:     "/api/items/3/subtasks",
+			parentId: 3,
+			item:     todo.TodoItem{Title: "step"},
+			itemBody: `{"title":"step"}`,
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, parentId int, item todo.TodoItem) {
+				s.EXPECT().CreateSubtask(userId, parentId, item).Return(0, todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.parentId, testCase.item)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/items/:id/subtasks", setPrincipal(testCase.userId), handler.createSubtask)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", testCase.path, bytes.NewBufferString(testCase.itemBody))
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -113,52 +189,91 @@ func TestItem_CreateItem(t *testing.T) {
 }
 
 func TestItem_GetAllItems(t *testing.T) {
-	type mockBehavior func(s *mock_service.MockTodoItem, userId, listId int, items []todo.TodoItem)
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, listId int, input todo.ItemsQuery, items []todo.TodoItem)
 
 	testTable := []struct {
 		name                string
-		headerValue         string
 		userId              int
 		listId              int
+		query               string
+		input               todo.ItemsQuery
 		output              []todo.TodoItem
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
 	}{
 		{
-			name:        "OK",
-			headerValue: "19",
-			userId:      19,
-			listId:      3,
+			name:   "OK",
+			userId: 19,
+			listId: 3,
 			output: []todo.TodoItem{
-				{1, "title1", "description1", true},
-				{2, "title2", "description2", false},
-				{3, "title3", "description3", true},
+				{Id: 1, Title: "title1", Description: "description1", Done: true},
+				{Id: 2, Title: "title2", Description: "description2", Done: false},
+				{Id: 3, Title: "title3", Description: "description3", Done: true},
 			},
-			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, output []todo.TodoItem) {
-				s.EXPECT().GetAll(userId, listId).Return(output, nil)
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.ItemsQuery, output []todo.TodoItem) {
+				s.EXPECT().GetAll(userId, listId, input).Return(output, "", nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: `[{"id":1,"title":"title1","description":"description1","done":true},{"id":2,"title":"title2","description":"description2","done":false},{"id":3,"title":"title3","description":"description3","done":true}]`,
+			expectedRequestBody: `{"data":[{"id":1,"title":"title1","description":"description1","done":true,"comment_count":0},{"id":2,"title":"title2","description":"description2","done":false,"comment_count":0},{"id":3,"title":"title3","description":"description3","done":true,"comment_count":0}]}`,
 		},
 		{
-			name:                "No Header",
-			mockBehavior:        func(s *mock_service.MockTodoItem, userId, listId int, output []todo.TodoItem) {},
+			name: "No Header",
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.ItemsQuery, output []todo.TodoItem) {
+			},
 			expectedStatusCode:  401,
 			expectedRequestBody: `{"message":"unauthorized user"}`,
 		},
 		{
-			name:        "Service Failure",
-			headerValue: "19",
-			userId:      19,
-			listId:      3,
-			output:      []todo.TodoItem{},
-			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, output []todo.TodoItem) {
-				s.EXPECT().GetAll(userId, listId).Return(nil, errors.New("service failure"))
+			name:   "Service Failure",
+			userId: 19,
+			listId: 3,
+			output: []todo.TodoItem{},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.ItemsQuery, output []todo.TodoItem) {
+				s.EXPECT().GetAll(userId, listId, input).Return(nil, "", errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
 		},
+		{
+			name:   "Foreign List",
+			userId: 19,
+			listId: 3,
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.ItemsQuery, output []todo.TodoItem) {
+				s.EXPECT().GetAll(userId, listId, input).Return(nil, "", todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+		{
+			name:   "Filters",
+			userId: 19,
+			listId: 3,
+			query:  "?done=false&title=title&limit=1&sort=created_at",
+			input: todo.ItemsQuery{
+				PageQuery: todo.PageQuery{Limit: 1, Sort: "created_at"},
+				Title:     "title",
+				Done:      boolPointer(false),
+			},
+			output: []todo.TodoItem{
+				{Id: 2, Title: "title2", Description: "description2", Done: false},
+			},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.ItemsQuery, output []todo.TodoItem) {
+				s.EXPECT().GetAll(userId, listId, input).Return(output, "next", nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"id":2,"title":"title2","description":"description2","done":false,"comment_count":0}],"next_cursor":"next"}`,
+		},
+		{
+			name:   "Invalid Done",
+			userId: 19,
+			listId: 3,
+			query:  "?done=maybe",
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, listId int, input todo.ItemsQuery, output []todo.TodoItem) {
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid query params"}`,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -168,19 +283,18 @@ func TestItem_GetAllItems(t *testing.T) {
 			defer c.Finish()
 
 			todoItem := mock_service.NewMockTodoItem(c)
-			testCase.mockBehavior(todoItem, testCase.userId, testCase.listId, testCase.output)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.listId, testCase.input, testCase.output)
 
 			services := &service.Service{TodoItem: todoItem}
 			handler := NewHandler(services)
 
 			// Test Server
 			r := gin.New()
-			r.GET("/api/lists/:id/items", handler.getAllItems)
+			r.GET("/api/lists/:id/items", setPrincipal(testCase.userId), handler.getAllItems)
 
 			// Test Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", fmt.Sprintf("/api/lists/%d/items", testCase.listId), nil)
-			req.AddCookie(&http.Cookie{Name: userCtx, Value: testCase.headerValue})
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/lists/%d/items%s", testCase.listId, testCase.query), nil)
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -197,9 +311,578 @@ func TestItem_GetItemById(t *testing.T) {
 }
 
 func TestItem_UpdateItem(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, itemId int, input todo.UpdateItemInput)
+
+	dueAt := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		itemId              int
+		inputBody           string
+		input               todo.UpdateItemInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"done":true,"due_at":"2023-05-01T09:00:00Z"}`,
+			input: todo.UpdateItemInput{
+				Done:  boolPointer(true),
+				DueAt: todo.NullableTime{Set: true, Time: &dueAt},
+			},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(userId, itemId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:      "Clear Due Date",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"due_at":null}`,
+			input: todo.UpdateItemInput{
+				DueAt: todo.NullableTime{Set: true},
+			},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(userId, itemId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "Invalid Date",
+			userId:              1,
+			itemId:              2,
+			inputBody:           `{"due_at":"tomorrow"}`,
+			mockBehavior:        func(s *mock_service.MockTodoItem, userId, itemId int, input todo.UpdateItemInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"parsing time \"tomorrow\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"tomorrow\" as \"2006\""}`,
+		},
+		{
+			name:      "Open Subtasks",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"done":true}`,
+			input:     todo.UpdateItemInput{Done: boolPointer(true)},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(userId, itemId, input).Return(fmt.Errorf("%w: item has open subtasks", todo.ErrConflict))
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"conflict: item has open subtasks"}`,
+		},
+		{
+			name:      "Unknown Priority",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"priority":"critical"}`,
+			input:     todo.UpdateItemInput{Priority: priorityPointer("critical")},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(userId, itemId, input).Return(input.Validate())
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: priority must be one of none, low, medium, high, urgent"}`,
+		},
+		{
+			name:      "Not Found",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"done":true}`,
+			input:     todo.UpdateItemInput{Done: boolPointer(true)},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.UpdateItemInput) {
+				s.EXPECT().Update(userId, itemId, input).Return(todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.itemId, testCase.input)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.PUT("/api/items/:id", setPrincipal(testCase.userId), handler.updateItem)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", fmt.Sprintf("/api/items/%d", testCase.itemId),
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestItem_MoveItem(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, itemId int, input todo.MoveInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		itemId              int
+		inputBody           string
+		input               todo.MoveInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"after":3,"before":4}`,
+			input:     todo.MoveInput{After: intPointer(3), Before: intPointer(4)},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.MoveInput) {
+				s.EXPECT().Move(userId, itemId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:      "No Neighbours",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{}`,
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.MoveInput) {
+				s.EXPECT().Move(userId, itemId, input).Return(input.Validate())
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: before or after is required"}`,
+		},
+		{
+			name:      "Forbidden",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"before":4}`,
+			input:     todo.MoveInput{Before: intPointer(4)},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.MoveInput) {
+				s.EXPECT().Move(userId, itemId, input).Return(todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.itemId, testCase.input)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
 
+			// Test Server
+			r := gin.New()
+			r.POST("/api/items/:id/move", setPrincipal(testCase.userId), handler.moveItem)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/items/%d/move", testCase.itemId),
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestItem_TransferItem(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, itemId int, input todo.TransferItemInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		itemId              int
+		inputBody           string
+		input               todo.TransferItemInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"to_list_id":4}`,
+			input:     todo.TransferItemInput{ToListId: 4},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.TransferItemInput) {
+				s.EXPECT().Transfer(userId, itemId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "No Destination",
+			userId:              1,
+			itemId:              2,
+			inputBody:           `{"from_list_id":3}`,
+			mockBehavior:        func(s *mock_service.MockTodoItem, userId, itemId int, input todo.TransferItemInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Destination Not Editable",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"from_list_id":3,"to_list_id":4}`,
+			input:     todo.TransferItemInput{FromListId: 3, ToListId: 4},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.TransferItemInput) {
+				s.EXPECT().Transfer(userId, itemId, input).Return(todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.itemId, testCase.input)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/items/:id/transfer", setPrincipal(testCase.userId), handler.transferItem)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/items/%d/transfer", testCase.itemId),
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestItem_CopyItem(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, itemId int, input todo.CopyItemInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		itemId              int
+		inputBody           string
+		input               todo.CopyItemInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"list_id":4,"subtasks":true}`,
+			input:     todo.CopyItemInput{ListId: 4, Subtasks: true},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.CopyItemInput) {
+				s.EXPECT().Copy(userId, itemId, input).Return(10, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":10}`,
+		},
+		{
+			name:      "Not Found",
+			userId:    1,
+			itemId:    2,
+			inputBody: `{"list_id":4}`,
+			input:     todo.CopyItemInput{ListId: 4},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId int, input todo.CopyItemInput) {
+				s.EXPECT().Copy(userId, itemId, input).Return(0, todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.itemId, testCase.input)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/items/:id/copy", setPrincipal(testCase.userId), handler.copyItem)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/items/%d/copy", testCase.itemId),
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestItem_UnlinkItem(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId, itemId, listId int)
+
+	testTable := []struct {
+		name                string
+		path                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name: "OK",
+			path: "/api/items/2/lists/4",
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId, listId int) {
+				s.EXPECT().Unlink(userId, itemId, listId).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name: "Last List",
+			path: "/api/items/2/lists/4",
+			mockBehavior: func(s *mock_service.MockTodoItem, userId, itemId, listId int) {
+				s.EXPECT().Unlink(userId, itemId, listId).
+					Return(fmt.Errorf("%w: item must stay in at least one list", todo.ErrConflict))
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"conflict: item must stay in at least one list"}`,
+		},
+		{
+			name:                "Invalid List Id",
+			path:                "/api/items/2/lists/inbox",
+			mockBehavior:        func(s *mock_service.MockTodoItem, userId, itemId, listId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid list_id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, 1, 2, 4)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.DELETE("/api/items/:id/lists/:list_id", setPrincipal(1), handler.unlinkItem)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", testCase.path, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
 }
 
 func TestItem_DeleteItem(t *testing.T) {
 
 }
+
+func TestItem_GetDueItems(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId int, window string, input todo.DueQuery, output []todo.TodoItem)
+
+	dueAt := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		path                string
+		window              string
+		input               todo.DueQuery
+		output              []todo.TodoItem
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			path:   "/api/due/today?tz=Europe/Moscow",
+			window: todo.DueToday,
+			input:  todo.DueQuery{TimeZone: "Europe/Moscow"},
+			output: []todo.TodoItem{
+				{Id: 3, Title: "title3", Description: "description3", DueAt: &dueAt},
+			},
+			mockBehavior: func(s *mock_service.MockTodoItem, userId int, window string, input todo.DueQuery, output []todo.TodoItem) {
+				s.EXPECT().GetDue(userId, window, input).Return(output, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"id":3,"title":"title3","description":"description3","done":false,"due_at":"2023-05-01T09:00:00Z","comment_count":0}]}`,
+		},
+		{
+			name:   "Unknown Window",
+			userId: 1,
+			path:   "/api/due/month",
+			window: "month",
+			mockBehavior: func(s *mock_service.MockTodoItem, userId int, window string, input todo.DueQuery, output []todo.TodoItem) {
+				s.EXPECT().GetDue(userId, window, input).Return(nil, fmt.Errorf("%w: unknown window", todo.ErrValidation))
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: unknown window"}`,
+		},
+		{
+			name: "No Principal",
+			path: "/api/due/overdue",
+			mockBehavior: func(s *mock_service.MockTodoItem, userId int, window string, input todo.DueQuery, output []todo.TodoItem) {
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"unauthorized user"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId, testCase.window, testCase.input, testCase.output)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/due/:window", setPrincipal(testCase.userId), handler.getDueItems)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", testCase.path, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func boolPointer(b bool) *bool {
+	return &b
+}
+
+func priorityPointer(p todo.Priority) *todo.Priority {
+	return &p
+}
+
+func intPointer(i int) *int {
+	return &i
+}
+
+func TestItem_GetAssignedItems(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoItem, userId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockTodoItem, userId int) {
+				s.EXPECT().GetAssigned(userId).Return([]todo.TodoItem{
+					{Id: 3, Title: "title3", Description: "description3", AssigneeId: intPointer(1)},
+				}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"id":3,"title":"title3","description":"description3","done":false,"assignee_id":1,"comment_count":0}]}`,
+		},
+		{
+			name:                "No Principal",
+			mockBehavior:        func(s *mock_service.MockTodoItem, userId int) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"unauthorized user"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoItem := mock_service.NewMockTodoItem(c)
+			testCase.mockBehavior(todoItem, testCase.userId)
+
+			services := &service.Service{TodoItem: todoItem}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/me/assigned", setPrincipal(testCase.userId), handler.getAssignedItems)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/me/assigned", nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestLabel_CreateLabel(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockLabel, userId int, input todo.Label)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		inputBody           string
+		input               todo.Label
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			inputBody: `{"name":"urgent","color":"#ff0000"}`,
+			input:     todo.Label{Name: "urgent", Color: "#ff0000"},
+			mockBehavior: func(s *mock_service.MockLabel, userId int, input todo.Label) {
+				s.EXPECT().Create(userId, input).Return(4, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":4}`,
+		},
+		{
+			name:                "Empty Name",
+			userId:              1,
+			inputBody:           `{"color":"#ff0000"}`,
+			mockBehavior:        func(s *mock_service.MockLabel, userId int, input todo.Label) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Duplicate Name",
+			userId:    1,
+			inputBody: `{"name":"urgent"}`,
+			input:     todo.Label{Name: "urgent"},
+			mockBehavior: func(s *mock_service.MockLabel, userId int, input todo.Label) {
+				s.EXPECT().Create(userId, input).Return(0, todo.ErrConflict)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"conflict"}`,
+		},
+		{
+			name:      "Invalid Color",
+			userId:    1,
+			inputBody: `{"name":"urgent","color":"red"}`,
+			input:     todo.Label{Name: "urgent", Color: "red"},
+			mockBehavior: func(s *mock_service.MockLabel, userId int, input todo.Label) {
+				s.EXPECT().Create(userId, input).Return(0, input.Validate())
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: color must be a hex value like #1e90ff"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			labels := mock_service.NewMockLabel(c)
+			testCase.mockBehavior(labels, testCase.userId, testCase.input)
+
+			services := &service.Service{Label: labels}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/labels", setPrincipal(testCase.userId), handler.createLabel)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/api/labels", bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestLabel_GetLabelItems(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockLabel, userId, labelId int, output []todo.TodoItem)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		labelId             int
+		output              []todo.TodoItem
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			userId:  1,
+			labelId: 4,
+			output: []todo.TodoItem{
+				{Id: 3, Title: "title3", Description: "description3", Labels: []todo.Label{{Id: 4, Name: "urgent", Color: "#ff0000"}}},
+			},
+			mockBehavior: func(s *mock_service.MockLabel, userId, labelId int, output []todo.TodoItem) {
+				s.EXPECT().GetItems(userId, labelId).Return(output, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"id":3,"title":"title3","description":"description3","done":false,"comment_count":0,"labels":[{"id":4,"name":"urgent","color":"#ff0000"}]}]}`,
+		},
+		{
+			name:    "Foreign Label",
+			userId:  1,
+			labelId: 9,
+			mockBehavior: func(s *mock_service.MockLabel, userId, labelId int, output []todo.TodoItem) {
+				s.EXPECT().GetItems(userId, labelId).Return(nil, todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			labels := mock_service.NewMockLabel(c)
+			testCase.mockBehavior(labels, testCase.userId, testCase.labelId, testCase.output)
+
+			services := &service.Service{Label: labels}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/labels/:id/items", setPrincipal(testCase.userId), handler.getLabelItems)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/labels/%d/items", testCase.labelId), nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestLabel_AttachLabel(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockLabel, userId, itemId, labelId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		path                string
+		itemId              int
+		labelId             int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:    "OK",
+			userId:  1,
+			path:    "/api/items/2/labels/4",
+			itemId:  2,
+			labelId: 4,
+			mockBehavior: func(s *mock_service.MockLabel, userId, itemId, labelId int) {
+				s.EXPECT().Attach(userId, itemId, labelId).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "Invalid Label Id",
+			userId:              1,
+			path:                "/api/items/2/labels/urgent",
+			mockBehavior:        func(s *mock_service.MockLabel, userId, itemId, labelId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid label_id param"}`,
+		},
+		{
+			name:    "Service Failure",
+			userId:  1,
+			path:    "/api/items/2/labels/4",
+			itemId:  2,
+			labelId: 4,
+			mockBehavior: func(s *mock_service.MockLabel, userId, itemId, labelId int) {
+				s.EXPECT().Attach(userId, itemId, labelId).Return(errors.New("service failure"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"service failure"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			labels := mock_service.NewMockLabel(c)
+			testCase.mockBehavior(labels, testCase.userId, testCase.itemId, testCase.labelId)
+
+			services := &service.Service{Label: labels}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.PUT("/api/items/:id/labels/:label_id", setPrincipal(testCase.userId), handler.attachLabel)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", testCase.path, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
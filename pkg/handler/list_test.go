@@ -7,7 +7,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
-	"net/http"
 	"net/http/httptest"
 	"testing"
 	todo "todo-app"
@@ -20,7 +19,6 @@ func TestList_CreateList(t *testing.T) {
 
 	testTable := []struct {
 		name                string
-		headerValue         string
 		inputBody           string
 		userId              int
 		list                todo.TodoList
@@ -29,10 +27,9 @@ func TestList_CreateList(t *testing.T) {
 		expectedRequestBody string
 	}{
 		{
-			name:        "OK",
-			headerValue: "2",
-			userId:      2,
-			inputBody:   `{"title": "test title", "description": "test description"}`,
+			name:      "OK",
+			userId:    2,
+			inputBody: `{"title": "test title", "description": "test description"}`,
 			list: todo.TodoList{
 				Title:       "test title",
 				Description: "test description",
@@ -45,7 +42,6 @@ func TestList_CreateList(t *testing.T) {
 		},
 		{
 			name:                "Empty Fields",
-			headerValue:         "2",
 			userId:              2,
 			inputBody:           `{"title": "", "description": "test description"}`,
 			mockBehavior:        func(s *mock_service.MockTodoList, userId int, list todo.TodoList) {},
@@ -64,10 +60,9 @@ func TestList_CreateList(t *testing.T) {
 			expectedRequestBody: `{"message":"unauthorized user"}`,
 		},
 		{
-			name:        "Service Failure",
-			headerValue: "2",
-			userId:      2,
-			inputBody:   `{"title": "test title", "description": "test description"}`,
+			name:      "Service Failure",
+			userId:    2,
+			inputBody: `{"title": "test title", "description": "test description"}`,
 			list: todo.TodoList{
 				Title:       "test title",
 				Description: "test description",
@@ -94,12 +89,11 @@ func TestList_CreateList(t *testing.T) {
 
 			// Test Server
 			r := gin.New()
-			r.POST("/api/lists", handler.createList)
+			r.POST("/api/lists", setPrincipal(testCase.userId), handler.createList)
 
 			// Test Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("POST", "/api/lists", bytes.NewBufferString(testCase.inputBody))
-			req.AddCookie(&http.Cookie{Name: userCtx, Value: testCase.headerValue})
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -112,21 +106,21 @@ func TestList_CreateList(t *testing.T) {
 }
 
 func TestList_GetAllLists(t *testing.T) {
-	type mockBehavior func(s *mock_service.MockTodoList, userId int, output []todo.TodoList)
+	type mockBehavior func(s *mock_service.MockTodoList, userId int, input todo.ListsQuery, output []todo.TodoList)
 
 	testTable := []struct {
 		name                string
-		headerValue         string
 		userId              int
+		query               string
+		input               todo.ListsQuery
 		output              []todo.TodoList
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
 		expectedRequestBody string
 	}{
 		{
-			name:        "OK",
-			headerValue: "4",
-			userId:      4,
+			name:   "OK",
+			userId: 4,
 			output: []todo.TodoList{
 				{
 					Id:          1,
@@ -144,29 +138,64 @@ func TestList_GetAllLists(t *testing.T) {
 					Description: "description3",
 				},
 			},
-			mockBehavior: func(s *mock_service.MockTodoList, userId int, output []todo.TodoList) {
-				s.EXPECT().GetAll(userId).Return(output, nil)
+			mockBehavior: func(s *mock_service.MockTodoList, userId int, input todo.ListsQuery, output []todo.TodoList) {
+				s.EXPECT().GetAll(userId, input).Return(output, "", nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"data":[{"id":1,"title":"title1","description":"description1"},{"id":2,"title":"title2","description":"description2"},{"id":3,"title":"title3","description":"description3"}]}`,
 		},
 		{
 			name:                "No Header",
-			mockBehavior:        func(s *mock_service.MockTodoList, userId int, output []todo.TodoList) {},
+			mockBehavior:        func(s *mock_service.MockTodoList, userId int, input todo.ListsQuery, output []todo.TodoList) {},
 			expectedStatusCode:  401,
 			expectedRequestBody: `{"message":"user unauthorized"}`,
 		},
 		{
-			name:        "Service Failure",
-			headerValue: "4",
-			userId:      4,
-			output:      []todo.TodoList{},
-			mockBehavior: func(s *mock_service.MockTodoList, userId int, output []todo.TodoList) {
-				s.EXPECT().GetAll(userId).Return(output, errors.New("service failure"))
+			name:   "Service Failure",
+			userId: 4,
+			output: []todo.TodoList{},
+			mockBehavior: func(s *mock_service.MockTodoList, userId int, input todo.ListsQuery, output []todo.TodoList) {
+				s.EXPECT().GetAll(userId, input).Return(output, "", errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
 		},
+		{
+			name:   "Query Params",
+			userId: 4,
+			query:  "?limit=1&sort=-title&title=work&cursor=abc",
+			input: todo.ListsQuery{
+				PageQuery: todo.PageQuery{Limit: 1, Cursor: "abc", Sort: "-title"},
+				Title:     "work",
+			},
+			output: []todo.TodoList{
+				{Id: 2, Title: "work", Description: "description2"},
+			},
+			mockBehavior: func(s *mock_service.MockTodoList, userId int, input todo.ListsQuery, output []todo.TodoList) {
+				s.EXPECT().GetAll(userId, input).Return(output, "def", nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"id":2,"title":"work","description":"description2"}],"next_cursor":"def"}`,
+		},
+		{
+			name:                "Invalid Limit",
+			userId:              4,
+			query:               "?limit=ten",
+			mockBehavior:        func(s *mock_service.MockTodoList, userId int, input todo.ListsQuery, output []todo.TodoList) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid query params"}`,
+		},
+		{
+			name:   "Invalid Sort",
+			userId: 4,
+			query:  "?sort=description",
+			input:  todo.ListsQuery{PageQuery: todo.PageQuery{Sort: "description"}},
+			mockBehavior: func(s *mock_service.MockTodoList, userId int, input todo.ListsQuery, output []todo.TodoList) {
+				s.EXPECT().GetAll(userId, input).Return(nil, "", input.Validate())
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: sort must be one of position, id, title, created_at"}`,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -176,19 +205,18 @@ func TestList_GetAllLists(t *testing.T) {
 			defer c.Finish()
 
 			todoList := mock_service.NewMockTodoList(c)
-			testCase.mockBehavior(todoList, testCase.userId, testCase.output)
+			testCase.mockBehavior(todoList, testCase.userId, testCase.input, testCase.output)
 
 			services := &service.Service{TodoList: todoList}
 			handler := NewHandler(services)
 
 			// Test Server
 			r := gin.New()
-			r.GET("/api/lists", handler.getAllLists)
+			r.GET("/api/lists", setPrincipal(testCase.userId), handler.getAllLists)
 
 			// Test Request
 			w := httptest.NewRecorder()
-			req := httptest.NewRequest("GET", "/api/lists", nil)
-			req.AddCookie(&http.Cookie{Name: userCtx, Value: testCase.headerValue})
+			req := httptest.NewRequest("GET", "/api/lists"+testCase.query, nil)
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -205,7 +233,6 @@ func TestList_GetListById(t *testing.T) {
 
 	testTable := []struct {
 		name                string
-		headerValue         string
 		userId              int
 		listId              int
 		output              todo.TodoList
@@ -214,10 +241,9 @@ func TestList_GetListById(t *testing.T) {
 		expectedRequestBody string
 	}{
 		{
-			name:        "OK",
-			headerValue: "3",
-			userId:      3,
-			listId:      2,
+			name:   "OK",
+			userId: 3,
+			listId: 2,
 			output: todo.TodoList{
 				Id:          2,
 				Title:       "test title",
@@ -236,17 +262,27 @@ func TestList_GetListById(t *testing.T) {
 			expectedRequestBody: `{"message":"user unauthorized"}`,
 		},
 		{
-			name:        "Service Failure",
-			headerValue: "3",
-			userId:      3,
-			listId:      2,
-			output:      todo.TodoList{},
+			name:   "Service Failure",
+			userId: 3,
+			listId: 2,
+			output: todo.TodoList{},
 			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int, output todo.TodoList) {
 				s.EXPECT().GetById(userId, listId).Return(output, errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
 		},
+		{
+			name:   "Not Found",
+			userId: 3,
+			listId: 2,
+			output: todo.TodoList{},
+			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int, output todo.TodoList) {
+				s.EXPECT().GetById(userId, listId).Return(output, todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -263,12 +299,11 @@ func TestList_GetListById(t *testing.T) {
 
 			// Test Server
 			r := gin.New()
-			r.GET("/api/lists/:id", handler.getListById)
+			r.GET("/api/lists/:id", setPrincipal(testCase.userId), handler.getListById)
 
 			// Test Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("GET", fmt.Sprintf("/api/lists/%d", testCase.listId), nil)
-			req.AddCookie(&http.Cookie{Name: userCtx, Value: testCase.headerValue})
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -285,7 +320,6 @@ func TestList_UpdateList(t *testing.T) {
 
 	testTable := []struct {
 		name                string
-		headerValue         string
 		userId              int
 		listId              int
 		input               todo.UpdateListInput
@@ -295,10 +329,9 @@ func TestList_UpdateList(t *testing.T) {
 		expectedRequestBody string
 	}{
 		{
-			name:        "OK No Empty Fields",
-			headerValue: "7",
-			userId:      7,
-			listId:      2,
+			name:   "OK No Empty Fields",
+			userId: 7,
+			listId: 2,
 			input: todo.UpdateListInput{
 				Title:       stringPointer("new title"),
 				Description: stringPointer("new description"),
@@ -317,10 +350,9 @@ func TestList_UpdateList(t *testing.T) {
 			expectedRequestBody: `{"message":"user unauthorized"}`,
 		},
 		{
-			name:        "OK One Field Empty",
-			headerValue: "7",
-			userId:      7,
-			listId:      2,
+			name:   "OK One Field Empty",
+			userId: 7,
+			listId: 2,
 			input: todo.UpdateListInput{
 				Title:       stringPointer(""),
 				Description: stringPointer("new description"),
@@ -333,10 +365,9 @@ func TestList_UpdateList(t *testing.T) {
 			expectedRequestBody: `{"status":"ok"}`,
 		},
 		{
-			name:        "OK All Empty",
-			headerValue: "7",
-			userId:      7,
-			listId:      2,
+			name:   "OK All Empty",
+			userId: 7,
+			listId: 2,
 			input: todo.UpdateListInput{
 				Title:       stringPointer(""),
 				Description: stringPointer(""),
@@ -364,12 +395,11 @@ func TestList_UpdateList(t *testing.T) {
 
 			// Test Server
 			r := gin.New()
-			r.PUT("/api/lists/:id", handler.updateList)
+			r.PUT("/api/lists/:id", setPrincipal(testCase.userId), handler.updateList)
 
 			// Test Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("PUT", fmt.Sprintf("/api/lists/%d", testCase.listId), bytes.NewBufferString(testCase.inputString))
-			req.AddCookie(&http.Cookie{Name: userCtx, Value: testCase.headerValue})
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -385,12 +415,173 @@ func stringPointer(s string) *string {
 	return &s
 }
 
+func TestList_MoveList(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoList, userId, listId int, input todo.MoveInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		listId              int
+		input               todo.MoveInput
+		inputString         string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:        "OK",
+			userId:      7,
+			listId:      2,
+			input:       todo.MoveInput{Before: intPointer(5)},
+			inputString: `{"before":5}`,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int, input todo.MoveInput) {
+				s.EXPECT().Move(userId, listId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "No Header",
+			mockBehavior:        func(s *mock_service.MockTodoList, userId, listId int, input todo.MoveInput) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+		{
+			name:        "Not A Member",
+			userId:      7,
+			listId:      2,
+			input:       todo.MoveInput{After: intPointer(5)},
+			inputString: `{"after":5}`,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int, input todo.MoveInput) {
+				s.EXPECT().Move(userId, listId, input).Return(todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoList := mock_service.NewMockTodoList(c)
+			testCase.mockBehavior(todoList, testCase.userId, testCase.listId, testCase.input)
+
+			services := &service.Service{TodoList: todoList}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/lists/:id/move", setPrincipal(testCase.userId), handler.moveList)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/lists/%d/move", testCase.listId),
+				bytes.NewBufferString(testCase.inputString))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestList_CloneList(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoList, userId, listId int, input todo.CloneListInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		listId              int
+		input               todo.CloneListInput
+		inputString         string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:        "OK",
+			userId:      7,
+			listId:      2,
+			inputString: `{}`,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int, input todo.CloneListInput) {
+				s.EXPECT().Clone(userId, listId, input).Return(9, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":9}`,
+		},
+		{
+			name:        "As Template",
+			userId:      7,
+			listId:      2,
+			input:       todo.CloneListInput{Title: "Release {{version}}", Template: true},
+			inputString: `{"title":"Release {{version}}","template":true}`,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int, input todo.CloneListInput) {
+				s.EXPECT().Clone(userId, listId, input).Return(9, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":9}`,
+		},
+		{
+			name:                "No Header",
+			mockBehavior:        func(s *mock_service.MockTodoList, userId, listId int, input todo.CloneListInput) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+		{
+			name:        "Not A Member",
+			userId:      7,
+			listId:      2,
+			inputString: `{}`,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int, input todo.CloneListInput) {
+				s.EXPECT().Clone(userId, listId, input).Return(0, todo.ErrNotFound)
+			},
+			expectedStatusCode:  404,
+			expectedRequestBody: `{"message":"not found"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoList := mock_service.NewMockTodoList(c)
+			testCase.mockBehavior(todoList, testCase.userId, testCase.listId, testCase.input)
+
+			services := &service.Service{TodoList: todoList}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/lists/:id/clone", setPrincipal(testCase.userId), handler.cloneList)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/lists/%d/clone", testCase.listId),
+				bytes.NewBufferString(testCase.inputString))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
 func TestList_DeleteList(t *testing.T) {
 	type mockBehavior func(s *mock_service.MockTodoList, userId, listId int)
 
 	testTable := []struct {
 		name                string
-		headerValue         string
 		userId              int
 		listId              int
 		mockBehavior        mockBehavior
@@ -398,12 +589,11 @@ func TestList_DeleteList(t *testing.T) {
 		expectedRequestBody string
 	}{
 		{
-			name:        "OK",
-			headerValue: "2",
-			userId:      2,
-			listId:      4,
+			name:   "OK",
+			userId: 2,
+			listId: 4,
 			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int) {
-				s.EXPECT().Delete(userId, listId).Return(nil)
+				s.EXPECT().Delete(userId, listId, nil).Return(nil)
 			},
 			expectedStatusCode:  200,
 			expectedRequestBody: `{"status":"ok"}`,
@@ -415,16 +605,25 @@ func TestList_DeleteList(t *testing.T) {
 			expectedRequestBody: `{"message":"user unauthorized"}`,
 		},
 		{
-			name:        "Service Failure",
-			headerValue: "2",
-			userId:      2,
-			listId:      4,
+			name:   "Service Failure",
+			userId: 2,
+			listId: 4,
 			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int) {
-				s.EXPECT().Delete(userId, listId).Return(errors.New("service failure"))
+				s.EXPECT().Delete(userId, listId, nil).Return(errors.New("service failure"))
 			},
 			expectedStatusCode:  500,
 			expectedRequestBody: `{"message":"service failure"}`,
 		},
+		{
+			name:   "Not Owner",
+			userId: 2,
+			listId: 4,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, listId int) {
+				s.EXPECT().Delete(userId, listId, nil).Return(todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -441,12 +640,11 @@ func TestList_DeleteList(t *testing.T) {
 
 			// Test Server
 			r := gin.New()
-			r.DELETE("/api/lists/:id", handler.deleteList)
+			r.DELETE("/api/lists/:id", setPrincipal(testCase.userId), handler.deleteList)
 
 			// Test Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/lists/%d", testCase.listId), nil)
-			req.AddCookie(&http.Cookie{Name: userCtx, Value: testCase.headerValue})
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestMember_GetAllMembers(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListMember, userId, listId int, output []todo.ListMember)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		listId              int
+		output              []todo.ListMember
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			listId: 2,
+			output: []todo.ListMember{
+				{UserId: 1, Name: "Owner", Username: "owner", Role: todo.RoleOwner},
+				{UserId: 3, Name: "Viewer", Username: "viewer", Role: todo.RoleViewer},
+			},
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId int, output []todo.ListMember) {
+				s.EXPECT().GetAll(userId, listId).Return(output, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"user_id":1,"name":"Owner","username":"owner","role":"owner"},{"user_id":3,"name":"Viewer","username":"viewer","role":"viewer"}]}`,
+		},
+		{
+			name:                "No Principal",
+			mockBehavior:        func(s *mock_service.MockListMember, userId, listId int, output []todo.ListMember) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+		{
+			name:   "Service Failure",
+			userId: 1,
+			listId: 2,
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId int, output []todo.ListMember) {
+				s.EXPECT().GetAll(userId, listId).Return(nil, errors.New("service failure"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"service failure"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			members := mock_service.NewMockListMember(c)
+			testCase.mockBehavior(members, testCase.userId, testCase.listId, testCase.output)
+
+			services := &service.Service{ListMember: members}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/lists/:id/members", setPrincipal(testCase.userId), handler.getAllMembers)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/lists/%d/members", testCase.listId), nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestMember_AddMember(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListMember, userId, listId int, input todo.AddMemberInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		listId              int
+		inputBody           string
+		input               todo.AddMemberInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			listId:    2,
+			inputBody: `{"username":"friend","role":"editor"}`,
+			input:     todo.AddMemberInput{Username: "friend", Role: todo.RoleEditor},
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId int, input todo.AddMemberInput) {
+				s.EXPECT().Add(userId, listId, input).Return(5, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"user_id":5}`,
+		},
+		{
+			name:                "Empty Fields",
+			userId:              1,
+			listId:              2,
+			inputBody:           `{"username":"friend"}`,
+			mockBehavior:        func(s *mock_service.MockListMember, userId, listId int, input todo.AddMemberInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:      "Not Owner",
+			userId:    1,
+			listId:    2,
+			inputBody: `{"username":"friend","role":"editor"}`,
+			input:     todo.AddMemberInput{Username: "friend", Role: todo.RoleEditor},
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId int, input todo.AddMemberInput) {
+				s.EXPECT().Add(userId, listId, input).Return(0, todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:      "Already A Member",
+			userId:    1,
+			listId:    2,
+			inputBody: `{"username":"friend","role":"editor"}`,
+			input:     todo.AddMemberInput{Username: "friend", Role: todo.RoleEditor},
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId int, input todo.AddMemberInput) {
+				s.EXPECT().Add(userId, listId, input).Return(0, todo.ErrConflict)
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"conflict"}`,
+		},
+		{
+			name:      "Invalid Role",
+			userId:    1,
+			listId:    2,
+			inputBody: `{"username":"friend","role":"admin"}`,
+			input:     todo.AddMemberInput{Username: "friend", Role: "admin"},
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId int, input todo.AddMemberInput) {
+				s.EXPECT().Add(userId, listId, input).Return(0, todo.ValidateRole(input.Role))
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: role must be one of owner, editor, viewer"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			members := mock_service.NewMockListMember(c)
+			testCase.mockBehavior(members, testCase.userId, testCase.listId, testCase.input)
+
+			services := &service.Service{ListMember: members}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/lists/:id/members", setPrincipal(testCase.userId), handler.addMember)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/lists/%d/members", testCase.listId),
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestMember_UpdateMember(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListMember, userId, listId, memberId int, input todo.UpdateMemberInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		listId              int
+		memberId            int
+		inputBody           string
+		input               todo.UpdateMemberInput
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:      "OK",
+			userId:    1,
+			listId:    2,
+			memberId:  5,
+			inputBody: `{"role":"viewer"}`,
+			input:     todo.UpdateMemberInput{Role: todo.RoleViewer},
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId, memberId int, input todo.UpdateMemberInput) {
+				s.EXPECT().UpdateRole(userId, listId, memberId, input).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:      "Not Owner",
+			userId:    1,
+			listId:    2,
+			memberId:  5,
+			inputBody: `{"role":"owner"}`,
+			input:     todo.UpdateMemberInput{Role: todo.RoleOwner},
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId, memberId int, input todo.UpdateMemberInput) {
+				s.EXPECT().UpdateRole(userId, listId, memberId, input).Return(todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			members := mock_service.NewMockListMember(c)
+			testCase.mockBehavior(members, testCase.userId, testCase.listId, testCase.memberId, testCase.input)
+
+			services := &service.Service{ListMember: members}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.PUT("/api/lists/:id/members/:user_id", setPrincipal(testCase.userId), handler.updateMember)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", fmt.Sprintf("/api/lists/%d/members/%d", testCase.listId, testCase.memberId),
+				bytes.NewBufferString(testCase.inputBody))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestMember_RemoveMember(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockListMember, userId, listId, memberId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		listId              int
+		memberId            int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:     "OK",
+			userId:   1,
+			listId:   2,
+			memberId: 5,
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId, memberId int) {
+				s.EXPECT().Remove(userId, listId, memberId).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:     "Not Owner",
+			userId:   1,
+			listId:   2,
+			memberId: 5,
+			mockBehavior: func(s *mock_service.MockListMember, userId, listId, memberId int) {
+				s.EXPECT().Remove(userId, listId, memberId).Return(todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:                "Invalid Member Id",
+			userId:              1,
+			listId:              2,
+			mockBehavior:        func(s *mock_service.MockListMember, userId, listId, memberId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid user_id param"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			members := mock_service.NewMockListMember(c)
+			testCase.mockBehavior(members, testCase.userId, testCase.listId, testCase.memberId)
+
+			services := &service.Service{ListMember: members}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.DELETE("/api/lists/:id/members/:user_id", setPrincipal(testCase.userId), handler.removeMember)
+
+			// Test Request
+			memberParam := fmt.Sprint(testCase.memberId)
+			if testCase.memberId == 0 {
+				memberParam = "me"
+			}
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/lists/%d/members/%s", testCase.listId, memberParam), nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"strconv"
 	"strings"
+	todo "todo-app"
+	"todo-app/pkg/service"
 )
 
 const (
 	authorizationHeader = "Authorization"
-	userCtx             = "userId"
+	principalCtx        = "principal"
 )
 
 func (h *Handler) userIdentity(c *gin.Context) {
@@ -32,26 +33,59 @@ func (h *Handler) userIdentity(c *gin.Context) {
 		return
 	}
 
-	userId, err := h.services.Authorization.ParseToken(headerParts[1])
+	principal, err := h.services.Authorization.ParseToken(headerParts[1])
 	if err != nil {
-		newErrorResponse(c, http.StatusUnauthorized, err.Error())
+		newTokenErrorResponse(c, err)
 		return
 	}
 
-	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie(userCtx, fmt.Sprintf("%d", userId), 3600*12, "", "", false, true)
+	c.Set(principalCtx, principal)
 }
 
-func getUserId(c *gin.Context) (int, error) {
-	id, err := c.Cookie(userCtx)
-	if err != nil {
-		return 0, errors.New("user id not found")
+var tokenErrors = []error{
+	service.ErrTokenExpired,
+	service.ErrTokenNotValidYet,
+	service.ErrTokenAudience,
+	service.ErrTokenIssuer,
+	service.ErrSessionRevoked,
+	service.ErrTokenInvalid,
+}
+
+// newTokenErrorResponse tells the client why its token was rejected, so it can tell
+// e.g. an expired token (refresh it) from a token meant for another service.
+func newTokenErrorResponse(c *gin.Context, err error) {
+	for _, tokenErr := range tokenErrors {
+		if errors.Is(err, tokenErr) {
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, tokenErr.Error()))
+			newErrorResponse(c, http.StatusUnauthorized, tokenErr.Error())
+			return
+		}
 	}
 
-	idInt, err := strconv.Atoi(id)
+	newErrorResponse(c, http.StatusInternalServerError, err.Error())
+}
+
+// getPrincipal returns the identity stored by userIdentity. Nothing sent by the
+// client (cookies, headers, query) is consulted here.
+func getPrincipal(c *gin.Context) (todo.Principal, error) {
+	value, ok := c.Get(principalCtx)
+	if !ok {
+		return todo.Principal{}, errors.New("user id not found")
+	}
+
+	principal, ok := value.(todo.Principal)
+	if !ok || principal.UserId == 0 {
+		return todo.Principal{}, errors.New("user id not found")
+	}
+
+	return principal, nil
+}
+
+func getUserId(c *gin.Context) (int, error) {
+	principal, err := getPrincipal(c)
 	if err != nil {
-		return 0, errors.New("user id not found")
+		return 0, err
 	}
 
-	return idInt, nil
+	return principal.UserId, nil
 }
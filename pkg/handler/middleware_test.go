@@ -5,8 +5,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/magiconair/properties/assert"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	todo "todo-app"
 	"todo-app/pkg/service"
 	mock_service "todo-app/pkg/service/mocks"
 )
@@ -18,6 +20,7 @@ func TestHandler_userIdentity(t *testing.T) {
 		name                string
 		headerName          string
 		headerValue         string
+		cookie              *http.Cookie
 		token               string
 		mockBehavior        mockBehavior
 		expectedStatusCode  int
@@ -29,10 +32,30 @@ func TestHandler_userIdentity(t *testing.T) {
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
-				s.EXPECT().ParseToken(token).Return(1, nil)
+				s.EXPECT().ParseToken(token).Return(todo.Principal{UserId: 1}, nil)
 			},
 			expectedStatusCode:  200,
-			expectedRequestBody: ``,
+			expectedRequestBody: `1`,
+		},
+		{
+			name:        "Forged Cookie Ignored",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			cookie:      &http.Cookie{Name: "userId", Value: "42"},
+			token:       "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(todo.Principal{UserId: 1}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `1`,
+		},
+		{
+			name:                "Forged Cookie Without Header",
+			headerName:          "",
+			cookie:              &http.Cookie{Name: "userId", Value: "42"},
+			mockBehavior:        func(s *mock_service.MockAuthorization, token string) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"empty auth header"}`,
 		},
 		{
 			name:                "No Header",
@@ -58,15 +81,59 @@ func TestHandler_userIdentity(t *testing.T) {
 			expectedRequestBody: `{"message":"token is empty"}`,
 		},
 		{
-			name:        "Service Failure",
+			name:        "Expired Token",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(todo.Principal{}, service.ErrTokenExpired)
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"token has expired"}`,
+		},
+		{
+			name:        "Token Not Valid Yet",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(todo.Principal{}, service.ErrTokenNotValidYet)
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"token is not valid yet"}`,
+		},
+		{
+			name:        "Wrong Audience",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(todo.Principal{}, service.ErrTokenAudience)
+			},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"token has invalid audience"}`,
+		},
+		{
+			name:        "Invalid Token",
 			headerName:  "Authorization",
 			headerValue: "Bearer token",
 			token:       "token",
 			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
-				s.EXPECT().ParseToken(token).Return(1, errors.New("failed to parse token"))
+				s.EXPECT().ParseToken(token).Return(todo.Principal{}, service.ErrTokenInvalid)
 			},
 			expectedStatusCode:  401,
-			expectedRequestBody: `{"message":"failed to parse token"}`,
+			expectedRequestBody: `{"message":"invalid token"}`,
+		},
+		{
+			name:        "Service Failure",
+			headerName:  "Authorization",
+			headerValue: "Bearer token",
+			token:       "token",
+			mockBehavior: func(s *mock_service.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(todo.Principal{}, errors.New("failed to check session"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"failed to check session"}`,
 		},
 	}
 
@@ -85,14 +152,17 @@ func TestHandler_userIdentity(t *testing.T) {
 			// Test Server
 			r := gin.New()
 			r.POST("/protected", handler.userIdentity, func(c *gin.Context) {
-				id, _ := c.Cookie(userCtx)
-				c.String(200, id)
+				id, _ := getUserId(c)
+				c.String(200, "%d", id)
 			})
 
 			// Test Request
 			w := httptest.NewRecorder()
 			req := httptest.NewRequest("POST", "/protected", nil)
 			req.Header.Set(testCase.headerName, testCase.headerValue)
+			if testCase.cookie != nil {
+				req.AddCookie(testCase.cookie)
+			}
 
 			// Perform Request
 			r.ServeHTTP(w, req)
@@ -103,3 +173,64 @@ func TestHandler_userIdentity(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_getUserId(t *testing.T) {
+	testTable := []struct {
+		name       string
+		cookie     *http.Cookie
+		setContext func(c *gin.Context)
+		want       int
+		wantErr    bool
+	}{
+		{
+			name: "OK",
+			setContext: func(c *gin.Context) {
+				c.Set(principalCtx, todo.Principal{UserId: 1, TokenId: "jti"})
+			},
+			want: 1,
+		},
+		{
+			name:       "Missing Principal",
+			setContext: func(c *gin.Context) {},
+			wantErr:    true,
+		},
+		{
+			name:       "Forged Cookie",
+			cookie:     &http.Cookie{Name: "userId", Value: "42"},
+			setContext: func(c *gin.Context) {},
+			wantErr:    true,
+		},
+		{
+			name: "Invalid Principal Type",
+			setContext: func(c *gin.Context) {
+				c.Set(principalCtx, 42)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", "/api/lists", nil)
+			if testCase.cookie != nil {
+				c.Request.AddCookie(testCase.cookie)
+			}
+			testCase.setContext(c)
+
+			got, err := getUserId(c)
+			assert.Equal(t, err != nil, testCase.wantErr)
+			assert.Equal(t, got, testCase.want)
+		})
+	}
+}
+
+// setPrincipal stands in for userIdentity in handler tests.
+func setPrincipal(userId int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userId != 0 {
+			c.Set(principalCtx, todo.Principal{UserId: userId})
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"net/http"
+	todo "todo-app"
 )
 
 type errorResponse struct {
@@ -17,3 +20,31 @@ func newErrorResponse(c *gin.Context, statusCode int, message string) {
 	logrus.Error(message)
 	c.AbortWithStatusJSON(statusCode, errorResponse{message})
 }
+
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{todo.ErrValidation, http.StatusBadRequest},
+	{todo.ErrForbidden, http.StatusForbidden},
+	{todo.ErrNotFound, http.StatusNotFound},
+	{todo.ErrConflict, http.StatusConflict},
+	{todo.ErrTooLarge, http.StatusRequestEntityTooLarge},
+	{todo.ErrPreconditionFailed, http.StatusPreconditionFailed},
+}
+
+// newServiceErrorResponse answers with the status matching the domain error returned by the
+// service layer. Errors the domain does not know about are internal server errors.
+func newServiceErrorResponse(c *gin.Context, err error) {
+	newErrorResponse(c, errorStatus(err), err.Error())
+}
+
+func errorStatus(err error) int {
+	for _, mapping := range errorStatuses {
+		if errors.Is(err, mapping.err) {
+			return mapping.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
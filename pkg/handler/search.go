@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	todo "todo-app"
+)
+
+type searchResponse struct {
+	Data []todo.SearchResult `json:"data"`
+}
+
+func (h *Handler) search(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, "user unauthorized")
+		return
+	}
+
+	var input todo.SearchQuery
+	if err := c.ShouldBindQuery(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid query params")
+		return
+	}
+
+	results, err := h.services.Search.Search(userId, input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, searchResponse{
+		Data: results,
+	})
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestHandler_search(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockSearch, userId int, input todo.SearchQuery, output []todo.SearchResult)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		query               string
+		input               todo.SearchQuery
+		output              []todo.SearchResult
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			query:  "?q=milk&limit=5",
+			input:  todo.SearchQuery{Query: "milk", Limit: 5},
+			output: []todo.SearchResult{
+				{Type: todo.SearchResultItem, Id: 7, ListId: 2, Title: "Buy milk", Snippet: "Buy <b>milk</b>", Rank: 0.6},
+				{Type: todo.SearchResultList, Id: 3, ListId: 3, Title: "Groceries", Snippet: "Groceries <b>milk</b> eggs", Rank: 0.2},
+			},
+			mockBehavior: func(s *mock_service.MockSearch, userId int, input todo.SearchQuery, output []todo.SearchResult) {
+				s.EXPECT().Search(userId, input).Return(output, nil)
+			},
+			expectedStatusCode: 200,
+			expectedRequestBody: `{"data":[{"type":"item","id":7,"list_id":2,"title":"Buy milk","snippet":"Buy \u003cb\u003emilk\u003c/b\u003e","rank":0.6},` +
+				`{"type":"list","id":3,"list_id":3,"title":"Groceries","snippet":"Groceries \u003cb\u003emilk\u003c/b\u003e eggs","rank":0.2}]}`,
+		},
+		{
+			name:                "No Principal",
+			query:               "?q=milk",
+			mockBehavior:        func(s *mock_service.MockSearch, userId int, input todo.SearchQuery, output []todo.SearchResult) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+		{
+			name:                "Invalid Limit",
+			userId:              1,
+			query:               "?q=milk&limit=all",
+			mockBehavior:        func(s *mock_service.MockSearch, userId int, input todo.SearchQuery, output []todo.SearchResult) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid query params"}`,
+		},
+		{
+			name:   "Empty Query",
+			userId: 1,
+			query:  "?q=",
+			input:  todo.SearchQuery{},
+			mockBehavior: func(s *mock_service.MockSearch, userId int, input todo.SearchQuery, output []todo.SearchResult) {
+				s.EXPECT().Search(userId, input).Return(nil, input.Validate())
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: search query is empty"}`,
+		},
+		{
+			name:   "Service Failure",
+			userId: 1,
+			query:  "?q=milk",
+			input:  todo.SearchQuery{Query: "milk"},
+			mockBehavior: func(s *mock_service.MockSearch, userId int, input todo.SearchQuery, output []todo.SearchResult) {
+				s.EXPECT().Search(userId, input).Return(nil, errors.New("service failure"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"service failure"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			search := mock_service.NewMockSearch(c)
+			testCase.mockBehavior(search, testCase.userId, testCase.input, testCase.output)
+
+			services := &service.Service{Search: search}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/search", setPrincipal(testCase.userId), handler.search)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/search"+testCase.query, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestTemplate_GetAllTemplates(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoList, userId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 7,
+			mockBehavior: func(s *mock_service.MockTodoList, userId int) {
+				s.EXPECT().GetTemplates(userId, todo.ListsQuery{}).Return([]todo.TodoList{
+					{Id: 3, Title: "Release {{version}}", Role: todo.RoleOwner, Template: true},
+				}, "", nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"id":3,"title":"Release {{version}}","description":"","role":"owner","template":true}]}`,
+		},
+		{
+			name:                "No Header",
+			mockBehavior:        func(s *mock_service.MockTodoList, userId int) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoList := mock_service.NewMockTodoList(c)
+			testCase.mockBehavior(todoList, testCase.userId)
+
+			services := &service.Service{TodoList: todoList}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/templates", setPrincipal(testCase.userId), handler.getAllTemplates)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/templates", nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestTemplate_InstantiateTemplate(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTodoList, userId, templateId int, input todo.InstantiateTemplateInput)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		templateId          int
+		input               todo.InstantiateTemplateInput
+		inputString         string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:        "OK",
+			userId:      7,
+			templateId:  3,
+			input:       todo.InstantiateTemplateInput{Variables: map[string]string{"version": "1.2"}},
+			inputString: `{"variables":{"version":"1.2"}}`,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, templateId int, input todo.InstantiateTemplateInput) {
+				s.EXPECT().Instantiate(userId, templateId, input).Return(9, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"id":9}`,
+		},
+		{
+			name:                "No Header",
+			mockBehavior:        func(s *mock_service.MockTodoList, userId, templateId int, input todo.InstantiateTemplateInput) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+		{
+			name:                "Invalid Input",
+			userId:              7,
+			templateId:          3,
+			inputString:         `{"variables":["1.2"]}`,
+			mockBehavior:        func(s *mock_service.MockTodoList, userId, templateId int, input todo.InstantiateTemplateInput) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid input body"}`,
+		},
+		{
+			name:        "Unknown Variable",
+			userId:      7,
+			templateId:  3,
+			inputString: `{}`,
+			mockBehavior: func(s *mock_service.MockTodoList, userId, templateId int, input todo.InstantiateTemplateInput) {
+				s.EXPECT().Instantiate(userId, templateId, input).
+					Return(0, fmt.Errorf("%w: unknown template variable \"version\"", todo.ErrValidation))
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: unknown template variable \"version\""}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			todoList := mock_service.NewMockTodoList(c)
+			testCase.mockBehavior(todoList, testCase.userId, testCase.templateId, testCase.input)
+
+			services := &service.Service{TodoList: todoList}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/templates/:id/instantiate", setPrincipal(testCase.userId), handler.instantiateTemplate)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/templates/%d/instantiate", testCase.templateId),
+				bytes.NewBufferString(testCase.inputString))
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+	todo "todo-app"
+	"todo-app/pkg/service"
+	mock_service "todo-app/pkg/service/mocks"
+)
+
+func TestHandler_getTrash(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTrash, userId int)
+
+	deletedAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockTrash, userId int) {
+				s.EXPECT().GetAll(userId).Return([]todo.TrashEntry{
+					{Type: todo.TrashEntryItem, Id: 7, ListId: 2, Title: "Buy milk", DeletedAt: deletedAt},
+				}, nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"data":[{"type":"item","id":7,"list_id":2,"title":"Buy milk","deleted_at":"2023-05-01T10:00:00Z"}]}`,
+		},
+		{
+			name:                "No Principal",
+			mockBehavior:        func(s *mock_service.MockTrash, userId int) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+		{
+			name:   "Service Failure",
+			userId: 1,
+			mockBehavior: func(s *mock_service.MockTrash, userId int) {
+				s.EXPECT().GetAll(userId).Return(nil, errors.New("service failure"))
+			},
+			expectedStatusCode:  500,
+			expectedRequestBody: `{"message":"service failure"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			trash := mock_service.NewMockTrash(c)
+			testCase.mockBehavior(trash, testCase.userId)
+
+			services := &service.Service{Trash: trash}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.GET("/api/trash/", setPrincipal(testCase.userId), handler.getTrash)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/api/trash/", nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandler_restoreFromTrash(t *testing.T) {
+	type mockBehavior func(s *mock_service.MockTrash, userId int)
+
+	testTable := []struct {
+		name                string
+		userId              int
+		path                string
+		mockBehavior        mockBehavior
+		expectedStatusCode  int
+		expectedRequestBody string
+	}{
+		{
+			name:   "OK",
+			userId: 1,
+			path:   "/api/trash/item/7/restore",
+			mockBehavior: func(s *mock_service.MockTrash, userId int) {
+				s.EXPECT().Restore(userId, todo.TrashEntryItem, 7).Return(nil)
+			},
+			expectedStatusCode:  200,
+			expectedRequestBody: `{"status":"ok"}`,
+		},
+		{
+			name:                "Invalid Id",
+			userId:              1,
+			path:                "/api/trash/list/abc/restore",
+			mockBehavior:        func(s *mock_service.MockTrash, userId int) {},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"invalid id param"}`,
+		},
+		{
+			name:   "Invalid Type",
+			userId: 1,
+			path:   "/api/trash/label/7/restore",
+			mockBehavior: func(s *mock_service.MockTrash, userId int) {
+				s.EXPECT().Restore(userId, "label", 7).Return(todo.ValidateTrashEntryType("label"))
+			},
+			expectedStatusCode:  400,
+			expectedRequestBody: `{"message":"validation failed: type must be one of list, item"}`,
+		},
+		{
+			name:   "Trashed Parent",
+			userId: 1,
+			path:   "/api/trash/item/7/restore",
+			mockBehavior: func(s *mock_service.MockTrash, userId int) {
+				s.EXPECT().Restore(userId, todo.TrashEntryItem, 7).
+					Return(fmt.Errorf("%w: the parent item is in the trash, restore it first", todo.ErrConflict))
+			},
+			expectedStatusCode:  409,
+			expectedRequestBody: `{"message":"conflict: the parent item is in the trash, restore it first"}`,
+		},
+		{
+			name:   "Forbidden",
+			userId: 1,
+			path:   "/api/trash/list/3/restore",
+			mockBehavior: func(s *mock_service.MockTrash, userId int) {
+				s.EXPECT().Restore(userId, todo.TrashEntryList, 3).Return(todo.ErrForbidden)
+			},
+			expectedStatusCode:  403,
+			expectedRequestBody: `{"message":"forbidden"}`,
+		},
+		{
+			name:                "No Principal",
+			path:                "/api/trash/list/3/restore",
+			mockBehavior:        func(s *mock_service.MockTrash, userId int) {},
+			expectedStatusCode:  401,
+			expectedRequestBody: `{"message":"user unauthorized"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			// Init Deps
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			trash := mock_service.NewMockTrash(c)
+			testCase.mockBehavior(trash, testCase.userId)
+
+			services := &service.Service{Trash: trash}
+			handler := NewHandler(services)
+
+			// Test Server
+			r := gin.New()
+			r.POST("/api/trash/:type/:id/restore", setPrincipal(testCase.userId), handler.restoreFromTrash)
+
+			// Test Request
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", testCase.path, nil)
+
+			// Perform Request
+			r.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, testCase.expectedStatusCode, w.Code)
+			assert.Equal(t, testCase.expectedRequestBody, w.Body.String())
+		})
+	}
+}
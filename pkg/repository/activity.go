@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"reflect"
+	"time"
+	todo "todo-app"
+)
+
+const activityColumns = "list_id, actor_id, action, entity_type, entity_id, changes"
+
+type ActivityPostgres struct {
+	db *sqlx.DB
+}
+
+func NewActivityPostgres(db *sqlx.DB) *ActivityPostgres {
+	return &ActivityPostgres{db: db}
+}
+
+// GetAll returns one page of the list's activity, latest first, and the cursor of
+// the next page.
+func (r *ActivityPostgres) GetAll(listId int, input todo.ActivityQuery) ([]todo.Activity, string, error) {
+	p, err := newPage("a", "", todo.PageQuery{Limit: input.Limit, Cursor: input.Cursor, Sort: "-id"})
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := "a.list_id=$1"
+	args := []interface{}{listId}
+	if condition, pageArgs := p.condition(len(args) + 1); condition != "" {
+		conditions += " AND " + condition
+		args = append(args, pageArgs...)
+	}
+
+	activity := make([]todo.Activity, 0)
+	query := fmt.Sprintf(`SELECT a.id, a.list_id, a.actor_id, coalesce(u.name, '') AS actor_name, a.action, a.entity_type,
+									a.entity_id, a.changes, a.created_at
+								FROM %s a LEFT JOIN %s u ON u.id = a.actor_id WHERE %s ORDER BY %s LIMIT %d`,
+		activityTable, usersTable, conditions, p.orderBy(), p.fetchLimit())
+	if err := r.db.Select(&activity, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(activity) > p.limit {
+		activity = activity[:p.limit]
+		next = p.nextCursor(activity[p.limit-1].Id, nil)
+	}
+
+	return activity, next, nil
+}
+
+// recordActivity adds an activity record to the list.
+func recordActivity(tx *sql.Tx, listId, actorId int, action, entityType string, entityId int, changes todo.Changes) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6)", activityTable, activityColumns)
+	_, err := tx.Exec(query, listId, actorId, action, entityType, entityId, changes)
+	return err
+}
+
+// recordItemActivity adds an activity record about the item to every list it is in.
+func recordItemActivity(tx *sql.Tx, actorId int, action string, itemId int, changes todo.Changes) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) SELECT list_id, $1, $2, $3, $4, $5 FROM %s WHERE item_id=$4",
+		activityTable, activityColumns, listsItemsTable)
+	_, err := tx.Exec(query, actorId, action, todo.ActivityEntityItem, itemId, changes)
+	return err
+}
+
+func listFields(list todo.TodoList) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       list.Title,
+		"description": list.Description,
+	}
+}
+
+func itemFields(item todo.TodoItem) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       item.Title,
+		"description": item.Description,
+		"done":        item.Done,
+		"due_at":      item.DueAt,
+		"remind_at":   item.RemindAt,
+		"priority":    item.Priority,
+		"parent_id":   item.ParentId,
+		"recurrence":  item.Recurrence,
+		"assignee_id": item.AssigneeId,
+	}
+}
+
+// diffFields returns the fields whose values differ between before and after. A nil
+// before or after stands for an entity that is created or deleted, all of whose
+// fields change.
+func diffFields(before, after map[string]interface{}) todo.Changes {
+	changes := make(todo.Changes)
+	for name, value := range before {
+		if after == nil || !sameValue(value, after[name]) {
+			changes[name] = todo.FieldChange{Before: value, After: after[name]}
+		}
+	}
+	for name, value := range after {
+		if before == nil {
+			changes[name] = todo.FieldChange{After: value}
+		}
+	}
+
+	return changes
+}
+
+// sameValue compares field values, times by the instant they stand for.
+func sameValue(a, b interface{}) bool {
+	if a, ok := a.(*time.Time); ok {
+		if b, ok := b.(*time.Time); ok && a != nil && b != nil {
+			return a.Equal(*b)
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+	todo "todo-app"
+)
+
+// expectActivity expects an activity record about an entity of the list. A nil
+// changes matches any changes.
+func expectActivity(mock sqlmock.Sqlmock, listId, actorId int, action, entityType string, entityId int, changes driver.Value) {
+	if changes == nil {
+		changes = sqlmock.AnyArg()
+	}
+	mock.ExpectExec("INSERT INTO activity \\(list_id, actor_id, action, entity_type, entity_id, changes\\) VALUES (.+)").
+		WithArgs(listId, actorId, action, entityType, entityId, changes).WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectItemActivity expects an activity record about the item in each of its lists.
+func expectItemActivity(mock sqlmock.Sqlmock, actorId int, action string, itemId int, changes driver.Value) {
+	if changes == nil {
+		changes = sqlmock.AnyArg()
+	}
+	mock.ExpectExec("INSERT INTO activity \\(list_id, actor_id, action, entity_type, entity_id, changes\\) "+
+		"SELECT list_id, (.+) FROM lists_items WHERE item_id=(.+)").
+		WithArgs(actorId, action, todo.ActivityEntityItem, itemId, changes).WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectLockItem expects the fields of an untitled item to be read for its activity.
+func expectLockItem(mock sqlmock.Sqlmock, itemId int, done bool) {
+	rows := sqlmock.NewRows([]string{"title", "description", "done", "due_at", "remind_at", "priority", "parent_item_id", "recurrence",
+		"assignee_id"}).AddRow("title", "description", done, nil, nil, int64(0), nil, nil, nil)
+	mock.ExpectQuery("SELECT title, description, done, due_at, remind_at, priority, parent_item_id, recurrence, assignee_id " +
+		"FROM todo_items WHERE id=(.+) FOR UPDATE").WithArgs(itemId).WillReturnRows(rows)
+}
+
+func TestActivity_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestActivity_GetAll func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewActivityPostgres(db)
+
+	createdAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "list_id", "actor_id", "actor_name", "action", "entity_type", "entity_id", "changes", "created_at"}
+
+	testTable := []struct {
+		name         string
+		input        todo.ActivityQuery
+		mockBehavior func()
+		want         []todo.Activity
+		wantNext     string
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			input: todo.ActivityQuery{Limit: 1},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows(columns).
+					AddRow(9, 2, 1, "Alice", "updated", "item", 7, `{"done":{"before":false,"after":true}}`, createdAt).
+					AddRow(8, 2, 1, "Alice", "created", "item", 7, nil, createdAt)
+				mock.ExpectQuery("SELECT (.+) FROM activity a LEFT JOIN users u ON (.+) WHERE a.list_id=(.+) ORDER BY a.id DESC LIMIT 2").
+					WithArgs(2).WillReturnRows(rows)
+			},
+			want: []todo.Activity{{
+				Id: 9, ListId: 2, ActorId: 1, ActorName: "Alice", Action: "updated", EntityType: "item", EntityId: 7,
+				Changes: todo.Changes{"done": {Before: false, After: true}}, CreatedAt: createdAt,
+			}},
+			wantNext: "eyJzIjoiLWlkIiwiaWQiOjl9",
+		},
+		{
+			name:  "After Cursor",
+			input: todo.ActivityQuery{Cursor: "eyJzIjoiLWlkIiwiaWQiOjl9"},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows(columns).AddRow(8, 2, 1, "Alice", "created", "item", 7, nil, createdAt)
+				mock.ExpectQuery("SELECT (.+) WHERE a.list_id=(.+) AND a.id < (.+) ORDER BY a.id DESC LIMIT 51").
+					WithArgs(2, 9).WillReturnRows(rows)
+			},
+			want: []todo.Activity{
+				{Id: 8, ListId: 2, ActorId: 1, ActorName: "Alice", Action: "created", EntityType: "item", EntityId: 7, CreatedAt: createdAt},
+			},
+		},
+		{
+			name:         "Invalid Cursor",
+			input:        todo.ActivityQuery{Cursor: "%%%"},
+			mockBehavior: func() {},
+			wantErr:      todo.ErrValidation,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, next, err := r.GetAll(2, testCase.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+				assert.Equal(t, testCase.wantNext, next)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	due := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	sameDue := due.In(time.FixedZone("UTC+3", 3*60*60))
+
+	before := itemFields(todo.TodoItem{Title: "milk", DueAt: &due, Priority: todo.PriorityLow})
+	after := itemFields(todo.TodoItem{Title: "oat milk", DueAt: &sameDue, Priority: todo.PriorityLow, Done: true})
+
+	assert.Equal(t, todo.Changes{
+		"title": {Before: "milk", After: "oat milk"},
+		"done":  {Before: false, After: true},
+	}, diffFields(before, after))
+
+	assert.Equal(t, todo.Changes{
+		"title":       {After: "milk"},
+		"description": {After: ""},
+	}, diffFields(nil, listFields(todo.TodoList{Title: "milk"})))
+
+	assert.Equal(t, todo.Changes{
+		"title":       {Before: "milk"},
+		"description": {Before: ""},
+	}, diffFields(listFields(todo.TodoList{Title: "milk"}), nil))
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	todo "todo-app"
+)
+
+var errAssignee = fmt.Errorf("%w: assignee must be a member of the item's list", todo.ErrValidation)
+
+// GetAssigned returns the open items of all the user's lists that are assigned to them,
+// the ones due first first.
+func (r *TodoItemRepository) GetAssigned(userId int) ([]todo.TodoItem, error) {
+	items := make([]todo.TodoItem, 0)
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
+		"INNER JOIN %s ul ON ul.list_id=li.list_id INNER JOIN %s tl ON tl.id=li.list_id "+
+		"WHERE ul.user_id=$1 AND ti.assignee_id=$1 AND NOT ti.done AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL "+
+		"ORDER BY ti.due_at NULLS LAST, ti.id",
+		itemColumns, todoItemsTable, listsItemsTable, usersListsTable, todoListsTable)
+	if err := r.db.Select(&items, query, userId); err != nil {
+		return nil, err
+	}
+
+	return items, loadItemLabels(r.db, userId, items)
+}
+
+// checkAssignee fails unless the assignee is a member of the list.
+func checkAssignee(tx *sql.Tx, listId, assigneeId int) error {
+	var member bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE list_id=$1 AND user_id=$2)", usersListsTable)
+	if err := tx.QueryRow(query, listId, assigneeId).Scan(&member); err != nil {
+		return err
+	}
+
+	if !member {
+		return errAssignee
+	}
+
+	return nil
+}
+
+// checkItemAssignee fails unless the assignee is a member of one of the item's lists.
+func checkItemAssignee(tx *sql.Tx, itemId, assigneeId int) error {
+	var member bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s li INNER JOIN %s ul ON ul.list_id=li.list_id "+
+		"WHERE li.item_id=$1 AND ul.user_id=$2)", listsItemsTable, usersListsTable)
+	if err := tx.QueryRow(query, itemId, assigneeId).Scan(&member); err != nil {
+		return err
+	}
+
+	if !member {
+		return errAssignee
+	}
+
+	return nil
+}
+
+// unassignMember unassigns a member who left the list from its items, unless they are
+// still a member of another list the item belongs to.
+func unassignMember(tx *sql.Tx, listId, memberId int) error {
+	query := fmt.Sprintf("UPDATE %[1]s ti SET assignee_id=NULL, version=ti.version+1 FROM %[2]s li WHERE li.item_id=ti.id AND li.list_id=$1 "+
+		"AND ti.assignee_id=$2 AND NOT EXISTS (SELECT 1 FROM %[2]s oli INNER JOIN %[3]s ul ON ul.list_id=oli.list_id "+
+		"WHERE oli.item_id=ti.id AND ul.user_id=$2)", todoItemsTable, listsItemsTable, usersListsTable)
+	_, err := tx.Exec(query, listId, memberId)
+	return err
+}
+
+// unassignNonMembers unassigns the items taken out of a list from assignees who are not
+// a member of any list the items are still in.
+func unassignNonMembers(tx *sql.Tx, itemIds []int64) error {
+	query := fmt.Sprintf("UPDATE %[1]s ti SET assignee_id=NULL, version=ti.version+1 WHERE ti.id = ANY($1) "+
+		"AND ti.assignee_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %[2]s oli INNER JOIN %[3]s ul ON ul.list_id=oli.list_id "+
+		"WHERE oli.item_id=ti.id AND ul.user_id=ti.assignee_id)", todoItemsTable, listsItemsTable, usersListsTable)
+	_, err := tx.Exec(query, pq.Array(itemIds))
+	return err
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func TestItem_GetAssigned(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestItem_GetAssigned func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "assignee_id"}).
+		AddRow(3, "title3", "description3", false, 1).
+		AddRow(5, "title5", "description5", false, 1)
+	mock.ExpectQuery("SELECT DISTINCT (.+) FROM todo_items ti (.+) WHERE ul.user_id=(.+) AND ti.assignee_id=(.+) AND NOT ti.done " +
+		"(.+) ORDER BY ti.due_at NULLS LAST, ti.id").
+		WithArgs(1).WillReturnRows(rows)
+	expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
+
+	got, err := r.GetAssigned(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.TodoItem{
+		{Id: 3, Title: "title3", Description: "description3", AssigneeId: intPointer(1)},
+		{Id: 5, Title: "title5", Description: "description5", AssigneeId: intPointer(1)},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	todo "todo-app"
+)
+
+const attachmentColumns = "a.id, coalesce(a.item_id, 0) AS item_id, a.user_id, a.filename, a.size, a.content_type, a.sha256, a.storage_key, a.created_at"
+
+// usageQuery sums the sizes of the attachments a user uploaded that are still attached.
+const usageQuery = "SELECT coalesce(sum(size), 0) FROM %s WHERE user_id=$1 AND item_id IS NOT NULL"
+
+type AttachmentPostgres struct {
+	db *sqlx.DB
+}
+
+func NewAttachmentPostgres(db *sqlx.DB) *AttachmentPostgres {
+	return &AttachmentPostgres{db: db}
+}
+
+// Create adds the metadata of a stored blob to an item the user can see, unless it takes
+// the user's attachments over the quota. The user's row stays locked until the insert is
+// committed, so concurrent uploads of the same user are checked one after another.
+func (r *AttachmentPostgres) Create(userId int, attachment todo.Attachment, quota int64) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE id=$1 FOR UPDATE", usersTable)
+	if err := requireAffected(tx.Exec(query, userId)); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	var usage int64
+	if err := tx.QueryRow(fmt.Sprintf(usageQuery, attachmentsTable), userId).Scan(&usage); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if usage+attachment.Size > quota {
+		tx.Rollback()
+		return 0, fmt.Errorf("%w: storage quota of %d bytes exceeded", todo.ErrTooLarge, quota)
+	}
+
+	var id int
+	query = fmt.Sprintf("INSERT INTO %s (item_id, user_id, filename, size, content_type, sha256, storage_key) "+
+		"SELECT $2, $1, $3, $4, $5, $6, $7 WHERE EXISTS (SELECT 1 FROM %s ti %s AND ti.id=$2) RETURNING id",
+		attachmentsTable, todoItemsTable, itemAccess)
+	err = tx.QueryRow(query, userId, attachment.ItemId, attachment.Filename, attachment.Size, attachment.ContentType,
+		attachment.SHA256, attachment.StorageKey).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return 0, translateError(err)
+	}
+
+	return id, tx.Commit()
+}
+
+// GetAll returns the attachments of an item the user can see, oldest first.
+func (r *AttachmentPostgres) GetAll(userId, itemId int) ([]todo.Attachment, error) {
+	var visible bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s ti %s AND ti.id=$2)", todoItemsTable, itemAccess)
+	if err := r.db.Get(&visible, query, userId, itemId); err != nil {
+		return nil, err
+	}
+
+	if !visible {
+		return nil, todo.ErrNotFound
+	}
+
+	attachments := make([]todo.Attachment, 0)
+	query = fmt.Sprintf("SELECT %s FROM %s a WHERE a.item_id=$1 ORDER BY a.id", attachmentColumns, attachmentsTable)
+	err := r.db.Select(&attachments, query, itemId)
+
+	return attachments, err
+}
+
+// GetById returns an attachment of an item the user can see.
+func (r *AttachmentPostgres) GetById(userId, attachmentId int) (todo.Attachment, error) {
+	var attachment todo.Attachment
+	query := fmt.Sprintf("SELECT %s FROM %s a WHERE a.id=$2 AND EXISTS (SELECT 1 FROM %s ti %s AND ti.id=a.item_id)",
+		attachmentColumns, attachmentsTable, todoItemsTable, itemAccess)
+	err := r.db.Get(&attachment, query, userId, attachmentId)
+
+	return attachment, translateError(err)
+}
+
+// GetUsage returns how many bytes the attachments the user uploaded take up.
+func (r *AttachmentPostgres) GetUsage(userId int) (int64, error) {
+	var usage int64
+	err := r.db.Get(&usage, fmt.Sprintf(usageQuery, attachmentsTable), userId)
+
+	return usage, err
+}
+
+// Detach takes the attachment off its item. Detached attachments are what is left of
+// removed items too; they wait for their blobs to be removed.
+func (r *AttachmentPostgres) Detach(attachmentId int) error {
+	query := fmt.Sprintf("UPDATE %s SET item_id=NULL WHERE id=$1 AND item_id IS NOT NULL", attachmentsTable)
+	return requireAffected(r.db.Exec(query, attachmentId))
+}
+
+// GetDetached returns up to limit attachments that belong to no item anymore.
+func (r *AttachmentPostgres) GetDetached(limit int) ([]todo.Attachment, error) {
+	attachments := make([]todo.Attachment, 0)
+	query := fmt.Sprintf("SELECT %s FROM %s a WHERE a.item_id IS NULL ORDER BY a.id LIMIT $1", attachmentColumns, attachmentsTable)
+	err := r.db.Select(&attachments, query, limit)
+
+	return attachments, err
+}
+
+// Delete removes the metadata of a detached attachment once its blob is gone.
+func (r *AttachmentPostgres) Delete(attachmentId int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id=$1 AND item_id IS NULL", attachmentsTable)
+	return requireAffected(r.db.Exec(query, attachmentId))
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+	todo "todo-app"
+)
+
+func TestAttachment_Create(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestAttachment_Create func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewAttachmentPostgres(db)
+
+	attachment := todo.Attachment{ItemId: 2, UserId: 1, Filename: "shot.png", Size: 2048, ContentType: "image/png",
+		SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", StorageKey: "key"}
+	quota := int64(1 << 20)
+
+	// expectUsage expects the user's row to be locked before the usage is summed
+	expectUsage := func(usage int64) {
+		mock.ExpectExec("SELECT 1 FROM users WHERE id=(.+) FOR UPDATE").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT coalesce\\(sum\\(size\\), 0\\) FROM attachments WHERE user_id=(.+) AND item_id IS NOT NULL").
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(usage))
+	}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectUsage(4096)
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(3)
+				mock.ExpectQuery("INSERT INTO attachments \\(item_id, user_id, filename, size, content_type, sha256, storage_key\\) "+
+					"SELECT (.+) WHERE EXISTS \\(SELECT 1 FROM todo_items ti (.+) AND ti.id=(.+)\\) RETURNING id").
+					WithArgs(1, 2, "shot.png", 2048, "image/png", attachment.SHA256, "key").WillReturnRows(rows)
+				mock.ExpectCommit()
+			},
+			want: 3,
+		},
+		{
+			name: "Over Quota",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectUsage(quota - 2047)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrTooLarge,
+		},
+		{
+			name: "Item Not Visible",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectUsage(0)
+				mock.ExpectQuery("INSERT INTO attachments (.+)").
+					WithArgs(1, 2, "shot.png", 2048, "image/png", attachment.SHA256, "key").WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Create(1, attachment, quota)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachment_GetUsage(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestAttachment_GetUsage func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewAttachmentPostgres(db)
+
+	mock.ExpectQuery("SELECT coalesce\\(sum\\(size\\), 0\\) FROM attachments WHERE user_id=(.+) AND item_id IS NOT NULL").
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(4096))
+
+	got, err := r.GetUsage(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4096), got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachment_GetDetached(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestAttachment_GetDetached func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewAttachmentPostgres(db)
+
+	createdAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "item_id", "user_id", "filename", "size", "content_type", "sha256", "storage_key",
+		"created_at"}).AddRow(3, 0, 1, "shot.png", 2048, "image/png", "abc", "key", createdAt)
+	mock.ExpectQuery("SELECT (.+) FROM attachments a WHERE a.item_id IS NULL ORDER BY a.id LIMIT (.+)").
+		WithArgs(100).WillReturnRows(rows)
+
+	got, err := r.GetDetached(100)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.Attachment{{Id: 3, UserId: 1, Filename: "shot.png", Size: 2048, ContentType: "image/png",
+		SHA256: "abc", StorageKey: "key", CreatedAt: createdAt}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachment_Detach(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestAttachment_Detach func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewAttachmentPostgres(db)
+
+	mock.ExpectExec("UPDATE attachments SET item_id=NULL WHERE id=(.+) AND item_id IS NOT NULL").
+		WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.Detach(3))
+
+	mock.ExpectExec("UPDATE attachments (.+)").WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, r.Detach(4), todo.ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
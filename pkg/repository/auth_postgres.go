@@ -16,20 +16,36 @@ func NewAuthPostgres(db *sqlx.DB) *AuthPostgres {
 
 func (r *AuthPostgres) CreateUser(user todo.User) (int, error) {
 	var id int
-	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash) values ($1,$2,$3) RETURNING id", usersTable)
+	query := fmt.Sprintf("INSERT INTO %s (name, username, password_hash, time_zone) values ($1,$2,$3,$4) RETURNING id",
+		usersTable)
 
-	row := r.db.QueryRow(query, user.Name, user.Username, user.Password)
+	row := r.db.QueryRow(query, user.Name, user.Username, user.Password, user.TimeZone)
 	if err := row.Scan(&id); err != nil {
-		return 0, err
+		return 0, translateError(err)
 	}
 
 	return id, nil
 }
 
-func (r *AuthPostgres) GetUser(username, password string) (todo.User, error) {
+func (r *AuthPostgres) GetUser(username string) (todo.User, error) {
 	var user todo.User
-	query := fmt.Sprintf("SELECT id FROM %s WHERE username=$1 AND password_hash=$2", usersTable)
-	err := r.db.Get(&user, query, username, password)
+	query := fmt.Sprintf("SELECT id, name, username, password_hash FROM %s WHERE username=$1", usersTable)
+	err := r.db.Get(&user, query, username)
 
-	return user, err
+	return user, translateError(err)
+}
+
+func (r *AuthPostgres) UpdatePasswordHash(userId int, passwordHash string) error {
+	query := fmt.Sprintf("UPDATE %s SET password_hash=$1 WHERE id=$2", usersTable)
+	_, err := r.db.Exec(query, passwordHash, userId)
+
+	return err
+}
+
+func (r *AuthPostgres) GetTimeZone(userId int) (string, error) {
+	var timeZone string
+	query := fmt.Sprintf("SELECT time_zone FROM %s WHERE id=$1", usersTable)
+	err := r.db.Get(&timeZone, query, userId)
+
+	return timeZone, translateError(err)
 }
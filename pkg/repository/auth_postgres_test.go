@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 	"log"
@@ -30,12 +31,13 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 				Name:     "Test",
 				Username: "test",
 				Password: "qwerty",
+				TimeZone: "Europe/Moscow",
 			},
 			id: 4,
 			mockBehavior: func(user todo.User, id int) {
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(id)
 
-				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password).
+				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password, user.TimeZone).
 					WillReturnRows(rows)
 			},
 		},
@@ -50,7 +52,7 @@ func TestAuthPostgres_CreateUser(t *testing.T) {
 			mockBehavior: func(user todo.User, id int) {
 				rows := sqlmock.NewRows([]string{"id"})
 
-				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password).
+				mock.ExpectQuery("INSERT INTO users").WithArgs(user.Name, user.Username, user.Password, user.TimeZone).
 					WillReturnRows(rows)
 			},
 			wantErr: true,
@@ -81,48 +83,97 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 
 	r := NewAuthPostgres(db)
 
+	testTable := []struct {
+		name         string
+		username     string
+		mockBehavior func(username string)
+		want         todo.User
+		wantErr      bool
+	}{
+		{
+			name:     "OK",
+			username: "test",
+			mockBehavior: func(username string) {
+				rows := sqlmock.NewRows([]string{"id", "name", "username", "password_hash"}).
+					AddRow("1", "Test", "test", "$argon2id$hash")
+				mock.ExpectQuery("SELECT (.+) FROM users WHERE (.+)").WithArgs(username).
+					WillReturnRows(rows)
+			},
+			want: todo.User{
+				Id:       1,
+				Name:     "Test",
+				Username: "test",
+				Password: "$argon2id$hash",
+			},
+		},
+		{
+			name:     "Not Found",
+			username: "",
+			mockBehavior: func(username string) {
+				rows := sqlmock.NewRows([]string{"id", "name", "username", "password_hash"})
+
+				mock.ExpectQuery("SELECT (.+) FROM users WHERE (.+)").WithArgs(username).
+					WillReturnRows(rows)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.username)
+
+			got, err := r.GetUser(testCase.username)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthPostgres_UpdatePasswordHash(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestAuthPostgres_UpdatePasswordHash func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewAuthPostgres(db)
+
 	type args struct {
-		username string
-		password string
+		userId       int
+		passwordHash string
 	}
 
 	testTable := []struct {
 		name         string
 		args         args
 		mockBehavior func(args args)
-		want         todo.User
 		wantErr      bool
 	}{
 		{
 			name: "OK",
 			args: args{
-				username: "test",
-				password: "qwerty",
+				userId:       1,
+				passwordHash: "$argon2id$hash",
 			},
 			mockBehavior: func(args args) {
-				rows := sqlmock.NewRows([]string{"id", "name", "username", "password"}).
-					AddRow("1", "Test", "test", "qwerty")
-				mock.ExpectQuery("SELECT (.+) FROM users WHERE (.+)").WithArgs(args.username, args.password).
-					WillReturnRows(rows)
-			},
-			want: todo.User{
-				Id:       1,
-				Name:     "Test",
-				Username: "test",
-				Password: "qwerty",
+				mock.ExpectExec("UPDATE users SET password_hash=(.+) WHERE (.+)").
+					WithArgs(args.passwordHash, args.userId).WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 		},
 		{
-			name: "Empty Fields",
+			name: "DB Error",
 			args: args{
-				username: "",
-				password: "qwerty",
+				userId:       1,
+				passwordHash: "$argon2id$hash",
 			},
 			mockBehavior: func(args args) {
-				rows := sqlmock.NewRows([]string{"id", "name", "username", "password"})
-
-				mock.ExpectQuery("SELECT (.+) FROM users WHERE (.+)").WithArgs(args.username, args.password).
-					WillReturnRows(rows)
+				mock.ExpectExec("UPDATE users SET password_hash=(.+) WHERE (.+)").
+					WithArgs(args.passwordHash, args.userId).WillReturnError(errors.New("some error"))
 			},
 			wantErr: true,
 		},
@@ -132,12 +183,11 @@ func TestAuthPostgres_GetUser(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
-			got, err := r.GetUser(testCase.args.username, testCase.args.password)
+			err := r.UpdatePasswordHash(testCase.args.userId, testCase.args.passwordHash)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, testCase.want, got)
 			}
 		})
 	}
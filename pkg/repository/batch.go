@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	todo "todo-app"
+)
+
+// Schedule returns the next occurrence of a recurring item that was marked done.
+// ok is false for items that do not repeat or whose schedule has ended.
+type Schedule func(item todo.TodoItem) (next todo.TodoItem, ok bool, err error)
+
+// Batch runs the operations on the items of the list in one transaction. An atomic batch
+// fails with the first failing operation; in a best effort batch every operation runs in
+// a savepoint, so that a failure only undoes that operation and is reported in its result.
+func (r *TodoItemRepository) Batch(userId, listId int, input todo.BatchInput, schedule Schedule) ([]todo.BatchResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	bestEffort := input.Mode == todo.BatchBestEffort
+	results := make([]todo.BatchResult, len(input.Operations))
+	for index, op := range input.Operations {
+		if bestEffort {
+			if _, err := tx.Exec("SAVEPOINT batch_operation"); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		result, err := runBatchOperation(tx, userId, listId, op, schedule)
+		if err != nil && !bestEffort {
+			tx.Rollback()
+			return nil, fmt.Errorf("operation %d: %w", index, err)
+		}
+
+		savepoint := "RELEASE SAVEPOINT batch_operation"
+		if err != nil {
+			savepoint = "ROLLBACK TO SAVEPOINT batch_operation"
+			result = todo.BatchResult{Op: op.Op, Id: op.ItemId, Status: todo.BatchFailed, Error: err.Error()}
+		}
+
+		if bestEffort {
+			if _, err := tx.Exec(savepoint); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+		results[index] = result
+	}
+
+	return results, tx.Commit()
+}
+
+func runBatchOperation(tx *sql.Tx, userId, listId int, op todo.BatchOperation, schedule Schedule) (todo.BatchResult, error) {
+	result := todo.BatchResult{Op: op.Op, Id: op.ItemId, Status: todo.BatchOk}
+
+	switch op.Op {
+	case todo.BatchCreate:
+		item := *op.Item
+		if item.ParentId != nil {
+			if err := checkParentList(tx, *item.ParentId, listId); err != nil {
+				return result, err
+			}
+		}
+
+		itemId, err := createItem(tx, listId, item)
+		if err != nil {
+			return result, err
+		}
+		result.Id = itemId
+
+		return result, recordItemCreated(tx, userId, itemId, item)
+	case todo.BatchUpdate:
+		if err := requireListItem(tx, listId, op.ItemId); err != nil {
+			return result, err
+		}
+
+		update := *op.Update
+		update.Version = op.Version
+
+		return result, updateAndSchedule(tx, userId, op.ItemId, update, schedule)
+	case todo.BatchDelete:
+		if err := requireListItem(tx, listId, op.ItemId); err != nil {
+			return result, err
+		}
+
+		return result, deleteItem(tx, userId, op.ItemId, op.Version)
+	case todo.BatchCompleteAll:
+		// subtasks come before their parents, which cannot be done while a subtask is open
+		query := fmt.Sprintf("WITH RECURSIVE ancestors AS (SELECT ti.id AS item_id, ti.parent_item_id FROM %s ti "+
+			"INNER JOIN %s li ON li.item_id=ti.id WHERE li.list_id=$1 AND NOT ti.done AND ti.deleted_at IS NULL "+
+			"UNION ALL SELECT a.item_id, p.parent_item_id FROM ancestors a INNER JOIN %[1]s p ON p.id=a.parent_item_id) "+
+			"SELECT item_id FROM ancestors GROUP BY item_id ORDER BY count(*) DESC, item_id",
+			todoItemsTable, listsItemsTable)
+		ids, err := queryIds(tx, query, listId)
+		if err != nil {
+			return result, err
+		}
+
+		done := true
+		for _, id := range ids {
+			before, err := lockItemFields(tx, int(id))
+			if err != nil {
+				return result, err
+			}
+
+			if err := updateItem(tx, userId, int(id), before, todo.UpdateItemInput{Done: &done}); err != nil {
+				return result, err
+			}
+		}
+
+		// Next occurrences are added once everything is done, as an open occurrence of a
+		// subtask would keep its parent from being completed. Parents come first here, so
+		// that the occurrence of a subtask reopens its parent like a single update does
+		// only after the parent's own occurrence is scheduled.
+		for i := len(ids) - 1; i >= 0; i-- {
+			id := ids[i]
+			after, err := lockItemFields(tx, int(id))
+			if err != nil {
+				return result, err
+			}
+
+			if err := scheduleNext(tx, userId, int(id), after, schedule); err != nil {
+				return result, err
+			}
+		}
+		result.Count = len(ids)
+
+		return result, nil
+	case todo.BatchClearCompleted:
+		// a done parent has no open subtasks and takes its subtree to the trash with it
+		query := fmt.Sprintf("SELECT ti.id FROM %[1]s ti INNER JOIN %[2]s li ON li.item_id=ti.id "+
+			"WHERE li.list_id=$1 AND ti.done AND ti.deleted_at IS NULL AND NOT EXISTS "+
+			"(SELECT 1 FROM %[1]s p WHERE p.id=ti.parent_item_id AND p.done AND p.deleted_at IS NULL) ORDER BY li.position, ti.id",
+			todoItemsTable, listsItemsTable)
+		ids, err := queryIds(tx, query, listId)
+		if err != nil {
+			return result, err
+		}
+
+		for _, id := range ids {
+			if err := deleteItem(tx, userId, int(id), nil); err != nil {
+				return result, err
+			}
+		}
+		result.Count = len(ids)
+
+		return result, nil
+	}
+
+	return result, fmt.Errorf("%w: unknown operation %q", todo.ErrValidation, op.Op)
+}
+
+// requireListItem checks that the item is in the list and not in the trash.
+func requireListItem(tx *sql.Tx, listId, itemId int) error {
+	var ok bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s li INNER JOIN %s ti ON ti.id=li.item_id "+
+		"WHERE li.list_id=$1 AND li.item_id=$2 AND ti.deleted_at IS NULL)", listsItemsTable, todoItemsTable)
+	if err := tx.QueryRow(query, listId, itemId).Scan(&ok); err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: item %d is not in the list", todo.ErrNotFound, itemId)
+	}
+
+	return nil
+}
+
+// updateAndSchedule applies the update like Update and, when it marks a recurring item
+// done, adds the next occurrence like CompleteOccurrence.
+func updateAndSchedule(tx *sql.Tx, userId, itemId int, input todo.UpdateItemInput, schedule Schedule) error {
+	before, err := lockItemFields(tx, itemId)
+	if err != nil {
+		return err
+	}
+
+	if err := updateItem(tx, userId, itemId, before, input); err != nil {
+		return err
+	}
+
+	if before.Done || input.Done == nil || !*input.Done {
+		return nil
+	}
+
+	after, err := lockItemFields(tx, itemId)
+	if err != nil {
+		return err
+	}
+
+	return scheduleNext(tx, userId, itemId, after, schedule)
+}
+
+// scheduleNext adds the next occurrence of the item, which has just been marked done
+// and whose fields are now after.
+func scheduleNext(tx *sql.Tx, userId, itemId int, after todo.TodoItem, schedule Schedule) error {
+	next, ok, err := schedule(after)
+	if err != nil || !ok {
+		return err
+	}
+
+	return createOccurrence(tx, userId, itemId, next)
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func noOccurrence(item todo.TodoItem) (todo.TodoItem, bool, error) {
+	return todo.TodoItem{}, false, nil
+}
+
+func expectListItem(mock sqlmock.Sqlmock, listId, itemId int, ok bool) {
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items li INNER JOIN todo_items ti ON (.+) "+
+		"WHERE li.list_id=(.+) AND li.item_id=(.+) AND ti.deleted_at IS NULL\\)").
+		WithArgs(listId, itemId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(ok))
+}
+
+func expectDeleteItem(mock sqlmock.Sqlmock, userId, itemId int) {
+	mock.ExpectExec("WITH RECURSIVE subtree AS \\((.+)\\) UPDATE todo_items SET deleted_at=now\\(\\) WHERE id IN \\(SELECT id FROM subtree\\)").
+		WithArgs(userId, itemId, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectLockItem(mock, itemId, true)
+	expectItemActivity(mock, userId, todo.ActivityDeleted, itemId, nil)
+}
+
+func expectCompleteItem(mock sqlmock.Sqlmock, userId, itemId int) {
+	expectLockItem(mock, itemId, false)
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM todo_items WHERE parent_item_id=(.+) AND NOT done AND deleted_at IS NULL\\)").
+		WithArgs(itemId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("UPDATE todo_items ti SET done=(.+) FROM (.+)").
+		WithArgs(true, userId, itemId).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectItemActivity(mock, userId, todo.ActivityUpdated, itemId, nil)
+}
+
+func TestItem_Batch(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestItem_Batch func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	testTable := []struct {
+		name         string
+		input        todo.BatchInput
+		mockBehavior func()
+		want         []todo.BatchResult
+		wantErr      error
+	}{
+		{
+			name: "Atomic",
+			input: todo.BatchInput{Operations: []todo.BatchOperation{
+				{Op: todo.BatchCreate, Item: &todo.TodoItem{Title: "buy milk", Priority: todo.PriorityNone}},
+				{Op: todo.BatchDelete, ItemId: 7},
+			}},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("INSERT INTO todo_items (.+) RETURNING id").
+					WithArgs("buy milk", "", nil, nil, int64(0), nil, nil, nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+				mock.ExpectExec("INSERT INTO lists_items").
+					WithArgs(3, 9, "000001").WillReturnResult(sqlmock.NewResult(1, 1))
+				expectItemActivity(mock, 1, todo.ActivityCreated, 9, nil)
+				expectListItem(mock, 3, 7, true)
+				expectDeleteItem(mock, 1, 7)
+				mock.ExpectCommit()
+			},
+			want: []todo.BatchResult{
+				{Op: todo.BatchCreate, Id: 9, Status: todo.BatchOk},
+				{Op: todo.BatchDelete, Id: 7, Status: todo.BatchOk},
+			},
+		},
+		{
+			name: "Atomic Failure",
+			input: todo.BatchInput{Operations: []todo.BatchOperation{
+				{Op: todo.BatchDelete, ItemId: 7},
+				{Op: todo.BatchUpdate, ItemId: 8, Update: &todo.UpdateItemInput{Title: stringPointer("title")}},
+			}},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectListItem(mock, 3, 7, true)
+				expectDeleteItem(mock, 1, 7)
+				expectListItem(mock, 3, 8, false)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+		{
+			name: "Best Effort",
+			input: todo.BatchInput{Mode: todo.BatchBestEffort, Operations: []todo.BatchOperation{
+				{Op: todo.BatchDelete, ItemId: 8},
+				{Op: todo.BatchDelete, ItemId: 7},
+			}},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("^SAVEPOINT batch_operation").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectListItem(mock, 3, 8, false)
+				mock.ExpectExec("^ROLLBACK TO SAVEPOINT batch_operation").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("^SAVEPOINT batch_operation").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectListItem(mock, 3, 7, true)
+				expectDeleteItem(mock, 1, 7)
+				mock.ExpectExec("^RELEASE SAVEPOINT batch_operation").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			want: []todo.BatchResult{
+				{Op: todo.BatchDelete, Id: 8, Status: todo.BatchFailed, Error: "not found: item 8 is not in the list"},
+				{Op: todo.BatchDelete, Id: 7, Status: todo.BatchOk},
+			},
+		},
+		{
+			name:  "Complete All",
+			input: todo.BatchInput{Operations: []todo.BatchOperation{{Op: todo.BatchCompleteAll}}},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("WITH RECURSIVE ancestors AS \\((.+) WHERE li.list_id=(.+) AND NOT ti.done (.+)\\) " +
+					"SELECT item_id FROM ancestors GROUP BY item_id ORDER BY count\\(\\*\\) DESC, item_id").
+					WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"item_id"}).AddRow(5).AddRow(4))
+				expectCompleteItem(mock, 1, 5)
+				expectCompleteItem(mock, 1, 4)
+				expectLockItem(mock, 4, true)
+				expectLockItem(mock, 5, true)
+				mock.ExpectCommit()
+			},
+			want: []todo.BatchResult{{Op: todo.BatchCompleteAll, Count: 2, Status: todo.BatchOk}},
+		},
+		{
+			name:  "Clear Completed",
+			input: todo.BatchInput{Operations: []todo.BatchOperation{{Op: todo.BatchClearCompleted}}},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT ti.id FROM todo_items ti INNER JOIN lists_items li ON (.+) " +
+					"WHERE li.list_id=(.+) AND ti.done AND ti.deleted_at IS NULL AND NOT EXISTS (.+) ORDER BY li.position, ti.id").
+					WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+				expectDeleteItem(mock, 1, 6)
+				mock.ExpectCommit()
+			},
+			want: []todo.BatchResult{{Op: todo.BatchClearCompleted, Count: 1, Status: todo.BatchOk}},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Batch(1, 3, testCase.input, noOccurrence)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestItem_Batch_RecurringSubtask(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestItem_Batch_RecurringSubtask func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	expectDoneItem := func(itemId int, parentId interface{}, recurrence string) {
+		rows := sqlmock.NewRows([]string{"title", "description", "done", "due_at", "remind_at", "priority", "parent_item_id",
+			"recurrence", "assignee_id"}).AddRow("title", "", true, nil, nil, int64(0), parentId, recurrence, nil)
+		mock.ExpectQuery("SELECT (.+) FROM todo_items WHERE id=(.+) FOR UPDATE").WithArgs(itemId).WillReturnRows(rows)
+	}
+
+	// the subtask 5 of item 4 repeats daily and the item weekly; the item is scheduled
+	// first, then the subtask's occurrence reopens it like a single update would
+	mock.ExpectBegin()
+	mock.ExpectQuery("WITH RECURSIVE ancestors AS (.+)").
+		WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"item_id"}).AddRow(5).AddRow(4))
+	expectCompleteItem(mock, 1, 5)
+	expectCompleteItem(mock, 1, 4)
+	expectDoneItem(4, nil, "FREQ=WEEKLY")
+	mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+		WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(3))
+	mock.ExpectQuery("INSERT INTO todo_items (.+) RETURNING id").
+		WithArgs("title", "", nil, nil, int64(0), nil, "FREQ=WEEKLY", nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+		WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000004"))
+	mock.ExpectExec("INSERT INTO lists_items").WithArgs(3, 9, "000005").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+) AND list_id<>(.+)").
+		WithArgs(4, 3).WillReturnRows(sqlmock.NewRows([]string{"list_id"}))
+	mock.ExpectExec("INSERT INTO items_labels (.+)").WithArgs(9, 4).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectItemActivity(mock, 1, todo.ActivityCreated, 9, nil)
+
+	expectDoneItem(5, 4, "FREQ=DAILY")
+	mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+		WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(3))
+	mock.ExpectQuery("INSERT INTO todo_items (.+) RETURNING id").
+		WithArgs("title", "", nil, nil, int64(0), 4, "FREQ=DAILY", nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+		WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000005"))
+	mock.ExpectExec("INSERT INTO lists_items").WithArgs(3, 10, "000006").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+) AND list_id<>(.+)").
+		WithArgs(4, 3).WillReturnRows(sqlmock.NewRows([]string{"list_id"}))
+	mock.ExpectExec("WITH RECURSIVE ancestors AS (.+) UPDATE todo_items SET done=false, completed_at=NULL").
+		WithArgs(10).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO items_labels (.+)").WithArgs(10, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectItemActivity(mock, 1, todo.ActivityCreated, 10, nil)
+	mock.ExpectCommit()
+
+	var scheduled []todo.Recurrence
+	schedule := func(item todo.TodoItem) (todo.TodoItem, bool, error) {
+		scheduled = append(scheduled, item.Recurrence)
+		item.Done = false
+		return item, item.Recurrence != "", nil
+	}
+
+	input := todo.BatchInput{Operations: []todo.BatchOperation{{Op: todo.BatchCompleteAll}}}
+	got, err := r.Batch(1, 3, input, schedule)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.BatchResult{{Op: todo.BatchCompleteAll, Count: 2, Status: todo.BatchOk}}, got)
+	assert.Equal(t, []todo.Recurrence{"FREQ=WEEKLY", "FREQ=DAILY"}, scheduled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
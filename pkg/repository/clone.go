@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	todo "todo-app"
+)
+
+// clonedItem is an item of the source list with its position in the list.
+type clonedItem struct {
+	todo.TodoItem
+	Position string `db:"position"`
+}
+
+// Clone copies the list and all of its items into a new list owned by the user.
+func (r *TodoListPostgres) Clone(userId, listId int, input todo.CloneListInput) (int, error) {
+	keep := func(title string) (string, error) {
+		return title, nil
+	}
+
+	return r.clone(userId, listId, input.Title, false, input.Template, keep)
+}
+
+// Instantiate creates a list from the template, filling in the variables of the titles.
+func (r *TodoListPostgres) Instantiate(userId, templateId int, input todo.InstantiateTemplateInput) (int, error) {
+	expand := func(title string) (string, error) {
+		return todo.ExpandTemplate(title, input.Variables)
+	}
+
+	return r.clone(userId, templateId, input.Title, true, false, expand)
+}
+
+func (r *TodoListPostgres) clone(userId, listId int, title string, fromTemplate, asTemplate bool,
+	expand func(string) (string, error)) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := cloneList(tx, userId, listId, title, fromTemplate, asTemplate, expand)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// cloneList copies the list, titled title or after the source, and expands the titles of
+// the copy. Items are copied open and unassigned in their order and keep their subtasks
+// and labels; the copy is appended to the user's lists.
+func cloneList(tx *sqlx.Tx, userId, listId int, title string, fromTemplate, asTemplate bool,
+	expand func(string) (string, error)) (int, error) {
+	var source todo.TodoList
+	query := fmt.Sprintf("SELECT tl.title, tl.description, tl.is_template FROM %s tl INNER JOIN %s ul ON tl.id=ul.list_id "+
+		"WHERE ul.user_id=$1 AND ul.list_id=$2 AND tl.deleted_at IS NULL", todoListsTable, usersListsTable)
+	if err := tx.Get(&source, query, userId, listId); err != nil {
+		return 0, translateError(err)
+	}
+
+	if fromTemplate && !source.Template {
+		return 0, fmt.Errorf("%w: template", todo.ErrNotFound)
+	}
+
+	if title == "" {
+		title = source.Title
+	}
+
+	title, err := expand(title)
+	if err != nil {
+		return 0, err
+	}
+
+	list := todo.TodoList{Title: title, Description: source.Description}
+	var id int
+	createListQuery := fmt.Sprintf("INSERT INTO %s (title, description, is_template) VALUES ($1,$2,$3) RETURNING id", todoListsTable)
+	if err := tx.QueryRow(createListQuery, list.Title, list.Description, asTemplate).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	position, err := appendListPosition(tx.Tx, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	createUsersListsQuery := fmt.Sprintf("INSERT INTO %s (user_id, list_id, role, position) VALUES ($1,$2,$3,$4)", usersListsTable)
+	if _, err := tx.Exec(createUsersListsQuery, userId, id, todo.RoleOwner, position); err != nil {
+		return 0, err
+	}
+
+	var items []clonedItem
+	query = fmt.Sprintf("SELECT %s, li.position FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
+		"WHERE li.list_id=$1 AND ti.deleted_at IS NULL ORDER BY li.position, ti.id", itemColumns, todoItemsTable, listsItemsTable)
+	if err := tx.Select(&items, query, listId); err != nil {
+		return 0, err
+	}
+
+	copies := make(map[int]int, len(items))
+	for _, item := range items {
+		itemTitle, err := expand(item.Title)
+		if err != nil {
+			return 0, err
+		}
+
+		var copyId int
+		createItemQuery := fmt.Sprintf("INSERT INTO %s (title, description, due_at, remind_at, priority, recurrence) "+
+			"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id", todoItemsTable)
+		err = tx.QueryRow(createItemQuery, itemTitle, item.Description, item.DueAt, item.RemindAt, item.Priority,
+			item.Recurrence).Scan(&copyId)
+		if err != nil {
+			return 0, err
+		}
+
+		createListItemsQuery := fmt.Sprintf("INSERT INTO %s (list_id, item_id, position) VALUES ($1, $2, $3)", listsItemsTable)
+		if _, err := tx.Exec(createListItemsQuery, id, copyId, item.Position); err != nil {
+			return 0, err
+		}
+
+		if err := copyLabels(tx.Tx, userId, item.Id, copyId); err != nil {
+			return 0, err
+		}
+
+		copies[item.Id] = copyId
+	}
+
+	// Subtasks are linked once every item is copied, as a subtask may come before its parent.
+	for _, item := range items {
+		if item.ParentId == nil {
+			continue
+		}
+
+		parentId, ok := copies[*item.ParentId]
+		if !ok {
+			continue
+		}
+
+		query := fmt.Sprintf("UPDATE %s SET parent_item_id=$1 WHERE id=$2", todoItemsTable)
+		if _, err := tx.Exec(query, parentId, copies[item.Id]); err != nil {
+			return 0, err
+		}
+	}
+
+	changes := diffFields(nil, listFields(list))
+	if err := recordActivity(tx.Tx, id, userId, todo.ActivityCreated, todo.ActivityEntityList, id, changes); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
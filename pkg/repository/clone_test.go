@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+var clonedItemColumns = []string{"id", "title", "description", "done", "priority", "parent_item_id", "position"}
+
+// expectCloneList expects the source list to be read and the copy to be created.
+func expectCloneList(mock sqlmock.Sqlmock, userId, listId int, title string, isTemplate, asTemplate bool, cloneId int) {
+	mock.ExpectQuery("SELECT tl.title, tl.description, tl.is_template FROM todo_lists tl INNER JOIN users_lists ul ON (.+) "+
+		"WHERE ul.user_id=(.+) AND ul.list_id=(.+) AND tl.deleted_at IS NULL").WithArgs(userId, listId).
+		WillReturnRows(sqlmock.NewRows([]string{"title", "description", "is_template"}).AddRow(title, "description", isTemplate))
+	if cloneId == 0 {
+		return
+	}
+
+	mock.ExpectQuery("INSERT INTO todo_lists \\(title, description, is_template\\)").
+		WithArgs(sqlmock.AnyArg(), "description", asTemplate).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(cloneId))
+	mock.ExpectQuery("SELECT max\\(position\\) FROM users_lists WHERE user_id=(.+)").WithArgs(userId).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("00000a"))
+	mock.ExpectExec("INSERT INTO users_lists").WithArgs(userId, cloneId, todo.RoleOwner, "00000b").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectClonedItem expects the item to be copied into the list at the same position,
+// with the user's own labels.
+func expectClonedItem(mock sqlmock.Sqlmock, userId, listId, itemId int, title, position string, copyId int) {
+	mock.ExpectQuery("INSERT INTO todo_items \\(title, description, due_at, remind_at, priority, recurrence\\)").
+		WithArgs(title, "", nil, nil, 0, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(copyId))
+	mock.ExpectExec("INSERT INTO lists_items \\(list_id, item_id, position\\)").WithArgs(listId, copyId, position).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO items_labels \\(item_id, label_id\\) SELECT (.+) FROM items_labels il "+
+		"INNER JOIN labels l ON l.id=il.label_id WHERE il.item_id=(.+) AND l.user_id=(.+)").
+		WithArgs(copyId, itemId, userId).WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestList_Clone(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestList_Clone func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoListPostgres(db)
+
+	type args struct {
+		userId int
+		listId int
+		input  todo.CloneListInput
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, listId: 2},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectCloneList(mock, args.userId, args.listId, "Release {{version}}", false, false, 10)
+
+				rows := sqlmock.NewRows(clonedItemColumns).
+					AddRow(5, "subtask", "", true, 0, 3, "000001").
+					AddRow(3, "parent", "", true, 0, nil, "000002")
+				mock.ExpectQuery("SELECT (.+), li.position FROM todo_items ti INNER JOIN lists_items li ON (.+) " +
+					"WHERE li.list_id=(.+) AND ti.deleted_at IS NULL ORDER BY li.position, ti.id").
+					WithArgs(args.listId).WillReturnRows(rows)
+
+				expectClonedItem(mock, args.userId, 10, 5, "subtask", "000001", 21)
+				expectClonedItem(mock, args.userId, 10, 3, "parent", "000002", 22)
+
+				mock.ExpectExec("UPDATE todo_items SET parent_item_id=(.+) WHERE id=(.+)").WithArgs(22, 21).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				expectActivity(mock, 10, args.userId, todo.ActivityCreated, todo.ActivityEntityList, 10,
+					`{"description":{"before":null,"after":"description"},"title":{"before":null,"after":"Release {{version}}"}}`)
+				mock.ExpectCommit()
+			},
+			want: 10,
+		},
+		{
+			name: "As Template",
+			args: args{userId: 1, listId: 2, input: todo.CloneListInput{Title: "Release {{version}}", Template: true}},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectCloneList(mock, args.userId, args.listId, "Release 1.0", false, true, 10)
+
+				mock.ExpectQuery("SELECT (.+), li.position FROM todo_items ti").WithArgs(args.listId).
+					WillReturnRows(sqlmock.NewRows(clonedItemColumns))
+
+				expectActivity(mock, 10, args.userId, todo.ActivityCreated, todo.ActivityEntityList, 10,
+					`{"description":{"before":null,"after":"description"},"title":{"before":null,"after":"Release {{version}}"}}`)
+				mock.ExpectCommit()
+			},
+			want: 10,
+		},
+		{
+			name: "Not Found",
+			args: args{userId: 1, listId: 2},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT tl.title, tl.description, tl.is_template FROM todo_lists tl").
+					WithArgs(args.userId, args.listId).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.Clone(testCase.args.userId, testCase.args.listId, testCase.args.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestList_Instantiate(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestList_Instantiate func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoListPostgres(db)
+
+	type args struct {
+		userId     int
+		templateId int
+		input      todo.InstantiateTemplateInput
+	}
+
+	variables := map[string]string{"date": "2024-05-01", "version": "1.2"}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, templateId: 2, input: todo.InstantiateTemplateInput{Variables: variables}},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectCloneList(mock, args.userId, args.templateId, "Release {{ version }}", true, false, 10)
+
+				rows := sqlmock.NewRows(clonedItemColumns).AddRow(3, "Announce {{version}} on {{date}}", "", false, 0, nil, "000001")
+				mock.ExpectQuery("SELECT (.+), li.position FROM todo_items ti").WithArgs(args.templateId).
+					WillReturnRows(rows)
+
+				expectClonedItem(mock, args.userId, 10, 3, "Announce 1.2 on 2024-05-01", "000001", 21)
+
+				expectActivity(mock, 10, args.userId, todo.ActivityCreated, todo.ActivityEntityList, 10,
+					`{"description":{"before":null,"after":"description"},"title":{"before":null,"after":"Release 1.2"}}`)
+				mock.ExpectCommit()
+			},
+			want: 10,
+		},
+		{
+			name: "Not A Template",
+			args: args{userId: 1, templateId: 2, input: todo.InstantiateTemplateInput{Variables: variables}},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectCloneList(mock, args.userId, args.templateId, "Release", false, false, 0)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+		{
+			name: "Unknown Variable",
+			args: args{userId: 1, templateId: 2, input: todo.InstantiateTemplateInput{Title: "Sprint {{sprint}}"}},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				expectCloneList(mock, args.userId, args.templateId, "Sprint", true, false, 0)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrValidation,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.Instantiate(testCase.args.userId, testCase.args.templateId, testCase.args.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
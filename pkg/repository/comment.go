@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	todo "todo-app"
+)
+
+const commentColumns = "c.id, c.item_id, c.user_id, u.name AS author, c.body, c.created_at, c.updated_at"
+
+type CommentPostgres struct {
+	db *sqlx.DB
+}
+
+func NewCommentPostgres(db *sqlx.DB) *CommentPostgres {
+	return &CommentPostgres{db: db}
+}
+
+// Create adds the user's comment to an item they can see.
+func (r *CommentPostgres) Create(userId, itemId int, input todo.CommentInput) (int, error) {
+	var id int
+	query := fmt.Sprintf("WITH created AS (INSERT INTO %s (item_id, user_id, body) SELECT $2, $1, $3 "+
+		"WHERE EXISTS (SELECT 1 FROM %s ti %s AND ti.id=$2) RETURNING id, item_id), "+
+		"changed AS (UPDATE %[2]s SET version=version+1 WHERE id IN (SELECT item_id FROM created)) SELECT id FROM created",
+		itemCommentsTable, todoItemsTable, itemAccess)
+	err := r.db.QueryRow(query, userId, itemId, input.Body).Scan(&id)
+
+	return id, translateError(err)
+}
+
+// GetAll returns the comments of an item the user can see, oldest first.
+func (r *CommentPostgres) GetAll(userId, itemId int) ([]todo.Comment, error) {
+	var visible bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s ti %s AND ti.id=$2)", todoItemsTable, itemAccess)
+	if err := r.db.Get(&visible, query, userId, itemId); err != nil {
+		return nil, err
+	}
+
+	if !visible {
+		return nil, todo.ErrNotFound
+	}
+
+	comments := make([]todo.Comment, 0)
+	query = fmt.Sprintf("SELECT %s FROM %s c INNER JOIN %s u ON u.id=c.user_id WHERE c.item_id=$1 ORDER BY c.id",
+		commentColumns, itemCommentsTable, usersTable)
+	err := r.db.Select(&comments, query, itemId)
+
+	return comments, err
+}
+
+// GetById returns a comment on an item the user can see.
+func (r *CommentPostgres) GetById(userId, commentId int) (todo.Comment, error) {
+	var comment todo.Comment
+	query := fmt.Sprintf("SELECT %s FROM %s c INNER JOIN %s u ON u.id=c.user_id "+
+		"WHERE c.id=$2 AND EXISTS (SELECT 1 FROM %s ti %s AND ti.id=c.item_id)",
+		commentColumns, itemCommentsTable, usersTable, todoItemsTable, itemAccess)
+	err := r.db.Get(&comment, query, userId, commentId)
+
+	return comment, translateError(err)
+}
+
+func (r *CommentPostgres) Update(commentId int, input todo.CommentInput) error {
+	query := fmt.Sprintf("UPDATE %s SET body=$1, updated_at=now() WHERE id=$2", itemCommentsTable)
+	return requireAffected(r.db.Exec(query, input.Body, commentId))
+}
+
+func (r *CommentPostgres) Delete(commentId int) error {
+	query := bumpChangedItems(fmt.Sprintf("DELETE FROM %s WHERE id=$1 RETURNING item_id", itemCommentsTable))
+	return requireAffected(r.db.Exec(query, commentId))
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+	todo "todo-app"
+)
+
+func TestComment_Create(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestComment_Create func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewCommentPostgres(db)
+
+	type args struct {
+		userId, itemId int
+		input          todo.CommentInput
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, itemId: 2, input: todo.CommentInput{Body: "on it"}},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(5)
+				mock.ExpectQuery("WITH created AS \\(INSERT INTO item_comments \\(item_id, user_id, body\\) SELECT (.+) WHERE EXISTS "+
+					"\\(SELECT 1 FROM todo_items ti INNER JOIN lists_items li ON (.+) WHERE ul.user_id=(.+) "+
+					"AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL AND ti.id=(.+)\\) RETURNING id, item_id\\), "+
+					"changed AS \\(UPDATE todo_items SET version=version\\+1 WHERE id IN \\(SELECT item_id FROM created\\)\\) SELECT id FROM created").
+					WithArgs(args.userId, args.itemId, args.input.Body).WillReturnRows(rows)
+			},
+			want: 5,
+		},
+		{
+			name: "Item Not Visible",
+			args: args{userId: 1, itemId: 2, input: todo.CommentInput{Body: "on it"}},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("WITH created AS \\(INSERT INTO item_comments (.+)").
+					WithArgs(args.userId, args.itemId, args.input.Body).WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.Create(testCase.args.userId, testCase.args.itemId, testCase.args.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestComment_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestComment_GetAll func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewCommentPostgres(db)
+
+	createdAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	type args struct {
+		userId, itemId int
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         []todo.Comment
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, itemId: 2},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM todo_items ti (.+) AND ti.id=(.+)\\)").
+					WithArgs(args.userId, args.itemId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+				rows := sqlmock.NewRows([]string{"id", "item_id", "user_id", "author", "body", "created_at", "updated_at"}).
+					AddRow(5, 2, 1, "Alice", "on it", createdAt, nil).
+					AddRow(6, 2, 3, "Bob", "thanks", createdAt, createdAt)
+				mock.ExpectQuery("SELECT (.+) FROM item_comments c INNER JOIN users u ON (.+) WHERE c.item_id=(.+) ORDER BY c.id").
+					WithArgs(args.itemId).WillReturnRows(rows)
+			},
+			want: []todo.Comment{
+				{Id: 5, ItemId: 2, UserId: 1, Author: "Alice", Body: "on it", CreatedAt: createdAt},
+				{Id: 6, ItemId: 2, UserId: 3, Author: "Bob", Body: "thanks", CreatedAt: createdAt, UpdatedAt: &createdAt},
+			},
+		},
+		{
+			name: "Item Not Visible",
+			args: args{userId: 1, itemId: 2},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("SELECT EXISTS (.+)").
+					WithArgs(args.userId, args.itemId).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.GetAll(testCase.args.userId, testCase.args.itemId)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestComment_Update(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestComment_Update func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewCommentPostgres(db)
+
+	input := todo.CommentInput{Body: "done now"}
+
+	mock.ExpectExec("UPDATE item_comments SET body=(.+), updated_at=now\\(\\) WHERE id=(.+)").
+		WithArgs(input.Body, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.Update(5, input))
+
+	mock.ExpectExec("UPDATE item_comments (.+)").WithArgs(input.Body, 6).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, r.Update(6, input), todo.ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestComment_Delete(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestComment_Delete func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewCommentPostgres(db)
+
+	mock.ExpectExec("WITH changed AS \\(DELETE FROM item_comments WHERE id=(.+) RETURNING item_id\\) " +
+		"UPDATE todo_items SET version=version\\+1 WHERE id IN \\(SELECT item_id FROM changed\\)").
+		WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.Delete(5))
+
+	mock.ExpectExec("WITH changed AS \\(DELETE FROM item_comments (.+)").WithArgs(6).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, r.Delete(6), todo.ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	todo "todo-app"
+)
+
+const pqUniqueViolation = "23505"
+
+// translateError maps driver errors onto the domain errors of the todo package.
+func translateError(err error) error {
+	var pqErr *pq.Error
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return todo.ErrNotFound
+	case errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation:
+		return todo.ErrConflict
+	}
+
+	return err
+}
+
+// requireAffected turns an UPDATE or DELETE that matched no rows into todo.ErrNotFound,
+// so that changing a missing or foreign resource does not look like a success.
+func requireAffected(result sql.Result, err error) error {
+	if err != nil {
+		return translateError(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return todo.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"testing"
+	todo "todo-app"
+)
+
+func TestTranslateError(t *testing.T) {
+	someErr := errors.New("some error")
+
+	testTable := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name: "No Rows",
+			err:  sql.ErrNoRows,
+			want: todo.ErrNotFound,
+		},
+		{
+			name: "Wrapped No Rows",
+			err:  fmt.Errorf("scan: %w", sql.ErrNoRows),
+			want: todo.ErrNotFound,
+		},
+		{
+			name: "Unique Violation",
+			err:  &pq.Error{Code: "23505"},
+			want: todo.ErrConflict,
+		},
+		{
+			name: "Other Driver Error",
+			err:  &pq.Error{Code: "23503"},
+			want: &pq.Error{Code: "23503"},
+		},
+		{
+			name: "Unknown Error",
+			err:  someErr,
+			want: someErr,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.want, translateError(testCase.err))
+		})
+	}
+}
+
+func TestRequireAffected(t *testing.T) {
+	assert.NoError(t, requireAffected(sqlmock.NewResult(0, 1), nil))
+	assert.ErrorIs(t, requireAffected(sqlmock.NewResult(0, 0), nil), todo.ErrNotFound)
+	assert.ErrorIs(t, requireAffected(nil, &pq.Error{Code: "23505"}), todo.ErrConflict)
+	assert.Error(t, requireAffected(sqlmock.NewErrorResult(errors.New("some error")), nil))
+}
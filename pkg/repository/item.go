@@ -1,12 +1,23 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"strings"
+	"time"
 	todo "todo-app"
 )
 
+const itemColumns = "ti.id, ti.title, ti.description, ti.done, ti.created_at, ti.due_at, ti.remind_at, ti.completed_at, ti.priority, ti.parent_item_id, ti.recurrence, ti.assignee_id, ti.version, " +
+	"(SELECT count(*) FROM item_comments ic WHERE ic.item_id=ti.id) AS comment_count"
+
+// itemAccess joins an item ti to the lists of user $1 and leaves out the item if it or
+// the list is in the trash. Everything attached to an item is checked with it.
+var itemAccess = fmt.Sprintf("INNER JOIN %s li ON li.item_id=ti.id INNER JOIN %s ul ON ul.list_id=li.list_id "+
+	"INNER JOIN %s tl ON tl.id=li.list_id WHERE ul.user_id=$1 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL",
+	listsItemsTable, usersListsTable, todoListsTable)
+
 type TodoItemRepository struct {
 	db *sqlx.DB
 }
@@ -15,25 +26,26 @@ func NewTodoItemRepository(db *sqlx.DB) *TodoItemRepository {
 	return &TodoItemRepository{db: db}
 }
 
-func (r *TodoItemRepository) CreateItem(listId int, item todo.TodoItem) (int, error) {
+func (r *TodoItemRepository) CreateItem(userId, listId int, item todo.TodoItem) (int, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return 0, err
 	}
 
-	var itemId int
-	createItemQuery := fmt.Sprintf("INSERT INTO %s (title, description) values ($1, $2) RETURNING id", todoItemsTable)
+	if item.ParentId != nil {
+		if err := checkParentList(tx, *item.ParentId, listId); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
 
-	row := tx.QueryRow(createItemQuery, item.Title, item.Description)
-	err = row.Scan(&itemId)
+	itemId, err := createItem(tx, listId, item)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
-	createListItemsQuery := fmt.Sprintf("INSERT INTO %s (list_id, item_id) values ($1, $2)", listsItemsTable)
-	_, err = tx.Exec(createListItemsQuery, listId, itemId)
-	if err != nil {
+	if err := recordItemCreated(tx, userId, itemId, item); err != nil {
 		tx.Rollback()
 		return 0, err
 	}
@@ -41,68 +53,440 @@ func (r *TodoItemRepository) CreateItem(listId int, item todo.TodoItem) (int, er
 	return itemId, tx.Commit()
 }
 
-func (r *TodoItemRepository) GetAll(userId, listId int) ([]todo.TodoItem, error) {
+// createItem appends the item to the list. Subtasks are added to the other lists of their
+// parent as well, and open ones reopen the parents they are added to.
+func createItem(tx *sql.Tx, listId int, item todo.TodoItem) (int, error) {
+	var itemId int
+	if item.AssigneeId != nil {
+		if err := checkAssignee(tx, listId, *item.AssigneeId); err != nil {
+			return 0, err
+		}
+	}
+
+	createItemQuery := fmt.Sprintf("INSERT INTO %s (title, description, due_at, remind_at, priority, parent_item_id, recurrence, "+
+		"assignee_id) values ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", todoItemsTable)
+
+	row := tx.QueryRow(createItemQuery, item.Title, item.Description, item.DueAt, item.RemindAt, item.Priority, item.ParentId,
+		item.Recurrence, item.AssigneeId)
+	if err := row.Scan(&itemId); err != nil {
+		return 0, err
+	}
+
+	last, err := lastRank(tx, fmt.Sprintf("SELECT max(position) FROM %s WHERE list_id=$1", listsItemsTable), listId)
+	if err != nil {
+		return 0, err
+	}
+
+	createListItemsQuery := fmt.Sprintf("INSERT INTO %s (list_id, item_id, position) values ($1, $2, $3)", listsItemsTable)
+	if _, err := tx.Exec(createListItemsQuery, listId, itemId, rankAfter(last)); err != nil {
+		return 0, err
+	}
+
+	if item.ParentId != nil {
+		if err := linkToListsOf(tx, itemId, *item.ParentId, listId); err != nil {
+			return 0, err
+		}
+
+		if err := reopenAncestors(tx, itemId); err != nil {
+			return 0, err
+		}
+	}
+
+	return itemId, nil
+}
+
+// lockItemFields reads the fields of the item that activity is recorded for and
+// locks the item until the transaction ends.
+func lockItemFields(tx *sql.Tx, itemId int) (todo.TodoItem, error) {
+	var item todo.TodoItem
+	query := fmt.Sprintf("SELECT title, description, done, due_at, remind_at, priority, parent_item_id, recurrence, assignee_id "+
+		"FROM %s WHERE id=$1 FOR UPDATE", todoItemsTable)
+	err := tx.QueryRow(query, itemId).Scan(&item.Title, &item.Description, &item.Done, &item.DueAt, &item.RemindAt,
+		&item.Priority, &item.ParentId, &item.Recurrence, &item.AssigneeId)
+
+	return item, translateError(err)
+}
+
+func recordItemCreated(tx *sql.Tx, userId, itemId int, item todo.TodoItem) error {
+	return recordItemActivity(tx, userId, todo.ActivityCreated, itemId, diffFields(nil, itemFields(item)))
+}
+
+// GetAll returns one page of the list's items and the cursor of the next page.
+func (r *TodoItemRepository) GetAll(userId, listId int, input todo.ItemsQuery) ([]todo.TodoItem, string, error) {
+	p, err := newPage("ti", "li", input.PageQuery)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := []string{"li.list_id=$1", "ul.user_id=$2", "ti.deleted_at IS NULL", "tl.deleted_at IS NULL"}
+	args := []interface{}{listId, userId}
+
+	if input.Title != "" {
+		args = append(args, likePattern(input.Title))
+		conditions = append(conditions, fmt.Sprintf("ti.title ILIKE $%d", len(args)))
+	}
+
+	if input.Done != nil {
+		args = append(args, *input.Done)
+		conditions = append(conditions, fmt.Sprintf("ti.done=$%d", len(args)))
+	}
+
+	if input.Tree {
+		conditions = append(conditions, "ti.parent_item_id IS NULL")
+	}
+
+	if input.Assigned == todo.AssignedToMe {
+		conditions = append(conditions, "ti.assignee_id=ul.user_id")
+	}
+
+	if input.Label != 0 {
+		args = append(args, input.Label)
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM %s il INNER JOIN %s l ON l.id=il.label_id "+
+			"WHERE il.item_id=ti.id AND l.user_id=ul.user_id AND l.id=$%d)", itemsLabelsTable, labelsTable, len(args)))
+	}
+
+	if condition, pageArgs := p.condition(len(args) + 1); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, pageArgs...)
+	}
+
 	var items []todo.TodoItem
-	query := fmt.Sprintf("SELECT ti.id, ti.title, ti.description, ti.done FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
-		"INNER JOIN %s ul ON ul.list_id=li.list_id WHERE li.list_id=$1 AND ul.user_id=$2",
-		todoItemsTable, listsItemsTable, usersListsTable)
-	if err := r.db.Select(&items, query, listId, userId); err != nil {
-		return nil, err
+	query := fmt.Sprintf("SELECT %s, li.position FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
+		"INNER JOIN %s ul ON ul.list_id=li.list_id INNER JOIN %s tl ON tl.id=li.list_id WHERE %s ORDER BY %s LIMIT %d",
+		itemColumns, todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, strings.Join(conditions, " AND "),
+		p.orderBy(), p.fetchLimit())
+	if err := r.db.Select(&items, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(items) > p.limit {
+		items = items[:p.limit]
+		last := items[p.limit-1]
+		next = p.nextCursor(last.Id, map[string]interface{}{
+			"title": last.Title, "created_at": last.CreatedAt, "priority": last.Priority, "due_at": last.DueAt,
+			"position": last.Position,
+		})
+	}
+
+	if err := loadItemLabels(r.db, userId, items); err != nil {
+		return nil, "", err
 	}
 
-	return items, nil
+	if input.Tree {
+		if err := loadSubtasks(r.db, userId, listId, items); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return items, next, nil
 }
 
 func (r *TodoItemRepository) GetById(userId, itemId int) (todo.TodoItem, error) {
 	var item todo.TodoItem
-	query := fmt.Sprintf("SELECT ti.id, ti.title, ti.description, ti.done FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
-		"INNER JOIN %s ul ON ul.list_id=li.list_id WHERE ul.user_id=$1 AND ti.id=$2",
-		todoItemsTable, listsItemsTable, usersListsTable)
+	query := fmt.Sprintf("SELECT %s FROM %s ti %s AND ti.id=$2", itemColumns, todoItemsTable, itemAccess)
 	if err := r.db.Get(&item, query, userId, itemId); err != nil {
+		return item, translateError(err)
+	}
+
+	items := []todo.TodoItem{item}
+	if err := loadItemLabels(r.db, userId, items); err != nil {
 		return item, err
 	}
 
-	return item, nil
+	return items[0], nil
+}
+
+// GetDue returns the open items of all the user's lists that are due in [from, to).
+// A zero from leaves the range open towards the past.
+func (r *TodoItemRepository) GetDue(userId int, from, to time.Time) ([]todo.TodoItem, error) {
+	conditions := []string{"ul.user_id=$1", "ti.deleted_at IS NULL", "tl.deleted_at IS NULL", "NOT ti.done", "ti.due_at < $2"}
+	args := []interface{}{userId, to}
+
+	if !from.IsZero() {
+		args = append(args, from)
+		conditions = append(conditions, fmt.Sprintf("ti.due_at >= $%d", len(args)))
+	}
+
+	items := make([]todo.TodoItem, 0)
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
+		"INNER JOIN %s ul ON ul.list_id=li.list_id INNER JOIN %s tl ON tl.id=li.list_id WHERE %s ORDER BY ti.due_at, ti.id",
+		itemColumns, todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, strings.Join(conditions, " AND "))
+	if err := r.db.Select(&items, query, args...); err != nil {
+		return nil, err
+	}
+
+	return items, loadItemLabels(r.db, userId, items)
+}
+
+// GetByLabel returns the items of all the user's lists tagged with the label.
+func (r *TodoItemRepository) GetByLabel(userId, labelId int) ([]todo.TodoItem, error) {
+	items := make([]todo.TodoItem, 0)
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
+		"INNER JOIN %s ul ON ul.list_id=li.list_id INNER JOIN %s tl ON tl.id=li.list_id INNER JOIN %s il ON il.item_id=ti.id "+
+		"INNER JOIN %s l ON l.id=il.label_id AND l.user_id=ul.user_id "+
+		"WHERE ul.user_id=$1 AND l.id=$2 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL ORDER BY ti.id",
+		itemColumns, todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, itemsLabelsTable, labelsTable)
+	if err := r.db.Select(&items, query, userId, labelId); err != nil {
+		return nil, err
+	}
+
+	return items, loadItemLabels(r.db, userId, items)
 }
 
 func (r *TodoItemRepository) Update(userId, itemId int, input todo.UpdateItemInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	before, err := lockItemFields(tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := updateItem(tx, userId, itemId, before, input); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CompleteOccurrence applies the update, which marks a recurring item done, and adds
+// the next occurrence to the item's list. Nothing is added if the item was done already.
+func (r *TodoItemRepository) CompleteOccurrence(userId, itemId int, input todo.UpdateItemInput, next todo.TodoItem) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	before, err := lockItemFields(tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := updateItem(tx, userId, itemId, before, input); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !before.Done {
+		if err := createOccurrence(tx, userId, itemId, next); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func createOccurrence(tx *sql.Tx, userId, itemId int, next todo.TodoItem) error {
+	var listId int
+	query := fmt.Sprintf("SELECT list_id FROM %s WHERE item_id=$1 ORDER BY id LIMIT 1", listsItemsTable)
+	if err := tx.QueryRow(query, itemId).Scan(&listId); err != nil {
+		return err
+	}
+
+	nextId, err := createItem(tx, listId, next)
+	if err != nil {
+		return err
+	}
+
+	if next.ParentId == nil {
+		if err := linkToListsOf(tx, nextId, itemId, listId); err != nil {
+			return err
+		}
+	}
+
+	// The occurrence continues the same item, so every member keeps their labels on it.
+	if err := carryLabels(tx, itemId, nextId); err != nil {
+		return err
+	}
+
+	return recordItemCreated(tx, userId, nextId, next)
+}
+
+// updateItem applies the input to the item, whose fields before the update were
+// read with lockItemFields.
+func updateItem(tx *sql.Tx, userId, itemId int, before todo.TodoItem, input todo.UpdateItemInput) error {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
+	after := before
 
 	if input.Title != nil {
 		setValues = append(setValues, fmt.Sprintf("title=$%d", argId))
 		args = append(args, *input.Title)
 		argId++
+		after.Title = *input.Title
 	}
 
 	if input.Description != nil {
 		setValues = append(setValues, fmt.Sprintf("description=$%d", argId))
 		args = append(args, *input.Description)
 		argId++
+		after.Description = *input.Description
 	}
 
 	if input.Done != nil {
-		setValues = append(setValues, fmt.Sprintf("done=$%d", argId))
+		// completed_at keeps the time an item was first marked done and is cleared once it is reopened
+		setValues = append(setValues, fmt.Sprintf("done=$%d", argId),
+			fmt.Sprintf("completed_at=CASE WHEN $%d THEN coalesce(ti.completed_at, now()) ELSE NULL END", argId))
 		args = append(args, *input.Done)
 		argId++
+		after.Done = *input.Done
 	}
 
+	if input.DueAt.Set {
+		setValues = append(setValues, fmt.Sprintf("due_at=$%d", argId))
+		args = append(args, input.DueAt.Time)
+		argId++
+		after.DueAt = input.DueAt.Time
+	}
+
+	if input.RemindAt.Set {
+		setValues = append(setValues, fmt.Sprintf("remind_at=$%d", argId))
+		args = append(args, input.RemindAt.Time)
+		argId++
+		after.RemindAt = input.RemindAt.Time
+	}
+
+	if input.Priority != nil {
+		setValues = append(setValues, fmt.Sprintf("priority=$%d", argId))
+		args = append(args, *input.Priority)
+		argId++
+		after.Priority = *input.Priority
+	}
+
+	if input.ParentId.Set {
+		setValues = append(setValues, fmt.Sprintf("parent_item_id=$%d", argId))
+		args = append(args, input.ParentId.Value)
+		argId++
+		after.ParentId = input.ParentId.Value
+	}
+
+	if input.Recurrence != nil {
+		setValues = append(setValues, fmt.Sprintf("recurrence=$%d", argId))
+		args = append(args, *input.Recurrence)
+		argId++
+		after.Recurrence = *input.Recurrence
+	}
+
+	if input.AssigneeId.Set {
+		setValues = append(setValues, fmt.Sprintf("assignee_id=$%d", argId))
+		args = append(args, input.AssigneeId.Value)
+		argId++
+		after.AssigneeId = input.AssigneeId.Value
+	}
+
+	// every update bumps the version the client sees as the ETag of the item
+	setValues = append(setValues, "version=ti.version+1")
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf("UPDATE %s ti SET %s FROM %s li, %s ul "+
-		"WHERE ti.id=li.item_id AND li.list_id=ul.list_id AND ul.user_id=$%d AND ti.id=$%d",
-		todoItemsTable, setQuery, listsItemsTable, usersListsTable, argId, argId+1)
+	query := fmt.Sprintf("UPDATE %s ti SET %s FROM %s li, %s ul, %s tl "+
+		"WHERE ti.id=li.item_id AND li.list_id=ul.list_id AND tl.id=li.list_id AND ul.user_id=$%d AND ti.id=$%d "+
+		"AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL",
+		todoItemsTable, setQuery, listsItemsTable, usersListsTable, todoListsTable, argId, argId+1)
 	args = append(args, userId, itemId)
 
-	_, err := r.db.Exec(query, args...)
-	return err
+	if input.Version != nil {
+		query += fmt.Sprintf(" AND ti.version=$%d", argId+2)
+		args = append(args, *input.Version)
+	}
+
+	if err := checkItemUpdate(tx, itemId, input); err != nil {
+		return err
+	}
+
+	if input.AssigneeId.Value != nil {
+		if err := checkItemAssignee(tx, itemId, *input.AssigneeId.Value); err != nil {
+			return err
+		}
+	}
+
+	if err := requireAffected(tx.Exec(query, args...)); err != nil {
+		return checkVersion(tx, todoItemsTable, itemId, input.Version, err)
+	}
+
+	changes := diffFields(itemFields(before), itemFields(after))
+	if err := recordItemActivity(tx, userId, todo.ActivityUpdated, itemId, changes); err != nil {
+		return err
+	}
+
+	// a reopened item, or an open one moved under another parent, reopens its new ancestors
+	if (input.Done != nil && !*input.Done) || input.ParentId.Value != nil {
+		return reopenAncestors(tx, itemId)
+	}
+
+	return nil
+}
+
+// Move changes the position of the item in the input's list or else in its first list.
+func (r *TodoItemRepository) Move(userId, itemId int, input todo.MoveInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	listId := input.ListId
+	if listId == 0 {
+		query := fmt.Sprintf("SELECT list_id FROM %s WHERE item_id=$1 ORDER BY id LIMIT 1", listsItemsTable)
+		if err := tx.QueryRow(query, itemId).Scan(&listId); err != nil {
+			tx.Rollback()
+			return translateError(err)
+		}
+	}
+
+	changes, err := move(tx, listsItemsTable, "list_id", "item_id", listId, itemId, input)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the position is that of the item in this list only
+	if err := recordActivity(tx, listId, userId, todo.ActivityUpdated, todo.ActivityEntityItem, itemId, changes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *TodoItemRepository) Delete(userId, itemId int) error {
-	query := fmt.Sprintf("DELETE FROM %s ti USING %s li, %s ul "+
-		"WHERE ti.id=li.item_id AND li.list_id=ul.list_id AND ul.user_id=$1 AND ti.id=$2",
+// Delete moves the item and its subtasks to the trash. They all get the same deletion
+// time, which tells them apart from subtasks that were trashed on their own before.
+// With version set only that version of the item is deleted.
+func (r *TodoItemRepository) Delete(userId, itemId int, version *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := deleteItem(tx, userId, itemId, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func deleteItem(tx *sql.Tx, userId, itemId int, version *int) error {
+	query := fmt.Sprintf("WITH RECURSIVE subtree AS (SELECT ti.id FROM %[1]s ti INNER JOIN %[2]s li ON li.item_id=ti.id "+
+		"INNER JOIN %[3]s ul ON ul.list_id=li.list_id WHERE ul.user_id=$1 AND ti.id=$2 AND ti.deleted_at IS NULL "+
+		"AND ($3::int IS NULL OR ti.version=$3) "+
+		"UNION SELECT ti.id FROM %[1]s ti INNER JOIN subtree s ON ti.parent_item_id=s.id WHERE ti.deleted_at IS NULL) "+
+		"UPDATE %[1]s SET deleted_at=now() WHERE id IN (SELECT id FROM subtree)",
 		todoItemsTable, listsItemsTable, usersListsTable)
-	_, err := r.db.Exec(query, userId, itemId)
-	return err
+	if err := requireAffected(tx.Exec(query, userId, itemId, version)); err != nil {
+		return checkVersion(tx, todoItemsTable, itemId, version, err)
+	}
+
+	item, err := lockItemFields(tx, itemId)
+	if err != nil {
+		return err
+	}
+
+	return recordItemActivity(tx, userId, todo.ActivityDeleted, itemId, diffFields(itemFields(item), nil))
 }
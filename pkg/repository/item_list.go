@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	todo "todo-app"
+)
+
+var (
+	errSubtaskLists  = fmt.Errorf("%w: subtasks follow the lists of their parent item", todo.ErrValidation)
+	errSourceList    = fmt.Errorf("%w: from_list_id is required for items in several lists", todo.ErrValidation)
+	errLastList      = fmt.Errorf("%w: item must stay in at least one list", todo.ErrConflict)
+	errAlreadyInList = fmt.Errorf("%w: item is already in the list", todo.ErrConflict)
+)
+
+// GetLists returns the lists of the user the item belongs to.
+func (r *TodoItemRepository) GetLists(userId, itemId int) ([]todo.TodoList, error) {
+	lists := make([]todo.TodoList, 0)
+	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, ul.role, tl.created_at, ul.position FROM %s tl
+								INNER JOIN %s ul ON ul.list_id=tl.id INNER JOIN %s li ON li.list_id=tl.id
+								WHERE ul.user_id=$1 AND li.item_id=$2 AND tl.deleted_at IS NULL ORDER BY ul.position, tl.id`,
+		todoListsTable, usersListsTable, listsItemsTable)
+	err := r.db.Select(&lists, query, userId, itemId)
+
+	return lists, err
+}
+
+// Transfer moves the item and those of its subtasks that share its source list to the
+// end of the destination list, keeping their order.
+func (r *TodoItemRepository) Transfer(userId, itemId int, input todo.TransferItemInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := transferItem(tx, userId, itemId, input); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func transferItem(tx *sql.Tx, userId, itemId int, input todo.TransferItemInput) error {
+	if err := requireTopLevel(tx, itemId); err != nil {
+		return err
+	}
+
+	fromListId := input.FromListId
+	if fromListId == 0 {
+		var lists int
+		query := fmt.Sprintf("SELECT coalesce(min(list_id), 0), count(*) FROM %s WHERE item_id=$1", listsItemsTable)
+		if err := tx.QueryRow(query, itemId).Scan(&fromListId, &lists); err != nil {
+			return err
+		}
+
+		if lists > 1 {
+			return errSourceList
+		}
+	}
+
+	ids, err := subtreeIds(tx, itemId)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT item_id FROM %s WHERE list_id=$1 AND item_id = ANY($2) ORDER BY position", listsItemsTable)
+	movedIds, err := queryIds(tx, query, fromListId, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	// the subtasks come along only from the source list
+	if !containsId(movedIds, itemId) {
+		return todo.ErrNotFound
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE list_id=$1 AND item_id = ANY($2)", listsItemsTable)
+	if _, err := tx.Exec(query, fromListId, pq.Array(movedIds)); err != nil {
+		return err
+	}
+
+	if err := linkItems(tx, input.ToListId, movedIds); err != nil {
+		return err
+	}
+
+	if err := unassignNonMembers(tx, movedIds); err != nil {
+		return err
+	}
+
+	changes := todo.Changes{"list_id": {Before: fromListId, After: input.ToListId}}
+	if err := recordActivity(tx, fromListId, userId, todo.ActivityRemoved, todo.ActivityEntityItem, itemId, changes); err != nil {
+		return err
+	}
+
+	return recordActivity(tx, input.ToListId, userId, todo.ActivityAdded, todo.ActivityEntityItem, itemId, changes)
+}
+
+// Copy creates a copy of the item in the list and returns its id. The copy stays a
+// subtask only if its parent is in the list too, and copies start unassigned.
+func (r *TodoItemRepository) Copy(userId, itemId int, input todo.CopyItemInput) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	var item todo.TodoItem
+	query := fmt.Sprintf("SELECT %s FROM %s ti WHERE ti.id=$1 AND ti.deleted_at IS NULL", itemColumns, todoItemsTable)
+	if err := tx.Get(&item, query, itemId); err != nil {
+		tx.Rollback()
+		return 0, translateError(err)
+	}
+
+	if item.ParentId != nil {
+		if err := checkParentList(tx.Tx, *item.ParentId, input.ListId); errors.Is(err, errParentList) {
+			item.ParentId = nil
+		} else if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	copyId, err := copyItem(tx, userId, item, input)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return copyId, tx.Commit()
+}
+
+func copyItem(tx *sqlx.Tx, userId int, item todo.TodoItem, input todo.CopyItemInput) (int, error) {
+	item.AssigneeId = nil
+	item.Done = false
+	copyId, err := createItem(tx.Tx, input.ListId, item)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := diffFields(nil, itemFields(item))
+	changes["copied_from"] = todo.FieldChange{After: item.Id}
+	if err := recordItemActivity(tx.Tx, userId, todo.ActivityCreated, copyId, changes); err != nil {
+		return 0, err
+	}
+
+	if input.Labels {
+		if err := copyLabels(tx.Tx, userId, item.Id, copyId); err != nil {
+			return 0, err
+		}
+	}
+
+	if !input.Subtasks {
+		return copyId, nil
+	}
+
+	var subtasks []todo.TodoItem
+	query := fmt.Sprintf("SELECT %s FROM %s ti WHERE ti.parent_item_id=$1 AND ti.deleted_at IS NULL ORDER BY ti.id", itemColumns, todoItemsTable)
+	if err := tx.Select(&subtasks, query, item.Id); err != nil {
+		return 0, err
+	}
+
+	for _, subtask := range subtasks {
+		subtask.ParentId = &copyId
+		if _, err := copyItem(tx, userId, subtask, input); err != nil {
+			return 0, err
+		}
+	}
+
+	return copyId, nil
+}
+
+// Link adds the item and its subtasks to the end of another list.
+func (r *TodoItemRepository) Link(userId, itemId, listId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := requireTopLevel(tx, itemId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	ids, err := subtreeIds(tx, itemId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := linkItems(tx, listId, ids); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	changes := todo.Changes{"list_id": {After: listId}}
+	if err := recordActivity(tx, listId, userId, todo.ActivityAdded, todo.ActivityEntityItem, itemId, changes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Unlink removes the item and its subtasks from one of its lists. The last list of an
+// item cannot be unlinked, the item has to be deleted instead.
+func (r *TodoItemRepository) Unlink(userId, itemId, listId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := unlinkItem(tx, itemId, listId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	changes := todo.Changes{"list_id": {Before: listId}}
+	if err := recordActivity(tx, listId, userId, todo.ActivityRemoved, todo.ActivityEntityItem, itemId, changes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func unlinkItem(tx *sql.Tx, itemId, listId int) error {
+	if err := requireTopLevel(tx, itemId); err != nil {
+		return err
+	}
+
+	ids, err := subtreeIds(tx, itemId)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE list_id=$1 AND item_id = ANY($2)", listsItemsTable)
+	if err := requireAffected(tx.Exec(query, listId, pq.Array(ids))); err != nil {
+		return err
+	}
+
+	var linked bool
+	query = fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE item_id=$1)", listsItemsTable)
+	if err := tx.QueryRow(query, itemId).Scan(&linked); err != nil {
+		return err
+	}
+
+	if !linked {
+		return errLastList
+	}
+
+	return unassignNonMembers(tx, ids)
+}
+
+// linkItems appends the items to the list in the given order.
+func linkItems(tx *sql.Tx, listId int, itemIds []int64) error {
+	last, err := lastRank(tx, fmt.Sprintf("SELECT max(position) FROM %s WHERE list_id=$1", listsItemsTable), listId)
+	if err != nil {
+		return err
+	}
+
+	positions := make([]string, len(itemIds))
+	for i := range itemIds {
+		last = rankAfter(last)
+		positions[i] = last
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (list_id, item_id, position) SELECT $1, * FROM unnest($2::int[], $3::text[])",
+		listsItemsTable)
+	if _, err := tx.Exec(query, listId, pq.Array(itemIds), pq.Array(positions)); err != nil {
+		if err = translateError(err); errors.Is(err, todo.ErrConflict) {
+			return errAlreadyInList
+		}
+		return err
+	}
+
+	return nil
+}
+
+// linkToListsOf adds the item to the lists of another item except the given one, so that
+// subtasks and occurrences end up in every list of their parent or original.
+func linkToListsOf(tx *sql.Tx, itemId, otherId, listId int) error {
+	query := fmt.Sprintf("SELECT list_id FROM %s WHERE item_id=$1 AND list_id<>$2 ORDER BY list_id", listsItemsTable)
+	listIds, err := queryIds(tx, query, otherId, listId)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range listIds {
+		if err := linkItems(tx, int(id), []int64{int64(itemId)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyLabels attaches the user's labels of one item to another; labels of the other
+// members are private to them and are not copied.
+func copyLabels(tx *sql.Tx, userId, fromItemId, toItemId int) error {
+	query := fmt.Sprintf("INSERT INTO %[1]s (item_id, label_id) SELECT $1, il.label_id FROM %[1]s il "+
+		"INNER JOIN %[2]s l ON l.id=il.label_id WHERE il.item_id=$2 AND l.user_id=$3", itemsLabelsTable, labelsTable)
+	_, err := tx.Exec(query, toItemId, fromItemId, userId)
+	return err
+}
+
+// carryLabels attaches all labels of one item to another, whoever owns them.
+func carryLabels(tx *sql.Tx, fromItemId, toItemId int) error {
+	query := fmt.Sprintf("INSERT INTO %[1]s (item_id, label_id) SELECT $1, label_id FROM %[1]s WHERE item_id=$2",
+		itemsLabelsTable)
+	_, err := tx.Exec(query, toItemId, fromItemId)
+	return err
+}
+
+func requireTopLevel(tx *sql.Tx, itemId int) error {
+	var parentId sql.NullInt64
+	query := fmt.Sprintf("SELECT parent_item_id FROM %s WHERE id=$1", todoItemsTable)
+	if err := tx.QueryRow(query, itemId).Scan(&parentId); err != nil {
+		return translateError(err)
+	}
+
+	if parentId.Valid {
+		return errSubtaskLists
+	}
+
+	return nil
+}
+
+// subtreeIds returns the ids of the item and of all its subtasks.
+func subtreeIds(tx *sql.Tx, itemId int) ([]int64, error) {
+	query := fmt.Sprintf("WITH RECURSIVE subtree AS (SELECT id FROM %[1]s WHERE id=$1 "+
+		"UNION SELECT ti.id FROM %[1]s ti INNER JOIN subtree s ON ti.parent_item_id=s.id) SELECT id FROM subtree ORDER BY id",
+		todoItemsTable)
+	return queryIds(tx, query, itemId)
+}
+
+// queryIds returns the ids the query selects.
+func queryIds(tx *sql.Tx, query string, args ...interface{}) ([]int64, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func containsId(ids []int64, id int) bool {
+	for _, other := range ids {
+		if other == int64(id) {
+			return true
+		}
+	}
+
+	return false
+}
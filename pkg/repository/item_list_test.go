@@ -0,0 +1,378 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func expectTopLevel(mock sqlmock.Sqlmock, itemId int, parentId interface{}) {
+	mock.ExpectQuery("SELECT parent_item_id FROM todo_items WHERE id=(.+)").
+		WithArgs(itemId).WillReturnRows(sqlmock.NewRows([]string{"parent_item_id"}).AddRow(parentId))
+}
+
+func expectSubtree(mock sqlmock.Sqlmock, itemId int, ids ...int) {
+	rows := sqlmock.NewRows([]string{"id"})
+	for _, id := range ids {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery("WITH RECURSIVE subtree AS \\(SELECT id FROM todo_items WHERE id=(.+)\\) SELECT id FROM subtree").
+		WithArgs(itemId).WillReturnRows(rows)
+}
+
+// expectUnassignNonMembers expects the items to lose assignees who are not a member of
+// any of their lists anymore.
+func expectUnassignNonMembers(mock sqlmock.Sqlmock, itemIds string) {
+	mock.ExpectExec("UPDATE todo_items ti SET assignee_id=NULL, version=ti.version\\+1 WHERE ti.id = ANY(.+) " +
+		"AND ti.assignee_id IS NOT NULL AND NOT EXISTS \\(SELECT 1 FROM lists_items oli INNER JOIN users_lists ul ON (.+) " +
+		"WHERE oli.item_id=ti.id AND ul.user_id=ti.assignee_id\\)").
+		WithArgs(itemIds).WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestItem_Transfer(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	testTable := []struct {
+		name         string
+		input        todo.TransferItemInput
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			input: todo.TransferItemInput{ToListId: 4},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				mock.ExpectQuery("SELECT coalesce\\(min\\(list_id\\), 0\\), count\\(\\*\\) FROM lists_items WHERE item_id=(.+)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"min", "count"}).AddRow(3, 1))
+				expectSubtree(mock, 2, 2, 5, 6)
+				mock.ExpectQuery("SELECT item_id FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+) ORDER BY position").
+					WithArgs(3, "{2,5,6}").WillReturnRows(sqlmock.NewRows([]string{"item_id"}).AddRow(2).AddRow(6).AddRow(5))
+				mock.ExpectExec("DELETE FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+)").
+					WithArgs(3, "{2,6,5}").WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000002"))
+				mock.ExpectExec("INSERT INTO lists_items \\(list_id, item_id, position\\) SELECT (.+) FROM unnest(.+)").
+					WithArgs(4, "{2,6,5}", `{"000003","000004","000005"}`).WillReturnResult(sqlmock.NewResult(0, 3))
+				expectUnassignNonMembers(mock, "{2,6,5}")
+				expectActivity(mock, 3, 1, todo.ActivityRemoved, todo.ActivityEntityItem, 2, `{"list_id":{"before":3,"after":4}}`)
+				expectActivity(mock, 4, 1, todo.ActivityAdded, todo.ActivityEntityItem, 2, `{"list_id":{"before":3,"after":4}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "Several Lists",
+			input: todo.TransferItemInput{ToListId: 4},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				mock.ExpectQuery("SELECT coalesce\\(min\\(list_id\\), 0\\), count\\(\\*\\) FROM lists_items WHERE item_id=(.+)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"min", "count"}).AddRow(3, 2))
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrValidation,
+		},
+		{
+			name:  "Subtask",
+			input: todo.TransferItemInput{FromListId: 3, ToListId: 4},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, 1)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrValidation,
+		},
+		{
+			name:  "Not In Source List",
+			input: todo.TransferItemInput{FromListId: 3, ToListId: 4},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				expectSubtree(mock, 2, 2)
+				mock.ExpectQuery("SELECT item_id FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+) ORDER BY position").
+					WithArgs(3, "{2}").WillReturnRows(sqlmock.NewRows([]string{"item_id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+		{
+			name:  "Already In Destination",
+			input: todo.TransferItemInput{FromListId: 3, ToListId: 4},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				expectSubtree(mock, 2, 2)
+				mock.ExpectQuery("SELECT item_id FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+) ORDER BY position").
+					WithArgs(3, "{2}").WillReturnRows(sqlmock.NewRows([]string{"item_id"}).AddRow(2))
+				mock.ExpectExec("DELETE FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+)").
+					WithArgs(3, "{2}").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000002"))
+				mock.ExpectExec("INSERT INTO lists_items \\(list_id, item_id, position\\) SELECT (.+) FROM unnest(.+)").
+					WithArgs(4, "{2}", `{"000003"}`).WillReturnError(&pq.Error{Code: pqUniqueViolation})
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrConflict,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Transfer(1, 2, testCase.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestItem_Copy(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	expectCreate := func(title string, parentId interface{}, id, listId int, position string) {
+		mock.ExpectQuery("INSERT INTO todo_items (.+) RETURNING id").
+			WithArgs(title, "", nil, nil, int64(0), parentId, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+		mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+			WithArgs(listId).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(position))
+		mock.ExpectExec("INSERT INTO lists_items").
+			WithArgs(listId, id, rankAfter(position)).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	testTable := []struct {
+		name         string
+		itemId       int
+		input        todo.CopyItemInput
+		mockBehavior func()
+		id           int
+		wantErr      error
+	}{
+		{
+			name:   "With Subtasks And Labels",
+			itemId: 2,
+			input:  todo.CopyItemInput{ListId: 4, Subtasks: true, Labels: true},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM todo_items ti WHERE ti.id=(.+)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "done"}).AddRow(2, "trip", true))
+				expectCreate("trip", nil, 10, 4, "000003")
+				expectItemActivity(mock, 1, todo.ActivityCreated, 10, `{"assignee_id":{"before":null,"after":null},`+
+					`"copied_from":{"before":null,"after":2},"description":{"before":null,"after":""},"done":{"before":null,"after":false},`+
+					`"due_at":{"before":null,"after":null},"parent_id":{"before":null,"after":null},"priority":{"before":null,"after":""},`+
+					`"recurrence":{"before":null,"after":""},"remind_at":{"before":null,"after":null},"title":{"before":null,"after":"trip"}}`)
+				mock.ExpectExec("INSERT INTO items_labels \\(item_id, label_id\\) SELECT (.+) FROM items_labels il (.+) WHERE il.item_id=(.+) AND l.user_id=(.+)").
+					WithArgs(10, 2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM todo_items ti WHERE ti.parent_item_id=(.+) AND ti.deleted_at IS NULL ORDER BY ti.id").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "parent_item_id"}).AddRow(5, "tickets", 2))
+
+				expectCreate("tickets", 10, 11, 4, "000004")
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+) AND list_id<>(.+)").
+					WithArgs(10, 4).WillReturnRows(sqlmock.NewRows([]string{"list_id"}))
+				mock.ExpectExec("WITH RECURSIVE ancestors AS (.+) UPDATE todo_items SET done=false, completed_at=NULL").
+					WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectItemActivity(mock, 1, todo.ActivityCreated, 11, nil)
+				mock.ExpectExec("INSERT INTO items_labels \\(item_id, label_id\\) SELECT (.+) FROM items_labels il (.+) WHERE il.item_id=(.+) AND l.user_id=(.+)").
+					WithArgs(11, 5, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery("SELECT (.+) FROM todo_items ti WHERE ti.parent_item_id=(.+) AND ti.deleted_at IS NULL ORDER BY ti.id").
+					WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectCommit()
+			},
+			id: 10,
+		},
+		{
+			name:   "Subtask Into Other List",
+			itemId: 5,
+			input:  todo.CopyItemInput{ListId: 4},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM todo_items ti WHERE ti.id=(.+)").
+					WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "parent_item_id"}).AddRow(5, "tickets", 2))
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items li INNER JOIN todo_items ti ON (.+) WHERE li.item_id=(.+) AND li.list_id=(.+) AND ti.deleted_at IS NULL\\)").
+					WithArgs(2, 4).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				expectCreate("tickets", nil, 10, 4, "")
+				expectItemActivity(mock, 1, todo.ActivityCreated, 10, nil)
+				mock.ExpectCommit()
+			},
+			id: 10,
+		},
+		{
+			name:   "Not Found",
+			itemId: 5,
+			input:  todo.CopyItemInput{ListId: 4},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM todo_items ti WHERE ti.id=(.+)").
+					WithArgs(5).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Copy(1, testCase.itemId, testCase.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.id, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestItem_Link(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				expectSubtree(mock, 2, 2, 5)
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000002"))
+				mock.ExpectExec("INSERT INTO lists_items \\(list_id, item_id, position\\) SELECT (.+) FROM unnest(.+)").
+					WithArgs(4, "{2,5}", `{"000003","000004"}`).WillReturnResult(sqlmock.NewResult(0, 2))
+				expectActivity(mock, 4, 1, todo.ActivityAdded, todo.ActivityEntityItem, 2, `{"list_id":{"before":null,"after":4}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Already In List",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				expectSubtree(mock, 2, 2)
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000002"))
+				mock.ExpectExec("INSERT INTO lists_items \\(list_id, item_id, position\\) SELECT (.+) FROM unnest(.+)").
+					WithArgs(4, "{2}", `{"000003"}`).WillReturnError(&pq.Error{Code: pqUniqueViolation})
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrConflict,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Link(1, 2, 4)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestItem_Unlink(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				expectSubtree(mock, 2, 2, 5)
+				mock.ExpectExec("DELETE FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+)").
+					WithArgs(4, "{2,5}").WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items WHERE item_id=(.+)\\)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				expectUnassignNonMembers(mock, "{2,5}")
+				expectActivity(mock, 4, 1, todo.ActivityRemoved, todo.ActivityEntityItem, 2, `{"list_id":{"before":4,"after":null}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Last List",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				expectSubtree(mock, 2, 2)
+				mock.ExpectExec("DELETE FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+)").
+					WithArgs(4, "{2}").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items WHERE item_id=(.+)\\)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrConflict,
+		},
+		{
+			name: "Not In List",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectTopLevel(mock, 2, nil)
+				expectSubtree(mock, 2, 2)
+				mock.ExpectExec("DELETE FROM lists_items WHERE list_id=(.+) AND item_id = ANY(.+)").
+					WithArgs(4, "{2}").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Unlink(1, 2, 4)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 	"log"
 	"testing"
+	"time"
 	todo "todo-app"
 )
 
@@ -39,6 +40,7 @@ func TestItem_CreateItem(t *testing.T) {
 				item: todo.TodoItem{
 					Title:       "test title",
 					Description: "test description",
+					Priority:    todo.PriorityHigh,
 				},
 			},
 			id: 2,
@@ -47,10 +49,14 @@ func TestItem_CreateItem(t *testing.T) {
 
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(id)
 				mock.ExpectQuery("INSERT INTO todo_items").
-					WithArgs(args.item.Title, args.item.Description).WillReturnRows(rows)
+					WithArgs(args.item.Title, args.item.Description, args.item.DueAt, args.item.RemindAt,
+						int64(3), nil, nil, nil).WillReturnRows(rows)
 
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(args.listId).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
 				mock.ExpectExec("INSERT INTO lists_items").
-					WithArgs(args.listId, id).WillReturnResult(sqlmock.NewResult(1, 1))
+					WithArgs(args.listId, id, "000001").WillReturnResult(sqlmock.NewResult(1, 1))
+				expectItemActivity(mock, 1, todo.ActivityCreated, id, nil)
 
 				mock.ExpectCommit()
 			},
@@ -69,7 +75,8 @@ func TestItem_CreateItem(t *testing.T) {
 
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(id).RowError(1, errors.New("some error"))
 				mock.ExpectQuery("INSERT INTO todo_items").
-					WithArgs(args.item.Title, args.item.Description).WillReturnRows(rows)
+					WithArgs(args.item.Title, args.item.Description, args.item.DueAt, args.item.RemindAt,
+						args.item.Priority, args.item.ParentId, args.item.Recurrence, args.item.AssigneeId).WillReturnRows(rows)
 
 				mock.ExpectRollback()
 			},
@@ -90,10 +97,13 @@ func TestItem_CreateItem(t *testing.T) {
 
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(id)
 				mock.ExpectQuery("INSERT INTO todo_items").
-					WithArgs(args.item.Title, args.item.Description).WillReturnRows(rows)
+					WithArgs(args.item.Title, args.item.Description, args.item.DueAt, args.item.RemindAt,
+						args.item.Priority, args.item.ParentId, args.item.Recurrence, args.item.AssigneeId).WillReturnRows(rows)
 
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(args.listId).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
 				mock.ExpectExec("INSERT INTO lists_items").
-					WithArgs(args.listId, id).WillReturnError(errors.New("some error"))
+					WithArgs(args.listId, id, "000001").WillReturnError(errors.New("some error"))
 
 				mock.ExpectRollback()
 			},
@@ -105,7 +115,7 @@ func TestItem_CreateItem(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args, testCase.id)
 
-			got, err := r.CreateItem(testCase.args.listId, testCase.args.item)
+			got, err := r.CreateItem(1, testCase.args.listId, testCase.args.item)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -125,9 +135,13 @@ func TestItem_GetAll(t *testing.T) {
 
 	r := NewTodoItemRepository(db)
 
+	priorityCursor, _ := newPage("ti", "li", todo.PageQuery{Sort: "priority"})
+	dueAt := time.Date(2023, 5, 1, 12, 30, 0, 0, time.UTC)
+
 	type args struct {
 		userId int
 		listId int
+		query  todo.ItemsQuery
 	}
 
 	testTable := []struct {
@@ -135,6 +149,7 @@ func TestItem_GetAll(t *testing.T) {
 		mockBehavior func()
 		input        args
 		want         []todo.TodoItem
+		wantNext     string
 		wantErr      bool
 	}{
 		{
@@ -146,15 +161,22 @@ func TestItem_GetAll(t *testing.T) {
 					AddRow(3, "title3", "description3", false)
 				mock.ExpectQuery("SELECT (.+) FROM todo_items ti INNER JOIN lists_items li ON (.+) INNER JOIN users_lists ul ON (.+) WHERE (.+) AND (.+)").
 					WillReturnRows(rows)
+
+				expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}).
+					AddRow(1, 4, "home", "#00ff00").
+					AddRow(1, 5, "urgent", "#ff0000"))
 			},
 			input: args{
 				userId: 1,
 				listId: 2,
 			},
 			want: []todo.TodoItem{
-				{1, "title1", "description1", true},
-				{2, "title2", "description2", false},
-				{3, "title3", "description3", false},
+				{Id: 1, Title: "title1", Description: "description1", Done: true, Labels: []todo.Label{
+					{Id: 4, Name: "home", Color: "#00ff00"},
+					{Id: 5, Name: "urgent", Color: "#ff0000"},
+				}},
+				{Id: 2, Title: "title2", Description: "description2", Done: false},
+				{Id: 3, Title: "title3", Description: "description3", Done: false},
 			},
 		},
 		{
@@ -170,18 +192,113 @@ func TestItem_GetAll(t *testing.T) {
 				listId: 2,
 			},
 		},
+		{
+			name: "Label Filter",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "done"})
+				mock.ExpectQuery("SELECT (.+) WHERE li.list_id=(.+) AND ul.user_id=(.+) AND EXISTS \\(SELECT 1 FROM items_labels il "+
+					"INNER JOIN labels l ON l.id=il.label_id WHERE il.item_id=ti.id AND l.user_id=ul.user_id AND l.id=(.+)\\) ORDER BY (.+)").
+					WithArgs(2, 1, 4).WillReturnRows(rows)
+			},
+			input: args{
+				userId: 1,
+				listId: 2,
+				query:  todo.ItemsQuery{Label: 4},
+			},
+		},
+		{
+			name: "Done Filter",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "done"}).
+					AddRow(1, "title1", "description1", true).
+					AddRow(3, "title3", "description3", true)
+				mock.ExpectQuery("SELECT (.+) FROM todo_items ti INNER JOIN lists_items li ON (.+) INNER JOIN users_lists ul ON (.+) "+
+					"WHERE li.list_id=(.+) AND ul.user_id=(.+) AND ti.done=(.+) ORDER BY ti.id DESC LIMIT 2").
+					WithArgs(2, 1, true).WillReturnRows(rows)
+
+				expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
+			},
+			input: args{
+				userId: 1,
+				listId: 2,
+				query:  todo.ItemsQuery{PageQuery: todo.PageQuery{Limit: 1, Sort: "-id"}, Done: boolPointer(true)},
+			},
+			want: []todo.TodoItem{
+				{Id: 1, Title: "title1", Description: "description1", Done: true},
+			},
+			wantNext: "eyJzIjoiLWlkIiwiaWQiOjF9",
+		},
+		{
+			name: "Priority Sort",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "due_at", "priority"}).
+					AddRow(4, "title4", "description4", false, dueAt, int64(4)).
+					AddRow(1, "title1", "description1", false, nil, int64(4))
+				mock.ExpectQuery("SELECT (.+) WHERE li.list_id=(.+) AND ul.user_id=(.+) "+
+					"ORDER BY -ti.priority ASC, coalesce\\(ti.due_at, 'infinity'\\) ASC, ti.id ASC LIMIT 2").
+					WithArgs(2, 1).WillReturnRows(rows)
+
+				expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
+			},
+			input: args{
+				userId: 1,
+				listId: 2,
+				query:  todo.ItemsQuery{PageQuery: todo.PageQuery{Limit: 1, Sort: "priority"}},
+			},
+			want: []todo.TodoItem{
+				{Id: 4, Title: "title4", Description: "description4", DueAt: &dueAt, Priority: todo.PriorityUrgent},
+			},
+			wantNext: priorityCursor.nextCursor(4, map[string]interface{}{"priority": todo.PriorityUrgent, "due_at": &dueAt}),
+		},
+		{
+			name: "Assigned To Me",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "assignee_id"}).
+					AddRow(3, "title3", "description3", false, 1)
+				mock.ExpectQuery("SELECT (.+) WHERE li.list_id=(.+) AND ul.user_id=(.+) AND ti.assignee_id=ul.user_id ORDER BY (.+)").
+					WithArgs(2, 1).WillReturnRows(rows)
+
+				expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
+			},
+			input: args{
+				userId: 1,
+				listId: 2,
+				query:  todo.ItemsQuery{Assigned: todo.AssignedToMe},
+			},
+			want: []todo.TodoItem{
+				{Id: 3, Title: "title3", Description: "description3", AssigneeId: intPointer(1)},
+			},
+		},
+		{
+			name: "After Priority Cursor",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "done"})
+				mock.ExpectQuery("SELECT (.+) WHERE li.list_id=(.+) AND ul.user_id=(.+) AND "+
+					"\\(-ti.priority, coalesce\\(ti.due_at, 'infinity'\\), ti.id\\) > \\(\\$3, \\$4, \\$5\\) ORDER BY (.+)").
+					WithArgs(2, 1, "-3", "infinity", 7).WillReturnRows(rows)
+			},
+			input: args{
+				userId: 1,
+				listId: 2,
+				query: todo.ItemsQuery{PageQuery: todo.PageQuery{
+					Sort:   "priority",
+					Cursor: priorityCursor.nextCursor(7, map[string]interface{}{"priority": todo.PriorityHigh, "due_at": (*time.Time)(nil)}),
+				}},
+			},
+		},
 	}
 
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior()
 
-			got, err := r.GetAll(testCase.input.userId, testCase.input.listId)
+			got, next, err := r.GetAll(testCase.input.userId, testCase.input.listId, testCase.input.query)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, testCase.want, got)
+				assert.Equal(t, testCase.wantNext, next)
 			}
 		})
 	}
@@ -216,12 +333,14 @@ func TestItem_GetById(t *testing.T) {
 
 				mock.ExpectQuery("SELECT (.+) FROM todo_items ti INNER JOIN lists_items li ON (.+) " +
 					"INNER JOIN users_lists ul ON (.+) WHERE (.+)").WillReturnRows(rows)
+
+				expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
 			},
 			input: args{
 				userId: 1,
 				itemId: 5,
 			},
-			want: todo.TodoItem{1, "title1", "description1", true},
+			want: todo.TodoItem{Id: 1, Title: "title1", Description: "description1", Done: true},
 		},
 		{
 			name: "Not Found",
@@ -287,8 +406,15 @@ func TestItem_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func() {
-				mock.ExpectExec("UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul WHERE (.+)").
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM todo_items WHERE parent_item_id=(.+) AND NOT done AND deleted_at IS NULL\\)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul, todo_lists tl WHERE (.+)").
 					WithArgs("new title", "new description", true, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, `{"description":{"before":"description","after":"new description"},`+
+					`"done":{"before":false,"after":true},"title":{"before":"title","after":"new title"}}`)
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -302,8 +428,12 @@ func TestItem_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func() {
-				mock.ExpectExec("UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul WHERE (.+)").
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul, todo_lists tl WHERE (.+)").
 					WithArgs("new title", "new description", 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -316,8 +446,12 @@ func TestItem_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func() {
-				mock.ExpectExec("UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul WHERE (.+)").
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET (.+) FROM lists_items li, users_lists ul, todo_lists tl WHERE (.+)").
 					WithArgs("new title", 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -327,10 +461,193 @@ func TestItem_Update(t *testing.T) {
 				itemId: 2,
 			},
 			mockBehavior: func() {
-				mock.ExpectExec("UPDATE todo_items ti SET FROM lists_items li, users_lists ul WHERE (.+)").
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET version=ti.version\\+1 FROM lists_items li, users_lists ul, todo_lists tl WHERE (.+)").
 					WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Reopen Clears Completion",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input: todo.UpdateItemInput{
+					Done: boolPointer(false),
+				},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET done=\\$1, "+
+					"completed_at=CASE WHEN \\$1 THEN coalesce\\(ti.completed_at, now\\(\\)\\) ELSE NULL END, version=ti.version\\+1 FROM (.+)").
+					WithArgs(false, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectExec("WITH RECURSIVE ancestors AS (.+) UPDATE todo_items SET done=false, completed_at=NULL").
+					WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			},
 		},
+		{
+			name: "Clear Due Date",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input: todo.UpdateItemInput{
+					DueAt:    todo.NullableTime{Set: true},
+					RemindAt: todo.NullableTime{Set: true},
+				},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET due_at=\\$1, remind_at=\\$2, version=ti.version\\+1 FROM (.+)").
+					WithArgs(nil, nil, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Priority",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{Priority: priorityPointer(todo.PriorityUrgent)},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET priority=\\$1, version=ti.version\\+1 FROM (.+)").
+					WithArgs(int64(4), 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Open Subtasks",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{Done: boolPointer(true)},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM todo_items WHERE parent_item_id=(.+) AND NOT done AND deleted_at IS NULL\\)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Move Under Parent",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{ParentId: todo.NullableInt{Set: true, Value: intPointer(5)}},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items p INNER JOIN lists_items c ON (.+)\\)").
+					WithArgs(5, 2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectQuery("WITH RECURSIVE ancestors AS (.+) SELECT EXISTS").
+					WithArgs(5, 2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("UPDATE todo_items ti SET parent_item_id=\\$1, version=ti.version\\+1 FROM (.+)").
+					WithArgs(5, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectExec("WITH RECURSIVE ancestors AS (.+) UPDATE todo_items SET done=false, completed_at=NULL").
+					WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Parent Cycle",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{ParentId: todo.NullableInt{Set: true, Value: intPointer(5)}},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items p INNER JOIN lists_items c ON (.+)\\)").
+					WithArgs(5, 2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectQuery("WITH RECURSIVE ancestors AS (.+) SELECT EXISTS").
+					WithArgs(5, 2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Parent In Other List",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{ParentId: todo.NullableInt{Set: true, Value: intPointer(5)}},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items p INNER JOIN lists_items c ON (.+)\\)").
+					WithArgs(5, 2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Detach From Parent",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{ParentId: todo.NullableInt{Set: true}},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET parent_item_id=\\$1, version=ti.version\\+1 FROM (.+)").
+					WithArgs(nil, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Assign Member",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{AssigneeId: todo.NullableInt{Set: true, Value: intPointer(3)}},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items li INNER JOIN users_lists ul ON (.+) "+
+					"WHERE li.item_id=(.+) AND ul.user_id=(.+)\\)").
+					WithArgs(2, 3).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectExec("UPDATE todo_items ti SET assignee_id=\\$1, version=ti.version\\+1 FROM (.+)").
+					WithArgs(3, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, `{"assignee_id":{"before":null,"after":3}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Assign Non Member",
+			args: args{
+				userId: 1,
+				itemId: 2,
+				input:  todo.UpdateItemInput{AssigneeId: todo.NullableInt{Set: true, Value: intPointer(4)}},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM lists_items li INNER JOIN users_lists ul ON (.+)\\)").
+					WithArgs(2, 4).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -347,6 +664,172 @@ func TestItem_Update(t *testing.T) {
 	}
 }
 
+func TestItem_GetDue(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestItem_GetDue func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	from := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	dueAt := from.Add(9 * time.Hour)
+
+	type args struct {
+		userId   int
+		from, to time.Time
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         []todo.TodoItem
+		wantErr      bool
+	}{
+		{
+			name: "Window",
+			args: args{userId: 1, from: from, to: to},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "due_at"}).
+					AddRow(3, "title3", "description3", false, dueAt)
+				mock.ExpectQuery("SELECT DISTINCT (.+) FROM todo_items ti (.+) WHERE ul.user_id=(.+) AND NOT ti.done "+
+					"AND ti.due_at < (.+) AND ti.due_at >= (.+) ORDER BY ti.due_at, ti.id").
+					WithArgs(args.userId, args.to, args.from).WillReturnRows(rows)
+
+				expectItemLabels(mock, args.userId, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
+			},
+			want: []todo.TodoItem{
+				{Id: 3, Title: "title3", Description: "description3", DueAt: &dueAt},
+			},
+		},
+		{
+			name: "Overdue",
+			args: args{userId: 1, to: to},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "due_at"})
+				mock.ExpectQuery("SELECT DISTINCT (.+) WHERE ul.user_id=(.+) AND NOT ti.done AND ti.due_at < (.+) ORDER BY (.+)").
+					WithArgs(args.userId, args.to).WillReturnRows(rows)
+			},
+			want: []todo.TodoItem{},
+		},
+		{
+			name: "Failure",
+			args: args{userId: 1, to: to},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("SELECT DISTINCT (.+)").
+					WithArgs(args.userId, args.to).WillReturnError(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.GetDue(testCase.args.userId, testCase.args.from, testCase.args.to)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestItem_CompleteOccurrence(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("an error on testing TestItem_CompleteOccurrence: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	dueAt := time.Date(2023, 5, 8, 9, 0, 0, 0, time.UTC)
+	input := todo.UpdateItemInput{Done: boolPointer(true)}
+	next := todo.TodoItem{Title: "pay rent", DueAt: &dueAt, Priority: todo.PriorityHigh, Recurrence: "FREQ=MONTHLY"}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM todo_items WHERE parent_item_id=(.+) AND NOT done AND deleted_at IS NULL\\)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("UPDATE todo_items ti SET done=(.+) FROM (.+)").
+					WithArgs(true, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(3))
+				mock.ExpectQuery("INSERT INTO todo_items (.+) RETURNING id").
+					WithArgs("pay rent", "", &dueAt, nil, int64(3), nil, "FREQ=MONTHLY", nil).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+				mock.ExpectExec("INSERT INTO lists_items").
+					WithArgs(3, 5, "000001").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+) AND list_id<>(.+)").
+					WithArgs(2, 3).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(7))
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000004"))
+				mock.ExpectExec("INSERT INTO lists_items \\(list_id, item_id, position\\) SELECT (.+) FROM unnest(.+)").
+					WithArgs(7, "{5}", `{"000005"}`).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO items_labels \\(item_id, label_id\\) SELECT (.+) FROM items_labels WHERE item_id=(.+)").
+					WithArgs(5, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+				expectItemActivity(mock, 1, todo.ActivityCreated, 5, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Already Done",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, true)
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM todo_items WHERE parent_item_id=(.+) AND NOT done AND deleted_at IS NULL\\)").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("UPDATE todo_items ti SET done=(.+) FROM (.+)").
+					WithArgs(true, 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Not Found",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT (.+) FROM todo_items WHERE id=(.+) FOR UPDATE").
+					WithArgs(2).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.CompleteOccurrence(1, 2, input, next)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func stringPointer(s string) *string {
 	return &s
 }
@@ -355,6 +838,100 @@ func boolPointer(b bool) *bool {
 	return &b
 }
 
+func intPointer(i int) *int {
+	return &i
+}
+
+func TestItem_Move(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestItem_Move func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	testTable := []struct {
+		name         string
+		input        todo.MoveInput
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name:  "OK",
+			input: todo.MoveInput{After: intPointer(4)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(3))
+				mock.ExpectQuery("SELECT position FROM lists_items WHERE list_id=(.+) AND item_id=(.+) FOR UPDATE").
+					WithArgs(3, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("000009"))
+				mock.ExpectQuery("SELECT position FROM lists_items WHERE list_id=(.+) AND item_id=(.+)").
+					WithArgs(3, 4).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("000002"))
+				mock.ExpectQuery("SELECT min\\(position\\) FROM lists_items WHERE list_id=(.+) AND item_id<>(.+) AND position > (.+)").
+					WithArgs(3, 7, "000002").WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow("000003"))
+				mock.ExpectExec("UPDATE lists_items SET position=(.+) WHERE list_id=(.+) AND item_id=(.+)").
+					WithArgs("000002i", 3, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 3, 1, todo.ActivityUpdated, todo.ActivityEntityItem, 7, `{"position":{"before":"000009","after":"000002i"}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "Neighbour In Other List",
+			input: todo.MoveInput{Before: intPointer(8)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(3))
+				mock.ExpectQuery("SELECT position FROM lists_items WHERE list_id=(.+) AND item_id=(.+) FOR UPDATE").
+					WithArgs(3, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("000009"))
+				mock.ExpectQuery("SELECT position FROM lists_items WHERE list_id=(.+) AND item_id=(.+)").
+					WithArgs(3, 8).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrValidation,
+		},
+		{
+			name:  "Next To Itself",
+			input: todo.MoveInput{Before: intPointer(7)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(3))
+				mock.ExpectQuery("SELECT position FROM lists_items WHERE list_id=(.+) AND item_id=(.+) FOR UPDATE").
+					WithArgs(3, 7).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow("000009"))
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrValidation,
+		},
+		{
+			name:  "Not Found",
+			input: todo.MoveInput{Before: intPointer(8)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+					WithArgs(7).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Move(1, 7, testCase.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestItem_Delete(t *testing.T) {
 	db, mock, err := sqlmock.Newx()
 	if err != nil {
@@ -382,8 +959,13 @@ func TestItem_Delete(t *testing.T) {
 				itemId: 7,
 			},
 			mockBehavior: func() {
-				mock.ExpectExec("DELETE FROM todo_items ti USING lists_items li, users_lists ul WHERE (.+)").
-					WithArgs(2, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectBegin()
+				mock.ExpectExec("WITH RECURSIVE subtree AS \\((.+) WHERE ul.user_id=(.+) AND ti.id=(.+) AND ti.deleted_at IS NULL (.+)\\) "+
+					"UPDATE todo_items SET deleted_at=now\\(\\) WHERE id IN \\(SELECT id FROM subtree\\)").
+					WithArgs(2, 7, nil).WillReturnResult(sqlmock.NewResult(0, 3))
+				expectLockItem(mock, 7, false)
+				expectItemActivity(mock, 2, todo.ActivityDeleted, 7, nil)
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -393,8 +975,11 @@ func TestItem_Delete(t *testing.T) {
 				itemId: 7,
 			},
 			mockBehavior: func() {
-				mock.ExpectExec("DELETE FROM todo_items ti USING lists_items li, users_lists ul WHERE (.+)").
-					WithArgs(2, 7).WillReturnError(sql.ErrNoRows)
+				mock.ExpectBegin()
+				mock.ExpectExec("WITH RECURSIVE subtree AS \\((.+) WHERE ul.user_id=(.+) AND ti.id=(.+) AND ti.deleted_at IS NULL (.+)\\) "+
+					"UPDATE todo_items SET deleted_at=now\\(\\) WHERE id IN \\(SELECT id FROM subtree\\)").
+					WithArgs(2, 7, nil).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
 			},
 			wantErr: true,
 		},
@@ -404,7 +989,7 @@ func TestItem_Delete(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior()
 
-			err := r.Delete(testCase.args.userId, testCase.args.itemId)
+			err := r.Delete(testCase.args.userId, testCase.args.itemId, nil)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -413,3 +998,7 @@ func TestItem_Delete(t *testing.T) {
 		})
 	}
 }
+
+func priorityPointer(p todo.Priority) *todo.Priority {
+	return &p
+}
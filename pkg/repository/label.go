@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"strings"
+	todo "todo-app"
+)
+
+type LabelPostgres struct {
+	db *sqlx.DB
+}
+
+func NewLabelPostgres(db *sqlx.DB) *LabelPostgres {
+	return &LabelPostgres{db: db}
+}
+
+func (r *LabelPostgres) Create(userId int, label todo.Label) (int, error) {
+	var id int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, name, color) VALUES ($1, $2, $3) RETURNING id", labelsTable)
+
+	row := r.db.QueryRow(query, userId, label.Name, label.Color)
+	if err := row.Scan(&id); err != nil {
+		return 0, translateError(err)
+	}
+
+	return id, nil
+}
+
+func (r *LabelPostgres) GetAll(userId int) ([]todo.Label, error) {
+	labels := make([]todo.Label, 0)
+	query := fmt.Sprintf("SELECT id, name, color FROM %s WHERE user_id=$1 ORDER BY name", labelsTable)
+	err := r.db.Select(&labels, query, userId)
+
+	return labels, err
+}
+
+func (r *LabelPostgres) GetById(userId, labelId int) (todo.Label, error) {
+	var label todo.Label
+	query := fmt.Sprintf("SELECT id, name, color FROM %s WHERE user_id=$1 AND id=$2", labelsTable)
+	err := r.db.Get(&label, query, userId, labelId)
+
+	return label, translateError(err)
+}
+
+func (r *LabelPostgres) Update(userId, labelId int, input todo.UpdateLabelInput) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=$%d", argId))
+		args = append(args, *input.Name)
+		argId++
+	}
+
+	if input.Color != nil {
+		setValues = append(setValues, fmt.Sprintf("color=$%d", argId))
+		args = append(args, *input.Color)
+		argId++
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE user_id=$%d AND id=$%d",
+		labelsTable, strings.Join(setValues, ", "), argId, argId+1)
+	args = append(args, userId, labelId)
+	if err := requireAffected(tx.Exec(query, args...)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := bumpLabeledItems(tx, userId, labelId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *LabelPostgres) Delete(userId, labelId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// the items lose the label along with it
+	if err := bumpLabeledItems(tx, userId, labelId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id=$1 AND id=$2", labelsTable)
+	if err := requireAffected(tx.Exec(query, userId, labelId)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// bumpLabeledItems bumps the version of the items the user attached the label to.
+func bumpLabeledItems(tx *sql.Tx, userId, labelId int) error {
+	query := bumpChangedItems(fmt.Sprintf("SELECT il.item_id FROM %s il INNER JOIN %s l ON l.id=il.label_id "+
+		"WHERE l.user_id=$1 AND il.label_id=$2", itemsLabelsTable, labelsTable))
+	_, err := tx.Exec(query, userId, labelId)
+	return err
+}
+
+// Attach tags the item with one of the user's labels. Attaching a label twice is a no-op;
+// a label of another user is reported as todo.ErrNotFound. The conflict clause updates
+// instead of doing nothing so that a repeated attach still counts as an affected row.
+func (r *LabelPostgres) Attach(userId, itemId, labelId int) error {
+	query := bumpChangedItems(fmt.Sprintf(`INSERT INTO %s (item_id, label_id) SELECT $1, l.id FROM %s l WHERE l.user_id=$2 AND l.id=$3
+								ON CONFLICT (item_id, label_id) DO UPDATE SET label_id=EXCLUDED.label_id RETURNING item_id`,
+		itemsLabelsTable, labelsTable))
+	return requireAffected(r.db.Exec(query, itemId, userId, labelId))
+}
+
+func (r *LabelPostgres) Detach(userId, itemId, labelId int) error {
+	query := bumpChangedItems(fmt.Sprintf("DELETE FROM %s il USING %s l WHERE il.label_id=l.id AND l.user_id=$1 "+
+		"AND il.item_id=$2 AND il.label_id=$3 RETURNING il.item_id", itemsLabelsTable, labelsTable))
+	return requireAffected(r.db.Exec(query, userId, itemId, labelId))
+}
+
+type itemLabel struct {
+	ItemId int `db:"item_id"`
+	todo.Label
+}
+
+// loadItemLabels fills in the labels the user attached to the items.
+func loadItemLabels(db *sqlx.DB, userId int, items []todo.TodoItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = int64(item.Id)
+	}
+
+	var labels []itemLabel
+	query := fmt.Sprintf(`SELECT il.item_id, l.id, l.name, l.color FROM %s il INNER JOIN %s l ON l.id=il.label_id
+								WHERE l.user_id=$1 AND il.item_id = ANY($2) ORDER BY l.name`,
+		itemsLabelsTable, labelsTable)
+	if err := db.Select(&labels, query, userId, pq.Array(ids)); err != nil {
+		return err
+	}
+
+	byItem := make(map[int][]todo.Label)
+	for _, label := range labels {
+		byItem[label.ItemId] = append(byItem[label.ItemId], label.Label)
+	}
+
+	for i := range items {
+		items[i].Labels = byItem[items[i].Id]
+	}
+
+	return nil
+}
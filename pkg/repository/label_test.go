@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func TestLabel_Create(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestLabel_Create func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	type args struct {
+		userId int
+		label  todo.Label
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, label: todo.Label{Name: "urgent", Color: "#ff0000"}},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(4)
+				mock.ExpectQuery("INSERT INTO labels \\(user_id, name, color\\) VALUES (.+) RETURNING id").
+					WithArgs(args.userId, args.label.Name, args.label.Color).WillReturnRows(rows)
+			},
+			want: 4,
+		},
+		{
+			name: "Duplicate Name",
+			args: args{userId: 1, label: todo.Label{Name: "urgent", Color: "#ff0000"}},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("INSERT INTO labels (.+)").
+					WithArgs(args.userId, args.label.Name, args.label.Color).WillReturnError(&pq.Error{Code: "23505"})
+			},
+			wantErr: todo.ErrConflict,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.Create(testCase.args.userId, testCase.args.label)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestLabel_Attach(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestLabel_Attach func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	type args struct {
+		userId, itemId, labelId int
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, itemId: 2, labelId: 4},
+			mockBehavior: func(args args) {
+				mock.ExpectExec("WITH changed AS \\(INSERT INTO items_labels \\(item_id, label_id\\) SELECT (.+) FROM labels l WHERE l.user_id=(.+) AND l.id=(.+) "+
+					"ON CONFLICT (.+) RETURNING item_id\\) UPDATE todo_items SET version=version\\+1 WHERE id IN \\(SELECT item_id FROM changed\\)").
+					WithArgs(args.itemId, args.userId, args.labelId).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "Foreign Label",
+			args: args{userId: 1, itemId: 2, labelId: 9},
+			mockBehavior: func(args args) {
+				mock.ExpectExec("WITH changed AS \\(INSERT INTO items_labels (.+)").
+					WithArgs(args.itemId, args.userId, args.labelId).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			err := r.Attach(testCase.args.userId, testCase.args.itemId, testCase.args.labelId)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLabel_Detach(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestLabel_Detach func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	mock.ExpectExec("WITH changed AS \\(DELETE FROM items_labels il USING labels l WHERE il.label_id=l.id AND l.user_id=(.+) AND il.item_id=(.+) "+
+		"AND il.label_id=(.+) RETURNING il.item_id\\) UPDATE todo_items SET version=version\\+1 WHERE id IN \\(SELECT item_id FROM changed\\)").
+		WithArgs(1, 2, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, r.Detach(1, 2, 4))
+
+	mock.ExpectExec("WITH changed AS \\(DELETE FROM items_labels (.+)").
+		WithArgs(1, 2, 4).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, r.Detach(1, 2, 4), todo.ErrNotFound)
+}
+
+func TestLabel_Update(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestLabel_Update func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	name := "home"
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE labels SET name=(.+) WHERE user_id=(.+) AND id=(.+)").
+		WithArgs(name, 1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectBumpLabeledItems(mock, 1, 4)
+	mock.ExpectCommit()
+	assert.NoError(t, r.Update(1, 4, todo.UpdateLabelInput{Name: &name}))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE labels (.+)").WithArgs(name, 1, 9).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, r.Update(1, 9, todo.UpdateLabelInput{Name: &name}), todo.ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLabel_Delete(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestLabel_Delete func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewLabelPostgres(db)
+
+	mock.ExpectBegin()
+	expectBumpLabeledItems(mock, 1, 4)
+	mock.ExpectExec("DELETE FROM labels WHERE user_id=(.+) AND id=(.+)").WithArgs(1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	assert.NoError(t, r.Delete(1, 4))
+
+	mock.ExpectBegin()
+	expectBumpLabeledItems(mock, 1, 9)
+	mock.ExpectExec("DELETE FROM labels (.+)").WithArgs(1, 9).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, r.Delete(1, 9), todo.ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectBumpLabeledItems expects the version of the items with the user's label to be bumped.
+func expectBumpLabeledItems(mock sqlmock.Sqlmock, userId, labelId int) {
+	mock.ExpectExec("WITH changed AS \\(SELECT il.item_id FROM items_labels il INNER JOIN labels l ON (.+) "+
+		"WHERE l.user_id=(.+) AND il.label_id=(.+)\\) UPDATE todo_items SET version=version\\+1 WHERE id IN \\(SELECT item_id FROM changed\\)").
+		WithArgs(userId, labelId).WillReturnResult(sqlmock.NewResult(0, 2))
+}
+
+func TestItem_GetByLabel(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestItem_GetByLabel func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "done"}).
+		AddRow(3, "title3", "description3", false)
+	mock.ExpectQuery("SELECT DISTINCT (.+) INNER JOIN items_labels il ON (.+) INNER JOIN labels l ON (.+) WHERE ul.user_id=(.+) AND l.id=(.+)").
+		WithArgs(1, 4).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT il.item_id, l.id, l.name, l.color FROM items_labels il (.+)").
+		WithArgs(1, pq.Array([]int64{3})).
+		WillReturnRows(sqlmock.NewRows([]string{"item_id", "id", "name", "color"}).AddRow(3, 4, "urgent", "#ff0000"))
+
+	got, err := r.GetByLabel(1, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.TodoItem{
+		{Id: 3, Title: "title3", Description: "description3", Labels: []todo.Label{{Id: 4, Name: "urgent", Color: "#ff0000"}}},
+	}, got)
+}
+
+func expectItemLabels(mock sqlmock.Sqlmock, userId int, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT il.item_id, l.id, l.name, l.color FROM items_labels il INNER JOIN labels l ON (.+) "+
+		"WHERE l.user_id=(.+) AND il.item_id = ANY(.+)").
+		WithArgs(userId, sqlmock.AnyArg()).WillReturnRows(rows)
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"strings"
@@ -29,69 +30,207 @@ func (r *TodoListPostgres) CreateList(userId int, list todo.TodoList) (int, erro
 		return 0, err
 	}
 
-	createUsersListsQuery := fmt.Sprintf("INSERT INTO %s (user_id, list_id) VALUES ($1,$2)", usersListsTable)
-	_, err = tx.Exec(createUsersListsQuery, userId, id)
+	position, err := appendListPosition(tx, userId)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
+	createUsersListsQuery := fmt.Sprintf("INSERT INTO %s (user_id, list_id, role, position) VALUES ($1,$2,$3,$4)", usersListsTable)
+	_, err = tx.Exec(createUsersListsQuery, userId, id, todo.RoleOwner, position)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	changes := diffFields(nil, listFields(list))
+	if err := recordActivity(tx, id, userId, todo.ActivityCreated, todo.ActivityEntityList, id, changes); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
 	return id, tx.Commit()
 }
 
-func (r *TodoListPostgres) GetAll(userId int) ([]todo.TodoList, error) {
+// GetAll returns one page of the user's lists and the cursor of the next page.
+func (r *TodoListPostgres) GetAll(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error) {
+	p, err := newPage("tl", "ul", input.PageQuery)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := []string{"ul.user_id = $1", "tl.deleted_at IS NULL", "NOT tl.is_template"}
+	if input.Template {
+		conditions[2] = "tl.is_template"
+	}
+	args := []interface{}{userId}
+
+	if input.Title != "" {
+		args = append(args, likePattern(input.Title))
+		conditions = append(conditions, fmt.Sprintf("tl.title ILIKE $%d", len(args)))
+	}
+
+	if condition, pageArgs := p.condition(len(args) + 1); condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, pageArgs...)
+	}
+
 	var lists []todo.TodoList
-	query := fmt.Sprintf("SELECT tl.id, tl.title, tl.description FROM %s tl INNER JOIN %s ul ON tl.id = ul.list_id WHERE ul.user_id = $1",
-		todoListsTable, usersListsTable)
-	err := r.db.Select(&lists, query, userId)
+	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, ul.role, tl.created_at, ul.position, tl.is_template, tl.version FROM %s tl
+								INNER JOIN %s ul ON tl.id = ul.list_id WHERE %s ORDER BY %s LIMIT %d`,
+		todoListsTable, usersListsTable, strings.Join(conditions, " AND "), p.orderBy(), p.fetchLimit())
+	if err := r.db.Select(&lists, query, args...); err != nil {
+		return nil, "", err
+	}
 
-	return lists, err
+	var next string
+	if len(lists) > p.limit {
+		lists = lists[:p.limit]
+		last := lists[p.limit-1]
+		next = p.nextCursor(last.Id, map[string]interface{}{
+			"title": last.Title, "created_at": last.CreatedAt, "position": last.Position,
+		})
+	}
+
+	return lists, next, nil
 }
 
 func (r *TodoListPostgres) GetById(userId int, listId int) (todo.TodoList, error) {
 	var list todo.TodoList
 
-	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description FROM %s tl
-								INNER JOIN %s ul on tl.id = ul.list_id WHERE ul.user_id = $1 AND ul.list_id = $2`,
+	query := fmt.Sprintf(`SELECT tl.id, tl.title, tl.description, ul.role, tl.created_at, ul.position, tl.is_template, tl.version FROM %s tl
+								INNER JOIN %s ul on tl.id = ul.list_id WHERE ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL`,
 		todoListsTable, usersListsTable)
 	err := r.db.Get(&list, query, userId, listId)
 
-	return list, err
+	return list, translateError(err)
 }
 
 func (r *TodoListPostgres) Update(userId, listId int, input todo.UpdateListInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := updateList(tx, userId, listId, input); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func updateList(tx *sql.Tx, userId, listId int, input todo.UpdateListInput) error {
+	var before todo.TodoList
+	query := fmt.Sprintf("SELECT title, description FROM %s WHERE id=$1 FOR UPDATE", todoListsTable)
+	if err := tx.QueryRow(query, listId).Scan(&before.Title, &before.Description); err != nil {
+		return translateError(err)
+	}
+
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
+	after := before
 
 	if input.Title != nil {
 		setValues = append(setValues, fmt.Sprintf("title=$%d", argId))
 		args = append(args, *input.Title)
 		argId++
+		after.Title = *input.Title
 	}
 
 	if input.Description != nil {
 		setValues = append(setValues, fmt.Sprintf("description=$%d", argId))
 		args = append(args, *input.Description)
 		argId++
+		after.Description = *input.Description
 	}
 
-	// title=$1
-	// description=$1
-	// title=$1, description=$2
+	// every update bumps the version the client sees as the ETag of the list
+	setValues = append(setValues, "version=tl.version+1")
+
+	// title=$1, version=tl.version+1
+	// description=$1, version=tl.version+1
+	// title=$1, description=$2, version=tl.version+1
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf("UPDATE %s tl SET %s FROM %s ul WHERE tl.id = ul.list_id AND ul.list_id=$%d AND ul.user_id=$%d",
+	query = fmt.Sprintf("UPDATE %s tl SET %s FROM %s ul "+
+		"WHERE tl.id = ul.list_id AND ul.list_id=$%d AND ul.user_id=$%d AND tl.deleted_at IS NULL",
 		todoListsTable, setQuery, usersListsTable, argId, argId+1)
 	args = append(args, listId, userId)
 
-	_, err := r.db.Exec(query, args...)
-	return err
+	if input.Version != nil {
+		query += fmt.Sprintf(" AND tl.version=$%d", argId+2)
+		args = append(args, *input.Version)
+	}
+
+	if err := requireAffected(tx.Exec(query, args...)); err != nil {
+		return checkVersion(tx, todoListsTable, listId, input.Version, err)
+	}
+
+	changes := diffFields(listFields(before), listFields(after))
+	return recordActivity(tx, listId, userId, todo.ActivityUpdated, todo.ActivityEntityList, listId, changes)
 }
 
-func (r *TodoListPostgres) Delete(userId, listId int) error {
-	query := fmt.Sprintf("DELETE FROM %s tl USING %s ul WHERE tl.id=ul.list_id AND ul.user_id=$1 AND ul.list_id=$2",
-		todoListsTable, usersListsTable)
-	_, err := r.db.Exec(query, userId, listId)
-	return err
+// Move changes the position of the list among the user's lists.
+func (r *TodoListPostgres) Move(userId, listId int, input todo.MoveInput) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	changes, err := move(tx, usersListsTable, "user_id", "list_id", userId, listId, input)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := bumpListVersion(tx, listId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := recordActivity(tx, listId, userId, todo.ActivityUpdated, todo.ActivityEntityList, listId, changes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete moves the list to the trash. Its items stay as they are and come back with it.
+// With version set only that version of the list is deleted.
+func (r *TodoListPostgres) Delete(userId, listId int, version *int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var list todo.TodoList
+	query := fmt.Sprintf("UPDATE %s tl SET deleted_at=now() FROM %s ul "+
+		"WHERE tl.id=ul.list_id AND ul.user_id=$1 AND ul.list_id=$2 AND ($3::int IS NULL OR tl.version=$3) AND tl.deleted_at IS NULL "+
+		"RETURNING tl.title, tl.description", todoListsTable, usersListsTable)
+	if err := tx.QueryRow(query, userId, listId, version).Scan(&list.Title, &list.Description); err != nil {
+		err = checkVersion(tx, todoListsTable, listId, version, translateError(err))
+		tx.Rollback()
+		return err
+	}
+
+	changes := diffFields(listFields(list), nil)
+	if err := recordActivity(tx, listId, userId, todo.ActivityDeleted, todo.ActivityEntityList, listId, changes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// appendListPosition returns the position of a list appended to the user's lists.
+func appendListPosition(tx *sql.Tx, userId int) (string, error) {
+	last, err := lastRank(tx, fmt.Sprintf("SELECT max(position) FROM %s WHERE user_id=$1", usersListsTable), userId)
+	if err != nil {
+		return "", err
+	}
+
+	return rankAfter(last), nil
 }
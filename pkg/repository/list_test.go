@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	sqlmock "github.com/zhashkevych/go-sqlxmock"
 	"log"
 	"testing"
+	"time"
 	todo "todo-app"
 )
 
@@ -48,9 +50,15 @@ func TestList_CreateList(t *testing.T) {
 				mock.ExpectQuery("INSERT INTO todo_lists").WithArgs(args.list.Title, args.list.Description).
 					WillReturnRows(rows)
 
-				mock.ExpectExec("INSERT INTO users_lists").WithArgs(args.userId, id).
+				mock.ExpectQuery("SELECT max\\(position\\) FROM users_lists WHERE user_id=(.+)").WithArgs(args.userId).
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("00000a"))
+
+				mock.ExpectExec("INSERT INTO users_lists").WithArgs(args.userId, id, todo.RoleOwner, "00000b").
 					WillReturnResult(sqlmock.NewResult(1, 1))
 
+				expectActivity(mock, id, args.userId, todo.ActivityCreated, todo.ActivityEntityList, id,
+					`{"description":{"before":null,"after":"description"},"title":{"before":null,"after":"title"}}`)
+
 				mock.ExpectCommit()
 			},
 			id: 5,
@@ -94,7 +102,10 @@ func TestList_CreateList(t *testing.T) {
 				mock.ExpectQuery("INSERT INTO todo_lists").WithArgs(args.list.Title, args.list.Description).
 					WillReturnRows(rows)
 
-				mock.ExpectExec("INSERT INTO users_lists").WithArgs(args.userId, id).
+				mock.ExpectQuery("SELECT max\\(position\\) FROM users_lists WHERE user_id=(.+)").WithArgs(args.userId).
+					WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+
+				mock.ExpectExec("INSERT INTO users_lists").WithArgs(args.userId, id, todo.RoleOwner, "000001").
 					WillReturnError(errors.New("some error"))
 
 				mock.ExpectRollback()
@@ -128,11 +139,17 @@ func TestList_GetAll(t *testing.T) {
 
 	r := NewTodoListPostgres(db)
 
+	positionCursor, _ := newPage("tl", "ul", todo.PageQuery{})
+	titleCursor, _ := newPage("tl", "ul", todo.PageQuery{Sort: "-title"})
+	createdAt := time.Date(2023, 5, 1, 12, 30, 0, 0, time.UTC)
+
 	testTable := []struct {
 		name         string
 		userId       int
+		input        todo.ListsQuery
 		mockBehavior func()
 		want         []todo.TodoList
+		wantNext     string
 		wantErr      bool
 	}{
 		{
@@ -143,13 +160,14 @@ func TestList_GetAll(t *testing.T) {
 					AddRow(1, "title1", "description1").
 					AddRow(2, "title2", "description2").
 					AddRow(3, "title3", "description3")
-				mock.ExpectQuery("SELECT (.+) FROM todo_lists tl INNER JOIN users_lists ul ON (.+)").
-					WillReturnRows(rows)
+				mock.ExpectQuery("SELECT (.+) FROM todo_lists tl INNER JOIN users_lists ul ON (.+) " +
+					"WHERE ul.user_id = (.+) AND tl.deleted_at IS NULL AND NOT tl.is_template ORDER BY ul.position ASC, tl.id ASC LIMIT 51").
+					WithArgs(3).WillReturnRows(rows)
 			},
 			want: []todo.TodoList{
-				{1, "title1", "description1"},
-				{2, "title2", "description2"},
-				{3, "title3", "description3"},
+				{Id: 1, Title: "title1", Description: "description1"},
+				{Id: 2, Title: "title2", Description: "description2"},
+				{Id: 3, Title: "title3", Description: "description3"},
 			},
 		},
 		{
@@ -161,18 +179,93 @@ func TestList_GetAll(t *testing.T) {
 					WillReturnRows(rows)
 			},
 		},
+		{
+			name:   "Title Filter",
+			userId: 3,
+			input:  todo.ListsQuery{Title: "50%_off"},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description"})
+				mock.ExpectQuery("SELECT (.+) FROM todo_lists tl INNER JOIN users_lists ul ON (.+) "+
+					"WHERE ul.user_id = (.+) AND tl.title ILIKE (.+) ORDER BY (.+)").
+					WithArgs(3, `%50\%\_off%`).WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "Next Page",
+			userId: 3,
+			input:  todo.ListsQuery{PageQuery: todo.PageQuery{Limit: 2, Sort: "created_at"}},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "created_at"}).
+					AddRow(1, "title1", "description1", createdAt).
+					AddRow(2, "title2", "description2", createdAt).
+					AddRow(3, "title3", "description3", createdAt)
+				mock.ExpectQuery("SELECT (.+) FROM todo_lists tl INNER JOIN users_lists ul ON (.+) " +
+					"ORDER BY tl.created_at ASC, tl.id ASC LIMIT 3").
+					WithArgs(3).WillReturnRows(rows)
+			},
+			want: []todo.TodoList{
+				{Id: 1, Title: "title1", Description: "description1", CreatedAt: &createdAt},
+				{Id: 2, Title: "title2", Description: "description2", CreatedAt: &createdAt},
+			},
+			wantNext: "eyJzIjoiY3JlYXRlZF9hdCIsInYiOlsiMjAyMy0wNS0wMVQxMjozMDowMFoiXSwiaWQiOjJ9",
+		},
+		{
+			name:   "After Cursor",
+			userId: 3,
+			input: todo.ListsQuery{PageQuery: todo.PageQuery{
+				Sort:   "-title",
+				Cursor: titleCursor.nextCursor(4, map[string]interface{}{"title": "groceries"}),
+			}},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description"})
+				mock.ExpectQuery("SELECT (.+) FROM todo_lists tl INNER JOIN users_lists ul ON (.+) "+
+					"WHERE ul.user_id = (.+) AND \\(tl.title, tl.id\\) < (.+) ORDER BY tl.title DESC, tl.id DESC").
+					WithArgs(3, "groceries", 4).WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "After Position Cursor",
+			userId: 3,
+			input: todo.ListsQuery{PageQuery: todo.PageQuery{
+				Cursor: positionCursor.nextCursor(4, map[string]interface{}{"position": "000004"}),
+			}},
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"id", "title", "description", "position"})
+				mock.ExpectQuery("SELECT (.+), ul.position, tl.is_template, tl.version FROM todo_lists tl INNER JOIN users_lists ul ON (.+) "+
+					"WHERE ul.user_id = (.+) AND \\(ul.position, tl.id\\) > (.+) ORDER BY ul.position ASC, tl.id ASC").
+					WithArgs(3, "000004", 4).WillReturnRows(rows)
+			},
+		},
+		{
+			name:   "Cursor For Other Sort",
+			userId: 3,
+			input: todo.ListsQuery{PageQuery: todo.PageQuery{
+				Sort:   "title",
+				Cursor: titleCursor.nextCursor(4, map[string]interface{}{"title": "groceries"}),
+			}},
+			mockBehavior: func() {},
+			wantErr:      true,
+		},
+		{
+			name:         "Malformed Cursor",
+			userId:       3,
+			input:        todo.ListsQuery{PageQuery: todo.PageQuery{Cursor: "not a cursor"}},
+			mockBehavior: func() {},
+			wantErr:      true,
+		},
 	}
 
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior()
 
-			got, err := r.GetAll(testCase.userId)
+			got, next, err := r.GetAll(testCase.userId, testCase.input)
 			if testCase.wantErr {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, todo.ErrValidation)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, testCase.want, got)
+				assert.Equal(t, testCase.wantNext, next)
 			}
 		})
 	}
@@ -213,8 +306,14 @@ func TestList_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT title, description FROM todo_lists WHERE id=(.+) FOR UPDATE").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"title", "description"}).AddRow("title", "description"))
 				mock.ExpectExec("UPDATE todo_lists tl SET (.+) FROM users_lists ul WHERE (.+)").
 					WithArgs("new title", "new description", 2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 1, todo.ActivityUpdated, todo.ActivityEntityList, 2,
+					`{"description":{"before":"description","after":"new description"},"title":{"before":"title","after":"new title"}}`)
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -227,8 +326,14 @@ func TestList_Update(t *testing.T) {
 				},
 			},
 			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT title, description FROM todo_lists WHERE id=(.+) FOR UPDATE").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"title", "description"}).AddRow("title", "description"))
 				mock.ExpectExec("UPDATE todo_lists tl SET (.+) FROM users_lists ul WHERE (.+)").
 					WithArgs("new description", 2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 1, todo.ActivityUpdated, todo.ActivityEntityList, 2,
+					`{"description":{"before":"description","after":"new description"}}`)
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -238,10 +343,34 @@ func TestList_Update(t *testing.T) {
 				listId: 2,
 			},
 			mockBehavior: func() {
-				mock.ExpectExec("UPDATE todo_lists tl SET FROM users_lists ul WHERE (.+)").
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT title, description FROM todo_lists WHERE id=(.+) FOR UPDATE").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"title", "description"}).AddRow("title", "description"))
+				mock.ExpectExec("UPDATE todo_lists tl SET version=tl.version\\+1 FROM users_lists ul WHERE (.+)").
 					WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectActivity(mock, 2, 1, todo.ActivityUpdated, todo.ActivityEntityList, 2, nil)
+				mock.ExpectCommit()
 			},
 		},
+		{
+			name: "Not Found",
+			args: args{
+				userId: 1,
+				listId: 2,
+				input: todo.UpdateListInput{
+					Title: stringPointer("new title"),
+				},
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT title, description FROM todo_lists WHERE id=(.+) FOR UPDATE").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"title", "description"}).AddRow("title", "description"))
+				mock.ExpectExec("UPDATE todo_lists tl SET (.+) FROM users_lists ul WHERE (.+)").
+					WithArgs("new title", 2, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -254,10 +383,191 @@ func TestList_Update(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
 
 func TestList_Delete(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on TestList_Delete: %v", err)
+	}
+	defer db.Close()
 
+	r := NewTodoListPostgres(db)
+
+	type args struct {
+		userId, listId int
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				userId: 1,
+				listId: 2,
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE todo_lists tl SET deleted_at=now\\(\\) FROM users_lists ul WHERE (.+) AND tl.deleted_at IS NULL "+
+					"RETURNING tl.title, tl.description").
+					WithArgs(1, 2, nil).WillReturnRows(sqlmock.NewRows([]string{"title", "description"}).AddRow("title", "description"))
+				expectActivity(mock, 2, 1, todo.ActivityDeleted, todo.ActivityEntityList, 2,
+					`{"description":{"before":"description","after":null},"title":{"before":"title","after":null}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Not Found",
+			args: args{
+				userId: 1,
+				listId: 2,
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE todo_lists tl SET deleted_at=now\\(\\) (.+) RETURNING tl.title, tl.description").
+					WithArgs(1, 2, nil).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Delete(testCase.args.userId, testCase.args.listId, nil)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestList_Move(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on TestList_Move: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoListPostgres(db)
+
+	expectPosition := func(listId int, position string) {
+		mock.ExpectQuery("SELECT position FROM users_lists WHERE user_id=(.+) AND list_id=(.+)").
+			WithArgs(1, listId).WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(position))
+	}
+
+	testTable := []struct {
+		name         string
+		input        todo.MoveInput
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name:  "Before",
+			input: todo.MoveInput{Before: intPointer(5)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectPosition(2, "000002")
+				expectPosition(5, "000004")
+				mock.ExpectQuery("SELECT max\\(position\\) FROM users_lists WHERE user_id=(.+) AND list_id<>(.+) AND position < (.+)").
+					WithArgs(1, 2, "000004").WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000003"))
+				mock.ExpectExec("UPDATE users_lists SET position=(.+) WHERE user_id=(.+) AND list_id=(.+)").
+					WithArgs("000003i", 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectBumpList(mock, 2)
+				expectActivity(mock, 2, 1, todo.ActivityUpdated, todo.ActivityEntityList, 2, `{"position":{"before":"000002","after":"000003i"}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "After The Last",
+			input: todo.MoveInput{After: intPointer(7)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectPosition(2, "000002")
+				expectPosition(7, "000005")
+				mock.ExpectQuery("SELECT min\\(position\\) FROM users_lists WHERE user_id=(.+) AND list_id<>(.+) AND position > (.+)").
+					WithArgs(1, 2, "000005").WillReturnRows(sqlmock.NewRows([]string{"min"}).AddRow(nil))
+				mock.ExpectExec("UPDATE users_lists SET position=(.+) WHERE user_id=(.+) AND list_id=(.+)").
+					WithArgs("000006", 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectBumpList(mock, 2)
+				expectActivity(mock, 2, 1, todo.ActivityUpdated, todo.ActivityEntityList, 2, `{"position":{"before":"000002","after":"000006"}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "Between",
+			input: todo.MoveInput{After: intPointer(3), Before: intPointer(4)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectPosition(2, "000005")
+				expectPosition(3, "000001")
+				expectPosition(4, "000001i")
+				mock.ExpectExec("UPDATE users_lists SET position=(.+) WHERE user_id=(.+) AND list_id=(.+)").
+					WithArgs("0000019", 1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectBumpList(mock, 2)
+				expectActivity(mock, 2, 1, todo.ActivityUpdated, todo.ActivityEntityList, 2, `{"position":{"before":"000005","after":"0000019"}}`)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:  "Wrong Order",
+			input: todo.MoveInput{After: intPointer(4), Before: intPointer(3)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectPosition(2, "000005")
+				expectPosition(4, "000004")
+				expectPosition(3, "000001")
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrValidation,
+		},
+		{
+			name:  "Foreign Neighbour",
+			input: todo.MoveInput{After: intPointer(9)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectPosition(2, "000002")
+				mock.ExpectQuery("SELECT position FROM users_lists WHERE user_id=(.+) AND list_id=(.+)").
+					WithArgs(1, 9).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrValidation,
+		},
+		{
+			name:  "Not Found",
+			input: todo.MoveInput{After: intPointer(3)},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT position FROM users_lists WHERE user_id=(.+) AND list_id=(.+) FOR UPDATE").
+					WithArgs(1, 2).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.Move(1, 2, testCase.input)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
 }
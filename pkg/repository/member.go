@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	todo "todo-app"
+)
+
+var errLastOwner = fmt.Errorf("%w: list must keep at least one owner", todo.ErrConflict)
+
+// roleRankQuery orders users_lists rows from the most to the least privileged role.
+const roleRankQuery = "CASE ul.role WHEN 'owner' THEN 3 WHEN 'editor' THEN 2 ELSE 1 END"
+
+type ListMemberPostgres struct {
+	db *sqlx.DB
+}
+
+func NewListMemberPostgres(db *sqlx.DB) *ListMemberPostgres {
+	return &ListMemberPostgres{db: db}
+}
+
+func (r *ListMemberPostgres) GetRole(userId, listId int) (string, error) {
+	var role string
+	query := fmt.Sprintf("SELECT ul.role FROM %s ul WHERE ul.user_id=$1 AND ul.list_id=$2", usersListsTable)
+	err := r.db.Get(&role, query, userId, listId)
+
+	return role, translateError(err)
+}
+
+// GetItemRole returns the strongest role the user has on any list the item belongs to.
+func (r *ListMemberPostgres) GetItemRole(userId, itemId int) (string, error) {
+	var role string
+	query := fmt.Sprintf(`SELECT ul.role FROM %s ul INNER JOIN %s li ON li.list_id=ul.list_id
+								WHERE ul.user_id=$1 AND li.item_id=$2 ORDER BY %s DESC LIMIT 1`,
+		usersListsTable, listsItemsTable, roleRankQuery)
+	err := r.db.Get(&role, query, userId, itemId)
+
+	return role, translateError(err)
+}
+
+func (r *ListMemberPostgres) GetAll(listId int) ([]todo.ListMember, error) {
+	var members []todo.ListMember
+	query := fmt.Sprintf(`SELECT ul.user_id, u.name, u.username, ul.role FROM %s ul
+								INNER JOIN %s u ON u.id=ul.user_id WHERE ul.list_id=$1 ORDER BY %s DESC, u.username`,
+		usersListsTable, usersTable, roleRankQuery)
+	err := r.db.Select(&members, query, listId)
+
+	return members, err
+}
+
+// Add shares the list with the user, appending it to the user's lists.
+func (r *ListMemberPostgres) Add(actorId, listId int, input todo.AddMemberInput) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var userId int
+	query := fmt.Sprintf("SELECT id FROM %s WHERE username=$1", usersTable)
+	if err := tx.QueryRow(query, input.Username).Scan(&userId); err != nil {
+		tx.Rollback()
+		return 0, translateError(err)
+	}
+
+	position, err := appendListPosition(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (user_id, list_id, role, position) VALUES ($1, $2, $3, $4)", usersListsTable)
+	if _, err := tx.Exec(query, userId, listId, input.Role, position); err != nil {
+		tx.Rollback()
+		return 0, translateError(err)
+	}
+
+	changes := todo.Changes{"role": {After: input.Role}}
+	if err := recordActivity(tx, listId, actorId, todo.ActivityAdded, todo.ActivityEntityMember, userId, changes); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return userId, tx.Commit()
+}
+
+func (r *ListMemberPostgres) UpdateRole(actorId, listId, memberId int, role string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if role != todo.RoleOwner {
+		if err := keepOwner(tx, listId, memberId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	var before string
+	query := fmt.Sprintf("SELECT role FROM %s WHERE list_id=$1 AND user_id=$2 FOR UPDATE", usersListsTable)
+	if err := tx.QueryRow(query, listId, memberId).Scan(&before); err != nil {
+		tx.Rollback()
+		return translateError(err)
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET role=$1 WHERE list_id=$2 AND user_id=$3", usersListsTable)
+	if err := requireAffected(tx.Exec(query, role, listId, memberId)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := bumpListVersion(tx, listId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	changes := diffFields(map[string]interface{}{"role": before}, map[string]interface{}{"role": role})
+	if err := recordActivity(tx, listId, actorId, todo.ActivityUpdated, todo.ActivityEntityMember, memberId, changes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ListMemberPostgres) Remove(actorId, listId, memberId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := keepOwner(tx, listId, memberId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var role string
+	query := fmt.Sprintf("DELETE FROM %s WHERE list_id=$1 AND user_id=$2 RETURNING role", usersListsTable)
+	if err := tx.QueryRow(query, listId, memberId).Scan(&role); err != nil {
+		tx.Rollback()
+		return translateError(err)
+	}
+
+	if err := unassignMember(tx, listId, memberId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	changes := todo.Changes{"role": {Before: role}}
+	if err := recordActivity(tx, listId, actorId, todo.ActivityRemoved, todo.ActivityEntityMember, memberId, changes); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// keepOwner fails if memberId is the only owner of the list. It locks the owners until the
+// transaction ends, so concurrent demotions and removals cannot leave the list without one.
+func keepOwner(tx *sql.Tx, listId, memberId int) error {
+	query := fmt.Sprintf("SELECT user_id FROM %s WHERE list_id=$1 AND role=$2 ORDER BY user_id FOR UPDATE", usersListsTable)
+	owners, err := queryIds(tx, query, listId, todo.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if len(owners) == 1 && owners[0] == int64(memberId) {
+		return errLastOwner
+	}
+
+	return nil
+}
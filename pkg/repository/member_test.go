@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func TestListMember_GetItemRole(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestListMember_GetItemRole func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	type args struct {
+		userId int
+		itemId int
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         string
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, itemId: 3},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"role"}).AddRow(todo.RoleEditor)
+				mock.ExpectQuery("SELECT ul.role FROM users_lists ul INNER JOIN lists_items li ON (.+) ORDER BY (.+) LIMIT 1").
+					WithArgs(args.userId, args.itemId).WillReturnRows(rows)
+			},
+			want: todo.RoleEditor,
+		},
+		{
+			name: "Not A Member",
+			args: args{userId: 1, itemId: 3},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("SELECT ul.role FROM users_lists ul INNER JOIN lists_items li ON (.+)").
+					WithArgs(args.userId, args.itemId).WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.GetItemRole(testCase.args.userId, testCase.args.itemId)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestListMember_Add(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestListMember_Add func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	type args struct {
+		listId int
+		input  todo.AddMemberInput
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         int
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{listId: 2, input: todo.AddMemberInput{Username: "friend", Role: todo.RoleViewer}},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(5)
+				mock.ExpectQuery("SELECT id FROM users WHERE username=(.+)").
+					WithArgs(args.input.Username).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT max\\(position\\) FROM users_lists WHERE user_id=(.+)").
+					WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000003"))
+				mock.ExpectExec("INSERT INTO users_lists").
+					WithArgs(5, args.listId, args.input.Role, "000004").WillReturnResult(sqlmock.NewResult(1, 1))
+				expectActivity(mock, args.listId, 1, todo.ActivityAdded, todo.ActivityEntityMember, 5,
+					`{"role":{"before":null,"after":"viewer"}}`)
+				mock.ExpectCommit()
+			},
+			want: 5,
+		},
+		{
+			name: "Unknown Username",
+			args: args{listId: 2, input: todo.AddMemberInput{Username: "nobody", Role: todo.RoleViewer}},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"})
+				mock.ExpectQuery("SELECT id FROM users WHERE username=(.+)").
+					WithArgs(args.input.Username).WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Already A Member",
+			args: args{listId: 2, input: todo.AddMemberInput{Username: "friend", Role: todo.RoleViewer}},
+			mockBehavior: func(args args) {
+				mock.ExpectBegin()
+				rows := sqlmock.NewRows([]string{"id"}).AddRow(5)
+				mock.ExpectQuery("SELECT id FROM users WHERE username=(.+)").
+					WithArgs(args.input.Username).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT max\\(position\\) FROM users_lists WHERE user_id=(.+)").
+					WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow("000003"))
+				mock.ExpectExec("INSERT INTO users_lists").
+					WithArgs(5, args.listId, args.input.Role, "000004").WillReturnError(&pq.Error{Code: "23505"})
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.Add(1, testCase.args.listId, testCase.args.input)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestListMember_UpdateRole(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestListMember_UpdateRole func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	mock.ExpectBegin()
+	expectOwners(mock, 2, 1, 5)
+	mock.ExpectQuery("SELECT role FROM users_lists WHERE list_id=(.+) AND user_id=(.+) FOR UPDATE").
+		WithArgs(2, 5).WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(todo.RoleViewer))
+	mock.ExpectExec("UPDATE users_lists SET role=(.+) WHERE list_id=(.+) AND user_id=(.+)").
+		WithArgs(todo.RoleEditor, 2, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectBumpList(mock, 2)
+	expectActivity(mock, 2, 1, todo.ActivityUpdated, todo.ActivityEntityMember, 5, `{"role":{"before":"viewer","after":"editor"}}`)
+	mock.ExpectCommit()
+	assert.NoError(t, r.UpdateRole(1, 2, 5, todo.RoleEditor))
+
+	mock.ExpectBegin()
+	expectOwners(mock, 2, 1)
+	mock.ExpectQuery("SELECT role FROM users_lists (.+)").WithArgs(2, 5).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, r.UpdateRole(1, 2, 5, todo.RoleEditor), todo.ErrNotFound)
+
+	mock.ExpectBegin()
+	expectOwners(mock, 2, 5)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, r.UpdateRole(5, 2, 5, todo.RoleEditor), todo.ErrConflict)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT role FROM users_lists (.+)").WithArgs(2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(todo.RoleOwner))
+	mock.ExpectExec("UPDATE users_lists SET role=(.+)").WithArgs(todo.RoleOwner, 2, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectBumpList(mock, 2)
+	expectActivity(mock, 2, 5, todo.ActivityUpdated, todo.ActivityEntityMember, 5, nil)
+	mock.ExpectCommit()
+	assert.NoError(t, r.UpdateRole(5, 2, 5, todo.RoleOwner))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListMember_Remove(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestListMember_Remove func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewListMemberPostgres(db)
+
+	mock.ExpectBegin()
+	expectOwners(mock, 2, 1)
+	mock.ExpectQuery("DELETE FROM users_lists WHERE list_id=(.+) AND user_id=(.+) RETURNING role").
+		WithArgs(2, 5).WillReturnRows(sqlmock.NewRows([]string{"role"}).AddRow(todo.RoleEditor))
+	mock.ExpectExec("UPDATE todo_items ti SET assignee_id=NULL, version=ti.version\\+1 FROM lists_items li WHERE li.item_id=ti.id AND li.list_id=(.+) "+
+		"AND ti.assignee_id=(.+) AND NOT EXISTS \\(SELECT 1 FROM lists_items oli INNER JOIN users_lists ul ON (.+)\\)").
+		WithArgs(2, 5).WillReturnResult(sqlmock.NewResult(0, 3))
+	expectActivity(mock, 2, 5, todo.ActivityRemoved, todo.ActivityEntityMember, 5, `{"role":{"before":"editor","after":null}}`)
+	mock.ExpectCommit()
+	assert.NoError(t, r.Remove(5, 2, 5))
+
+	mock.ExpectBegin()
+	expectOwners(mock, 2, 1)
+	mock.ExpectQuery("DELETE FROM users_lists (.+)").WithArgs(2, 5).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, r.Remove(1, 2, 5), todo.ErrNotFound)
+
+	mock.ExpectBegin()
+	expectOwners(mock, 2, 5)
+	mock.ExpectRollback()
+	assert.ErrorIs(t, r.Remove(5, 2, 5), todo.ErrConflict)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectOwners(mock sqlmock.Sqlmock, listId int, owners ...int) {
+	rows := sqlmock.NewRows([]string{"user_id"})
+	for _, id := range owners {
+		rows.AddRow(id)
+	}
+	mock.ExpectQuery("SELECT user_id FROM users_lists WHERE list_id=(.+) AND role=(.+) ORDER BY user_id FOR UPDATE").
+		WithArgs(listId, todo.RoleOwner).WillReturnRows(rows)
+}
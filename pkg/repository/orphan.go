@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	todo "todo-app"
+)
+
+var (
+	orphanListsCondition = fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s ul WHERE ul.list_id=tl.id)", usersListsTable)
+	orphanItemsCondition = fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s li WHERE li.item_id=ti.id)", listsItemsTable)
+)
+
+type OrphanPostgres struct {
+	db *sqlx.DB
+}
+
+func NewOrphanPostgres(db *sqlx.DB) *OrphanPostgres {
+	return &OrphanPostgres{db: db}
+}
+
+// Count returns the number of lists no user has access to and of items in no list.
+func (r *OrphanPostgres) Count() (todo.Orphans, error) {
+	var orphans todo.Orphans
+	query := fmt.Sprintf("SELECT (SELECT count(*) FROM %s tl WHERE %s) AS lists, (SELECT count(*) FROM %s ti WHERE %s) AS items",
+		todoListsTable, orphanListsCondition, todoItemsTable, orphanItemsCondition)
+	err := r.db.Get(&orphans, query)
+
+	return orphans, err
+}
+
+// Delete removes the orphaned lists and then the items left without a list, which
+// includes the items of those lists, and returns how many of each were removed.
+func (r *OrphanPostgres) Delete() (todo.Orphans, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return todo.Orphans{}, err
+	}
+
+	var orphans todo.Orphans
+	query := fmt.Sprintf("DELETE FROM %s tl WHERE %s", todoListsTable, orphanListsCondition)
+	result, err := tx.Exec(query)
+	if err != nil {
+		tx.Rollback()
+		return todo.Orphans{}, err
+	}
+
+	if orphans.Lists, err = result.RowsAffected(); err != nil {
+		tx.Rollback()
+		return todo.Orphans{}, err
+	}
+
+	if orphans.Items, err = deleteOrphanItems(tx); err != nil {
+		tx.Rollback()
+		return todo.Orphans{}, err
+	}
+
+	return orphans, tx.Commit()
+}
+
+// deleteOrphanItems removes the items that are not in any list anymore. Their
+// subtasks go with them.
+func deleteOrphanItems(tx *sql.Tx) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s ti WHERE %s", todoItemsTable, orphanItemsCondition)
+	result, err := tx.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
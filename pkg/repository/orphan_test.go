@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func TestOrphan_Count(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestOrphan_Count func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewOrphanPostgres(db)
+
+	mock.ExpectQuery("SELECT \\(SELECT count\\(\\*\\) FROM todo_lists tl WHERE NOT EXISTS \\(SELECT 1 FROM users_lists ul (.+)\\)\\) AS lists, " +
+		"\\(SELECT count\\(\\*\\) FROM todo_items ti WHERE NOT EXISTS \\(SELECT 1 FROM lists_items li (.+)\\)\\) AS items").
+		WillReturnRows(sqlmock.NewRows([]string{"lists", "items"}).AddRow(1, 4))
+
+	got, err := r.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, todo.Orphans{Lists: 1, Items: 4}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOrphan_Delete(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestOrphan_Delete func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewOrphanPostgres(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		want         todo.Orphans
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM todo_lists tl WHERE NOT EXISTS \\(SELECT 1 FROM users_lists ul WHERE ul.list_id=tl.id\\)").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM todo_items ti WHERE NOT EXISTS \\(SELECT 1 FROM lists_items li WHERE li.item_id=ti.id\\)").
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectCommit()
+			},
+			want: todo.Orphans{Lists: 1, Items: 4},
+		},
+		{
+			name: "Failure",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM todo_lists (.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM todo_items (.+)").WillReturnError(errors.New("some error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Delete()
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
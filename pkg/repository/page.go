@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	todo "todo-app"
+)
+
+// cursor points right after the last row of a page: its sort keys and id, which
+// breaks ties between rows with the same sort keys.
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v,omitempty"`
+	Id     int      `json:"id"`
+}
+
+// sortKey is an expression rows are ordered by, before the id. column names the
+// field whose value nextCursor stores for the expression.
+type sortKey struct {
+	column string
+	expr   string
+}
+
+// sortKeys are the expressions of every sort field, with %[1]s standing for the
+// table alias and %[2]s for the alias of the table holding positions.
+// Priorities are negated to put urgent items first in ascending order and
+// missing due dates are treated as infinitely late.
+var sortKeys = map[string][]sortKey{
+	"position":   {{column: "position", expr: "%[2]s.position"}},
+	"id":         nil,
+	"title":      {{column: "title", expr: "%[1]s.title"}},
+	"created_at": {{column: "created_at", expr: "%[1]s.created_at"}},
+	"priority": {
+		{column: "priority", expr: "-%[1]s.priority"},
+		{column: "due_at", expr: "coalesce(%[1]s.due_at, 'infinity')"},
+	},
+}
+
+// page translates a todo.PageQuery into keyset pagination clauses for the table
+// aliased as alias, whose rows are positioned in the table aliased as positions.
+type page struct {
+	alias     string
+	positions string
+	sort      string
+	keys      []sortKey
+	desc      bool
+	limit     int
+	after     *cursor
+}
+
+func newPage(alias, positions string, query todo.PageQuery) (page, error) {
+	p := page{alias: alias, positions: positions, sort: query.Sort, limit: query.Limit}
+	if p.sort == "" {
+		p.sort = "position"
+	}
+	if p.limit == 0 {
+		p.limit = todo.DefaultPageLimit
+	}
+	field := strings.TrimPrefix(p.sort, "-")
+	p.desc = field != p.sort
+
+	keys, ok := sortKeys[field]
+	if !ok {
+		return page{}, fmt.Errorf("%w: cannot sort by %s", todo.ErrValidation, field)
+	}
+	p.keys = keys
+
+	if query.Cursor == "" {
+		return p, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return page{}, fmt.Errorf("%w: invalid cursor", todo.ErrValidation)
+	}
+
+	var after cursor
+	if err := json.Unmarshal(data, &after); err != nil {
+		return page{}, fmt.Errorf("%w: invalid cursor", todo.ErrValidation)
+	}
+	if after.Sort != p.sort || len(after.Values) != len(p.keys) {
+		return page{}, fmt.Errorf("%w: cursor was issued for another sort order", todo.ErrValidation)
+	}
+	p.after = &after
+
+	return p, nil
+}
+
+// condition restricts the rows to those following the cursor. It returns an empty
+// string for the first page.
+func (p page) condition(argId int) (string, []interface{}) {
+	if p.after == nil {
+		return "", nil
+	}
+
+	op := ">"
+	if p.desc {
+		op = "<"
+	}
+
+	if len(p.keys) == 0 {
+		return fmt.Sprintf("%s.id %s $%d", p.alias, op, argId), []interface{}{p.after.Id}
+	}
+
+	exprs := make([]string, 0, len(p.keys)+1)
+	params := make([]string, 0, len(p.keys)+1)
+	args := make([]interface{}, 0, len(p.keys)+1)
+	for i, key := range p.keys {
+		exprs = append(exprs, fmt.Sprintf(key.expr, p.alias, p.positions))
+		params = append(params, fmt.Sprintf("$%d", argId+i))
+		args = append(args, p.after.Values[i])
+	}
+	exprs = append(exprs, p.alias+".id")
+	params = append(params, fmt.Sprintf("$%d", argId+len(p.keys)))
+	args = append(args, p.after.Id)
+
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(exprs, ", "), op, strings.Join(params, ", ")), args
+}
+
+func (p page) orderBy() string {
+	direction := "ASC"
+	if p.desc {
+		direction = "DESC"
+	}
+
+	order := make([]string, 0, len(p.keys)+1)
+	for _, key := range p.keys {
+		order = append(order, fmt.Sprintf(key.expr, p.alias, p.positions)+" "+direction)
+	}
+	order = append(order, fmt.Sprintf("%s.id %s", p.alias, direction))
+
+	return strings.Join(order, ", ")
+}
+
+// fetchLimit asks for one row more than the page holds, to know whether another page follows.
+func (p page) fetchLimit() int {
+	return p.limit + 1
+}
+
+// nextCursor returns the cursor of the page following the row with the given id.
+// values holds the sortable fields of that row.
+func (p page) nextCursor(id int, values map[string]interface{}) string {
+	next := cursor{Sort: p.sort, Id: id}
+	for _, key := range p.keys {
+		next.Values = append(next.Values, cursorValue(values[key.column]))
+	}
+
+	data, _ := json.Marshal(next)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// cursorValue formats a field the way its sort key expression compares it.
+func cursorValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case *time.Time:
+		if v == nil {
+			return "infinity"
+		}
+		return v.Format(time.RFC3339Nano)
+	case todo.Priority:
+		return strconv.Itoa(-v.Level())
+	}
+
+	return ""
+}
+
+// likePattern matches value as a case-insensitive substring in ILIKE conditions.
+func likePattern(value string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value) + "%"
+}
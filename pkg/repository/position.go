@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	todo "todo-app"
+)
+
+var (
+	errMoveNeighbour = fmt.Errorf("%w: before and after must be other entries of the same collection", todo.ErrValidation)
+	errMoveOrder     = fmt.Errorf("%w: the after entry must come before the before entry", todo.ErrValidation)
+)
+
+// Positions of lists and items are base 36 strings compared byte by byte (the position
+// columns use the "C" collation), so a row can always be moved between two others by
+// giving it a new position, without renumbering its neighbours.
+const (
+	rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+	// rankWidth is the length of appended positions: they count up in fixed width, so
+	// positions only grow longer after rankLimit (36^6) appends.
+	rankWidth = 6
+	rankLimit = 2176782336
+)
+
+// rankBetween returns a position that sorts strictly between before and after. An empty
+// before or after leaves that side open. The result never ends in a zero digit, so there
+// is always room for another position before it.
+func rankBetween(before, after string) string {
+	if after != "" {
+		n := 0
+		for n < len(after) && rankDigitAt(before, n) == after[n] {
+			n++
+		}
+		if n > 0 {
+			return after[:n] + rankBetween(rankTail(before, n), after[n:])
+		}
+	}
+
+	lo := 0
+	if before != "" {
+		lo = strings.IndexByte(rankDigits, before[0])
+	}
+	hi := len(rankDigits)
+	if after != "" {
+		hi = strings.IndexByte(rankDigits, after[0])
+	}
+
+	if hi-lo > 1 {
+		return string(rankDigits[(lo+hi)/2])
+	}
+
+	// the first digits are adjacent: keep before's and look further
+	if len(after) > 1 {
+		return after[:1]
+	}
+
+	return string(rankDigits[lo]) + rankBetween(rankTail(before, 1), "")
+}
+
+// rankAfter returns the position of a row appended after last.
+func rankAfter(last string) string {
+	head := last
+	if len(head) > rankWidth {
+		head = head[:rankWidth]
+	}
+	head += strings.Repeat("0", rankWidth-len(head))
+
+	n, err := strconv.ParseUint(head, len(rankDigits), 64)
+	if err != nil || n+1 >= rankLimit {
+		return rankBetween(last, "")
+	}
+
+	next := strconv.FormatUint(n+1, len(rankDigits))
+	return strings.Repeat("0", rankWidth-len(next)) + next
+}
+
+// lastRank returns the greatest position the query selects, or an empty string.
+func lastRank(tx *sql.Tx, query string, args ...interface{}) (string, error) {
+	var last sql.NullString
+	if err := tx.QueryRow(query, args...).Scan(&last); err != nil {
+		return "", err
+	}
+
+	return last.String, nil
+}
+
+// move gives the row of table with the given scope and id columns a position right
+// after input.After and before input.Before, which must be rows of the same scope,
+// e.g. the list of an item. Only the moved row changes. It returns the change of the
+// position for the activity record.
+func move(tx *sql.Tx, table, scopeColumn, idColumn string, scope, id int, input todo.MoveInput) (todo.Changes, error) {
+	var current string
+	query := fmt.Sprintf("SELECT position FROM %s WHERE %s=$1 AND %s=$2", table, scopeColumn, idColumn)
+	if err := tx.QueryRow(query+" FOR UPDATE", scope, id).Scan(&current); err != nil {
+		return nil, translateError(err)
+	}
+
+	position := func(neighbourId *int) (string, error) {
+		if *neighbourId == id {
+			return "", errMoveNeighbour
+		}
+
+		var neighbour string
+		if err := tx.QueryRow(query, scope, *neighbourId).Scan(&neighbour); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return "", errMoveNeighbour
+			}
+			return "", err
+		}
+
+		return neighbour, nil
+	}
+
+	// adjacent returns the closest position below or above the given one, leaving out
+	// the moved row, or an empty string at the ends
+	adjacent := func(aggregate, op, from string) (string, error) {
+		return lastRank(tx, fmt.Sprintf("SELECT %s(position) FROM %s WHERE %s=$1 AND %s<>$2 AND position %s $3",
+			aggregate, table, scopeColumn, idColumn, op), scope, id, from)
+	}
+
+	var after, before string
+	var err error
+	if input.After != nil {
+		if after, err = position(input.After); err != nil {
+			return nil, err
+		}
+	}
+	if input.Before != nil {
+		if before, err = position(input.Before); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case input.After == nil:
+		after, err = adjacent("max", "<", before)
+	case input.Before == nil:
+		before, err = adjacent("min", ">", after)
+	case after >= before:
+		err = errMoveOrder
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := rankAfter(after)
+	if before != "" {
+		next = rankBetween(after, before)
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET position=$1 WHERE %s=$2 AND %s=$3", table, scopeColumn, idColumn)
+	if err := requireAffected(tx.Exec(query, next, scope, id)); err != nil {
+		return nil, err
+	}
+
+	return todo.Changes{"position": {Before: current, After: next}}, nil
+}
+
+func rankDigitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+
+	return rankDigits[0]
+}
+
+func rankTail(rank string, n int) string {
+	if n < len(rank) {
+		return rank[n:]
+	}
+
+	return ""
+}
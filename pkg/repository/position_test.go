@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	testTable := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{name: "Empty", want: "i"},
+		{name: "First", after: "000001", want: "000000i"},
+		{name: "Last", before: "000009", want: "i"},
+		{name: "Adjacent Appends", before: "000005", after: "000006", want: "000005i"},
+		{name: "Wide Gap", before: "a", after: "k", want: "f"},
+		{name: "Shorter Before", before: "a", after: "a5", want: "a2"},
+		{name: "Trailing Zero", before: "000010", after: "0000100i", want: "00001009"},
+		{name: "Past Last Digit", before: "zz", want: "zzi"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := rankBetween(testCase.before, testCase.after)
+			assert.Equal(t, testCase.want, got)
+			assert.Less(t, testCase.before, got)
+			if testCase.after != "" {
+				assert.Less(t, got, testCase.after)
+			}
+		})
+	}
+}
+
+func TestRankBetween_RandomMoves(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	ranks := []string{rankAfter("")}
+	for i := 0; i < 2000; i++ {
+		var next string
+		switch at := random.Intn(len(ranks) + 1); {
+		case at == 0:
+			next = rankBetween("", ranks[0])
+		case at == len(ranks):
+			next = rankAfter(ranks[len(ranks)-1])
+		default:
+			next = rankBetween(ranks[at-1], ranks[at])
+		}
+
+		ranks = append(ranks, next)
+		sort.Strings(ranks)
+		for j := 1; j < len(ranks); j++ {
+			if ranks[j-1] >= ranks[j] {
+				t.Fatalf("ranks %q and %q are out of order", ranks[j-1], ranks[j])
+			}
+		}
+	}
+}
+
+func TestRankAfter(t *testing.T) {
+	assert.Equal(t, "000001", rankAfter(""))
+	assert.Equal(t, "00000a", rankAfter("000009"))
+	assert.Equal(t, "000010", rankAfter("00000z"))
+	assert.Equal(t, "000006", rankAfter("000005i"))
+	assert.Equal(t, "0i0001", rankAfter("0i"))
+	assert.Equal(t, "zzzzzzi", rankAfter("zzzzzz"))
+}
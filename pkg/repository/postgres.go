@@ -11,6 +11,16 @@ const (
 	usersListsTable = "users_lists"
 	todoItemsTable  = "todo_items"
 	listsItemsTable = "lists_items"
+
+	labelsTable      = "labels"
+	itemsLabelsTable = "items_labels"
+
+	refreshTokensTable = "refresh_tokens"
+
+	activityTable = "activity"
+
+	itemCommentsTable = "item_comments"
+	attachmentsTable  = "attachments"
 )
 
 type Config struct {
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	todo "todo-app"
+)
+
+var ErrRefreshTokenUsed = errors.New("refresh token has already been used")
+
+type RefreshTokenPostgres struct {
+	db *sqlx.DB
+}
+
+func NewRefreshTokenPostgres(db *sqlx.DB) *RefreshTokenPostgres {
+	return &RefreshTokenPostgres{db: db}
+}
+
+func (r *RefreshTokenPostgres) Create(token todo.RefreshToken) error {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, family_id, token_hash, expires_at) VALUES ($1,$2,$3,$4)",
+		refreshTokensTable)
+	_, err := r.db.Exec(query, token.UserId, token.FamilyId, token.TokenHash, token.ExpiresAt)
+
+	return err
+}
+
+func (r *RefreshTokenPostgres) GetByHash(tokenHash string) (todo.RefreshToken, error) {
+	var token todo.RefreshToken
+	query := fmt.Sprintf(`SELECT id, user_id, family_id, token_hash, expires_at, created_at, used_at, revoked_at
+								FROM %s WHERE token_hash=$1`, refreshTokensTable)
+	err := r.db.Get(&token, query, tokenHash)
+
+	return token, translateError(err)
+}
+
+// Rotate marks the presented token as used and stores its successor in one transaction.
+// ErrRefreshTokenUsed is returned when a concurrent request has already rotated the token.
+func (r *RefreshTokenPostgres) Rotate(usedId int, next todo.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	useTokenQuery := fmt.Sprintf("UPDATE %s SET used_at=now() WHERE id=$1 AND used_at IS NULL AND revoked_at IS NULL",
+		refreshTokensTable)
+	result, err := tx.Exec(useTokenQuery, usedId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return ErrRefreshTokenUsed
+	}
+
+	createTokenQuery := fmt.Sprintf("INSERT INTO %s (user_id, family_id, token_hash, expires_at) VALUES ($1,$2,$3,$4)",
+		refreshTokensTable)
+	_, err = tx.Exec(createTokenQuery, next.UserId, next.FamilyId, next.TokenHash, next.ExpiresAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *RefreshTokenPostgres) IsFamilyActive(familyId string) (bool, error) {
+	var active bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE family_id=$1 AND revoked_at IS NULL)",
+		refreshTokensTable)
+	err := r.db.Get(&active, query, familyId)
+
+	return active, err
+}
+
+func (r *RefreshTokenPostgres) RevokeFamily(familyId string) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at=now() WHERE family_id=$1 AND revoked_at IS NULL", refreshTokensTable)
+	_, err := r.db.Exec(query, familyId)
+
+	return err
+}
+
+func (r *RefreshTokenPostgres) RevokeAll(userId int) error {
+	query := fmt.Sprintf("UPDATE %s SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL", refreshTokensTable)
+	_, err := r.db.Exec(query, userId)
+
+	return err
+}
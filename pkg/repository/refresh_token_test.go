@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+	todo "todo-app"
+)
+
+func TestRefreshToken_Rotate(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestRefreshToken_Rotate func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewRefreshTokenPostgres(db)
+
+	next := todo.RefreshToken{
+		UserId:    1,
+		FamilyId:  "family",
+		TokenHash: "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	testTable := []struct {
+		name         string
+		usedId       int
+		mockBehavior func(usedId int)
+		wantErr      error
+	}{
+		{
+			name:   "OK",
+			usedId: 4,
+			mockBehavior: func(usedId int) {
+				mock.ExpectBegin()
+
+				mock.ExpectExec("UPDATE refresh_tokens SET used_at=now\\(\\) WHERE (.+)").WithArgs(usedId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs(next.UserId, next.FamilyId, next.TokenHash, next.ExpiresAt).
+					WillReturnResult(sqlmock.NewResult(5, 1))
+
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "Already Used",
+			usedId: 4,
+			mockBehavior: func(usedId int) {
+				mock.ExpectBegin()
+
+				mock.ExpectExec("UPDATE refresh_tokens SET used_at=now\\(\\) WHERE (.+)").WithArgs(usedId).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				mock.ExpectRollback()
+			},
+			wantErr: ErrRefreshTokenUsed,
+		},
+		{
+			name:   "Insert Error",
+			usedId: 4,
+			mockBehavior: func(usedId int) {
+				mock.ExpectBegin()
+
+				mock.ExpectExec("UPDATE refresh_tokens SET used_at=now\\(\\) WHERE (.+)").WithArgs(usedId).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectExec("INSERT INTO refresh_tokens").
+					WithArgs(next.UserId, next.FamilyId, next.TokenHash, next.ExpiresAt).
+					WillReturnError(errors.New("some error"))
+
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("some error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.usedId)
+
+			err := r.Rotate(testCase.usedId, next)
+			if testCase.wantErr != nil {
+				assert.EqualError(t, err, testCase.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRefreshToken_IsFamilyActive(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestRefreshToken_IsFamilyActive func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewRefreshTokenPostgres(db)
+
+	testTable := []struct {
+		name     string
+		familyId string
+		active   bool
+	}{
+		{
+			name:     "Active",
+			familyId: "family",
+			active:   true,
+		},
+		{
+			name:     "Revoked",
+			familyId: "family",
+			active:   false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			rows := sqlmock.NewRows([]string{"exists"}).AddRow(testCase.active)
+			mock.ExpectQuery("SELECT EXISTS (.+) refresh_tokens WHERE family_id=(.+)").
+				WithArgs(testCase.familyId).WillReturnRows(rows)
+
+			got, err := r.IsFamilyActive(testCase.familyId)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.active, got)
+		})
+	}
+}
@@ -2,40 +2,143 @@ package repository
 
 import (
 	"github.com/jmoiron/sqlx"
+	"time"
 	todo "todo-app"
 )
 
 type Authorization interface {
 	CreateUser(user todo.User) (int, error)
-	GetUser(username, password string) (todo.User, error)
+	GetUser(username string) (todo.User, error)
+	UpdatePasswordHash(userId int, passwordHash string) error
+	GetTimeZone(userId int) (string, error)
+}
+
+type RefreshToken interface {
+	Create(token todo.RefreshToken) error
+	GetByHash(tokenHash string) (todo.RefreshToken, error)
+	Rotate(usedId int, next todo.RefreshToken) error
+	IsFamilyActive(familyId string) (bool, error)
+	RevokeFamily(familyId string) error
+	RevokeAll(userId int) error
 }
 
 type TodoList interface {
 	CreateList(userId int, list todo.TodoList) (int, error)
-	GetAll(userId int) ([]todo.TodoList, error)
+	GetAll(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error)
 	GetById(userId, listId int) (todo.TodoList, error)
 	Update(userId, listId int, input todo.UpdateListInput) error
-	Delete(userId, listId int) error
+	Move(userId, listId int, input todo.MoveInput) error
+	Delete(userId, listId int, version *int) error
+	Clone(userId, listId int, input todo.CloneListInput) (int, error)
+	Instantiate(userId, templateId int, input todo.InstantiateTemplateInput) (int, error)
+}
+
+type ListMember interface {
+	GetRole(userId, listId int) (string, error)
+	GetItemRole(userId, itemId int) (string, error)
+	GetAll(listId int) ([]todo.ListMember, error)
+	Add(actorId, listId int, input todo.AddMemberInput) (int, error)
+	UpdateRole(actorId, listId, memberId int, role string) error
+	Remove(actorId, listId, memberId int) error
 }
 
 type TodoItem interface {
-	CreateItem(listId int, item todo.TodoItem) (int, error)
-	GetAll(userId, listId int) ([]todo.TodoItem, error)
+	CreateItem(userId, listId int, item todo.TodoItem) (int, error)
+	CreateSubtask(userId, parentId int, item todo.TodoItem) (int, error)
+	GetAll(userId, listId int, input todo.ItemsQuery) ([]todo.TodoItem, string, error)
 	GetById(userId, itemId int) (todo.TodoItem, error)
+	GetDue(userId int, from, to time.Time) ([]todo.TodoItem, error)
+	GetAssigned(userId int) ([]todo.TodoItem, error)
+	GetByLabel(userId, labelId int) ([]todo.TodoItem, error)
+	GetSubtasks(userId, itemId int) ([]todo.TodoItem, error)
+	GetLists(userId, itemId int) ([]todo.TodoList, error)
 	Update(userId, itemId int, input todo.UpdateItemInput) error
-	Delete(userId, itemId int) error
+	CompleteOccurrence(userId, itemId int, input todo.UpdateItemInput, next todo.TodoItem) error
+	Move(userId, itemId int, input todo.MoveInput) error
+	Transfer(userId, itemId int, input todo.TransferItemInput) error
+	Copy(userId, itemId int, input todo.CopyItemInput) (int, error)
+	Link(userId, itemId, listId int) error
+	Unlink(userId, itemId, listId int) error
+	Delete(userId, itemId int, version *int) error
+	Batch(userId, listId int, input todo.BatchInput, schedule Schedule) ([]todo.BatchResult, error)
+}
+
+type Label interface {
+	Create(userId int, label todo.Label) (int, error)
+	GetAll(userId int) ([]todo.Label, error)
+	GetById(userId, labelId int) (todo.Label, error)
+	Update(userId, labelId int, input todo.UpdateLabelInput) error
+	Delete(userId, labelId int) error
+	Attach(userId, itemId, labelId int) error
+	Detach(userId, itemId, labelId int) error
+}
+
+type Search interface {
+	Search(userId int, input todo.SearchQuery) ([]todo.SearchResult, error)
+}
+
+type Trash interface {
+	GetAll(userId int) ([]todo.TrashEntry, error)
+	RestoreList(userId, listId int) error
+	RestoreItem(userId, itemId int) error
+	Purge(before time.Time) (int64, error)
+}
+
+type Orphans interface {
+	Count() (todo.Orphans, error)
+	Delete() (todo.Orphans, error)
+}
+
+type Activity interface {
+	GetAll(listId int, input todo.ActivityQuery) ([]todo.Activity, string, error)
+}
+
+type Comment interface {
+	Create(userId, itemId int, input todo.CommentInput) (int, error)
+	GetAll(userId, itemId int) ([]todo.Comment, error)
+	GetById(userId, commentId int) (todo.Comment, error)
+	Update(commentId int, input todo.CommentInput) error
+	Delete(commentId int) error
+}
+
+type Attachment interface {
+	Create(userId int, attachment todo.Attachment, quota int64) (int, error)
+	GetAll(userId, itemId int) ([]todo.Attachment, error)
+	GetById(userId, attachmentId int) (todo.Attachment, error)
+	GetUsage(userId int) (int64, error)
+	Detach(attachmentId int) error
+	GetDetached(limit int) ([]todo.Attachment, error)
+	Delete(attachmentId int) error
 }
 
 type Repository struct {
 	Authorization
+	RefreshToken
 	TodoList
+	ListMember
 	TodoItem
+	Label
+	Search
+	Trash
+	Orphans
+	Activity
+	Comment
+	Attachment
 }
 
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{
 		Authorization: NewAuthPostgres(db),
+		RefreshToken:  NewRefreshTokenPostgres(db),
 		TodoList:      NewTodoListPostgres(db),
+		ListMember:    NewListMemberPostgres(db),
 		TodoItem:      NewTodoItemRepository(db),
+		Label:         NewLabelPostgres(db),
+		Search:        NewSearchPostgres(db),
+		Trash:         NewTrashPostgres(db),
+		Orphans:       NewOrphanPostgres(db),
+		Activity:      NewActivityPostgres(db),
+		Comment:       NewCommentPostgres(db),
+		Attachment:    NewAttachmentPostgres(db),
 	}
 }
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	todo "todo-app"
+)
+
+// searchHeadlineOptions makes ts_headline return up to two fragments of the title
+// and description with the matched words wrapped in <b></b>.
+const searchHeadlineOptions = "StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// htmlEscapes are the replacements applied to the searched text before ts_headline
+// marks it up, ampersand first so the other entities are not escaped twice.
+var htmlEscapes = [][2]string{{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&#34;"}}
+
+type SearchPostgres struct {
+	db *sqlx.DB
+}
+
+func NewSearchPostgres(db *sqlx.DB) *SearchPostgres {
+	return &SearchPostgres{db: db}
+}
+
+// Search ranks the lists and items the user has access to against the query.
+func (r *SearchPostgres) Search(userId int, input todo.SearchQuery) ([]todo.SearchResult, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = todo.DefaultSearchLimit
+	}
+
+	// An item linked into several of the user's lists is returned once, with the first of them.
+	results := make([]todo.SearchResult, 0)
+	query := fmt.Sprintf(`SELECT '%s' AS type, tl.id, tl.id AS list_id, tl.title,
+									ts_headline('simple', %s, q, '%s') AS snippet,
+									ts_rank(tl.search_vector, q) AS rank
+								FROM %s tl INNER JOIN %s ul ON ul.list_id = tl.id, websearch_to_tsquery('simple', $2) q
+								WHERE ul.user_id = $1 AND tl.deleted_at IS NULL AND tl.search_vector @@ q
+								UNION ALL
+								(SELECT DISTINCT ON (ti.id) '%s' AS type, ti.id, li.list_id, ti.title,
+									ts_headline('simple', %s, q, '%s') AS snippet,
+									ts_rank(ti.search_vector, q) AS rank
+								FROM %s ti INNER JOIN %s li ON li.item_id = ti.id
+									INNER JOIN %s ul ON ul.list_id = li.list_id INNER JOIN %s tl ON tl.id = li.list_id,
+									websearch_to_tsquery('simple', $2) q
+								WHERE ul.user_id = $1 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL AND ti.search_vector @@ q
+								ORDER BY ti.id, li.list_id)
+								ORDER BY rank DESC, type, id LIMIT $3`,
+		todo.SearchResultList, escapeHTML("concat_ws(' ', tl.title, tl.description)"), searchHeadlineOptions,
+		todoListsTable, usersListsTable,
+		todo.SearchResultItem, escapeHTML("concat_ws(' ', ti.title, ti.description)"), searchHeadlineOptions,
+		todoItemsTable, listsItemsTable, usersListsTable, todoListsTable)
+	err := r.db.Select(&results, query, userId, input.Query, limit)
+
+	return results, err
+}
+
+// escapeHTML wraps the SQL text expression so that it evaluates to the HTML-escaped text.
+func escapeHTML(expr string) string {
+	for _, escape := range htmlEscapes {
+		expr = fmt.Sprintf("replace(%s, '%s', '%s')", expr, escape[0], escape[1])
+	}
+
+	return expr
+}
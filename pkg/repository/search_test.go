@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func TestSearch_Search(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestSearch_Search func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewSearchPostgres(db)
+
+	type args struct {
+		userId int
+		input  todo.SearchQuery
+	}
+
+	testTable := []struct {
+		name         string
+		args         args
+		mockBehavior func(args args)
+		want         []todo.SearchResult
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{userId: 1, input: todo.SearchQuery{Query: "milk"}},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"type", "id", "list_id", "title", "snippet", "rank"}).
+					AddRow("item", 7, 2, "Buy milk", "Buy <b>milk</b>", 0.6).
+					AddRow("list", 3, 3, "Groceries", "Groceries <b>milk</b>", 0.2)
+				mock.ExpectQuery("SELECT 'list' AS type, (.+) FROM todo_lists tl (.+) UNION ALL \\(SELECT DISTINCT ON \\(ti.id\\) 'item' AS type, (.+) "+
+					"FROM todo_items ti (.+) ORDER BY ti.id, li.list_id\\) ORDER BY rank DESC, type, id LIMIT (.+)").
+					WithArgs(args.userId, "milk", todo.DefaultSearchLimit).WillReturnRows(rows)
+			},
+			want: []todo.SearchResult{
+				{Type: "item", Id: 7, ListId: 2, Title: "Buy milk", Snippet: "Buy <b>milk</b>", Rank: 0.6},
+				{Type: "list", Id: 3, ListId: 3, Title: "Groceries", Snippet: "Groceries <b>milk</b>", Rank: 0.2},
+			},
+		},
+		{
+			name: "Item In Several Lists",
+			args: args{userId: 1, input: todo.SearchQuery{Query: "milk"}},
+			mockBehavior: func(args args) {
+				// The item is linked into lists 2 and 4; DISTINCT ON keeps the lowest list.
+				rows := sqlmock.NewRows([]string{"type", "id", "list_id", "title", "snippet", "rank"}).
+					AddRow("item", 7, 2, "Buy milk", "Buy <b>milk</b>", 0.6)
+				mock.ExpectQuery("SELECT (.+) UNION ALL \\(SELECT DISTINCT ON \\(ti.id\\) (.+) ORDER BY ti.id, li.list_id\\) (.+)").
+					WithArgs(args.userId, "milk", todo.DefaultSearchLimit).WillReturnRows(rows)
+			},
+			want: []todo.SearchResult{
+				{Type: "item", Id: 7, ListId: 2, Title: "Buy milk", Snippet: "Buy <b>milk</b>", Rank: 0.6},
+			},
+		},
+		{
+			name: "No Matches",
+			args: args{userId: 1, input: todo.SearchQuery{Query: "milk", Limit: 5}},
+			mockBehavior: func(args args) {
+				rows := sqlmock.NewRows([]string{"type", "id", "list_id", "title", "snippet", "rank"})
+				mock.ExpectQuery("SELECT (.+) UNION ALL \\(SELECT (.+)").
+					WithArgs(args.userId, "milk", 5).WillReturnRows(rows)
+			},
+			want: []todo.SearchResult{},
+		},
+		{
+			name: "Failure",
+			args: args{userId: 1, input: todo.SearchQuery{Query: "milk"}},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery("SELECT (.+) UNION ALL \\(SELECT (.+)").
+					WithArgs(args.userId, "milk", todo.DefaultSearchLimit).WillReturnError(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			got, err := r.Search(testCase.args.userId, testCase.args.input)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestSearch_Search_EscapesSnippet(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestSearch_Search_EscapesSnippet func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewSearchPostgres(db)
+
+	// Both texts are escaped before ts_headline adds its own <b></b> markup.
+	headline := "ts_headline\\('simple', replace\\(replace\\(replace\\(replace\\(concat_ws\\(' ', %[1]s.title, %[1]s.description\\), " +
+		"'&', '&amp;'\\), '<', '&lt;'\\), '>', '&gt;'\\), '\"', '&#34;'\\), q, '(.+)'\\) AS snippet"
+	rows := sqlmock.NewRows([]string{"type", "id", "list_id", "title", "snippet", "rank"}).
+		AddRow("item", 7, 2, "<script>milk</script>", "&lt;script&gt;<b>milk</b>&lt;/script&gt;", 0.6)
+	mock.ExpectQuery("SELECT 'list' AS type, (.+) "+fmt.Sprintf(headline, "tl")+"(.+) UNION ALL (.+) "+fmt.Sprintf(headline, "ti")+"(.+)").
+		WithArgs(1, "milk", todo.DefaultSearchLimit).WillReturnRows(rows)
+
+	got, err := r.Search(1, todo.SearchQuery{Query: "milk"})
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.SearchResult{
+		{Type: "item", Id: 7, ListId: 2, Title: "<script>milk</script>", Snippet: "&lt;script&gt;<b>milk</b>&lt;/script&gt;", Rank: 0.6},
+	}, got)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
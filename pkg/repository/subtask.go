@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	todo "todo-app"
+)
+
+var (
+	errOpenSubtasks = fmt.Errorf("%w: item has open subtasks", todo.ErrConflict)
+	errParentList   = fmt.Errorf("%w: parent_id must be an item of the same list", todo.ErrValidation)
+	errParentCycle  = fmt.Errorf("%w: an item cannot become a subtask of itself or of its subtasks", todo.ErrValidation)
+)
+
+// CreateSubtask adds the item under the parent, in the parent's list.
+func (r *TodoItemRepository) CreateSubtask(userId, parentId int, item todo.TodoItem) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var listId int
+	query := fmt.Sprintf("SELECT list_id FROM %s WHERE item_id=$1 ORDER BY id LIMIT 1", listsItemsTable)
+	if err := tx.QueryRow(query, parentId).Scan(&listId); err != nil {
+		tx.Rollback()
+		return 0, translateError(err)
+	}
+
+	item.ParentId = &parentId
+	itemId, err := createItem(tx, listId, item)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := recordItemCreated(tx, userId, itemId, item); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return itemId, tx.Commit()
+}
+
+// GetSubtasks returns the direct subtasks of the item in the order of its list.
+func (r *TodoItemRepository) GetSubtasks(userId, itemId int) ([]todo.TodoItem, error) {
+	items := make([]todo.TodoItem, 0)
+	query := fmt.Sprintf("SELECT DISTINCT %s, li.position FROM %s ti INNER JOIN %s li ON li.item_id=ti.id "+
+		"INNER JOIN %s ul ON ul.list_id=li.list_id INNER JOIN %s tl ON tl.id=li.list_id "+
+		"WHERE ul.user_id=$1 AND ti.parent_item_id=$2 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL "+
+		"ORDER BY li.position, ti.id",
+		itemColumns, todoItemsTable, listsItemsTable, usersListsTable, todoListsTable)
+	if err := r.db.Select(&items, query, userId, itemId); err != nil {
+		return nil, err
+	}
+
+	return items, loadItemLabels(r.db, userId, items)
+}
+
+func checkParentList(tx *sql.Tx, parentId, listId int) error {
+	var ok bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s li INNER JOIN %s ti ON ti.id=li.item_id "+
+		"WHERE li.item_id=$1 AND li.list_id=$2 AND ti.deleted_at IS NULL)", listsItemsTable, todoItemsTable)
+	if err := tx.QueryRow(query, parentId, listId).Scan(&ok); err != nil {
+		return err
+	}
+
+	if !ok {
+		return errParentList
+	}
+
+	return nil
+}
+
+// checkItemUpdate keeps the item tree consistent: a parent is only done once all its
+// subtasks are, and a new parent must be in the item's list and not below the item.
+func checkItemUpdate(tx *sql.Tx, itemId int, input todo.UpdateItemInput) error {
+	if input.Done != nil && *input.Done {
+		var open bool
+		query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE parent_item_id=$1 AND NOT done AND deleted_at IS NULL)", todoItemsTable)
+		if err := tx.QueryRow(query, itemId).Scan(&open); err != nil {
+			return err
+		}
+
+		if open {
+			return errOpenSubtasks
+		}
+	}
+
+	if input.ParentId.Value == nil {
+		return nil
+	}
+	parentId := *input.ParentId.Value
+
+	var sameList bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %[1]s p INNER JOIN %[1]s c ON c.list_id=p.list_id "+
+		"WHERE p.item_id=$1 AND c.item_id=$2)", listsItemsTable)
+	if err := tx.QueryRow(query, parentId, itemId).Scan(&sameList); err != nil {
+		return err
+	}
+
+	if !sameList {
+		return errParentList
+	}
+
+	var cycle bool
+	query = fmt.Sprintf("WITH RECURSIVE ancestors AS (SELECT id, parent_item_id FROM %[1]s WHERE id=$1 "+
+		"UNION SELECT ti.id, ti.parent_item_id FROM %[1]s ti INNER JOIN ancestors a ON ti.id=a.parent_item_id) "+
+		"SELECT EXISTS (SELECT 1 FROM ancestors WHERE id=$2)", todoItemsTable)
+	if err := tx.QueryRow(query, parentId, itemId).Scan(&cycle); err != nil {
+		return err
+	}
+
+	if cycle {
+		return errParentCycle
+	}
+
+	return nil
+}
+
+// reopenAncestors reopens the done ancestors of the item if it is open itself.
+func reopenAncestors(tx *sql.Tx, itemId int) error {
+	query := fmt.Sprintf("WITH RECURSIVE ancestors AS ("+
+		"SELECT parent_item_id AS id FROM %[1]s WHERE id=$1 AND NOT done AND parent_item_id IS NOT NULL "+
+		"UNION SELECT ti.parent_item_id FROM %[1]s ti INNER JOIN ancestors a ON ti.id=a.id WHERE ti.parent_item_id IS NOT NULL) "+
+		"UPDATE %[1]s SET done=false, completed_at=NULL, version=version+1 WHERE done AND id IN (SELECT id FROM ancestors)", todoItemsTable)
+	_, err := tx.Exec(query, itemId)
+	return err
+}
+
+// loadSubtasks fills the Subtasks of the items of the list with their whole subtrees,
+// in the order of the list.
+func loadSubtasks(db *sqlx.DB, userId, listId int, items []todo.TodoItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(items))
+	for i, item := range items {
+		ids[i] = int64(item.Id)
+	}
+
+	var subtasks []todo.TodoItem
+	query := fmt.Sprintf("WITH RECURSIVE subtree AS ("+
+		"SELECT %[1]s FROM %[2]s ti WHERE ti.parent_item_id = ANY($1) AND ti.deleted_at IS NULL "+
+		"UNION SELECT %[1]s FROM %[2]s ti INNER JOIN subtree s ON ti.parent_item_id=s.id WHERE ti.deleted_at IS NULL) "+
+		"SELECT s.*, li.position FROM subtree s INNER JOIN %[3]s li ON li.item_id=s.id AND li.list_id=$2 "+
+		"ORDER BY li.position, s.id", itemColumns, todoItemsTable, listsItemsTable)
+	if err := db.Select(&subtasks, query, pq.Array(ids), listId); err != nil {
+		return err
+	}
+
+	if err := loadItemLabels(db, userId, subtasks); err != nil {
+		return err
+	}
+
+	children := make(map[int][]todo.TodoItem)
+	for _, subtask := range subtasks {
+		children[*subtask.ParentId] = append(children[*subtask.ParentId], subtask)
+	}
+
+	var attach func(items []todo.TodoItem)
+	attach = func(items []todo.TodoItem) {
+		for i := range items {
+			items[i].Subtasks = children[items[i].Id]
+			attach(items[i].Subtasks)
+		}
+	}
+	attach(items)
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func TestItem_CreateSubtask(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	testTable := []struct {
+		name         string
+		parentId     int
+		item         todo.TodoItem
+		mockBehavior func()
+		id           int
+		wantErr      error
+	}{
+		{
+			name:     "OK",
+			parentId: 3,
+			item:     todo.TodoItem{Title: "step", Priority: todo.PriorityNone},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+					WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"list_id"}).AddRow(1))
+				mock.ExpectQuery("INSERT INTO todo_items (.+) RETURNING id").
+					WithArgs("step", "", nil, nil, int64(0), 3, nil, nil).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+				mock.ExpectQuery("SELECT max\\(position\\) FROM lists_items WHERE list_id=(.+)").
+					WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+				mock.ExpectExec("INSERT INTO lists_items").
+					WithArgs(1, 4, "000001").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+) AND list_id<>(.+)").
+					WithArgs(3, 1).WillReturnRows(sqlmock.NewRows([]string{"list_id"}))
+				mock.ExpectExec("WITH RECURSIVE ancestors AS (.+) UPDATE todo_items SET done=false, completed_at=NULL").
+					WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityCreated, 4, nil)
+				mock.ExpectCommit()
+			},
+			id: 4,
+		},
+		{
+			name:     "Parent Not Found",
+			parentId: 3,
+			item:     todo.TodoItem{Title: "step"},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT list_id FROM lists_items WHERE item_id=(.+)").
+					WithArgs(3).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.CreateSubtask(1, testCase.parentId, testCase.item)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.id, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestItem_GetAllTree(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "title", "description", "done", "position"}).
+		AddRow(1, "root", "", false, "000001")
+	mock.ExpectQuery("SELECT (.+), li.position FROM (.+) WHERE li.list_id=(.+) AND ul.user_id=(.+) AND ti.parent_item_id IS NULL "+
+		"ORDER BY li.position ASC, ti.id ASC").
+		WithArgs(2, 1).WillReturnRows(rows)
+	expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
+
+	subtasks := sqlmock.NewRows([]string{"id", "title", "description", "done", "parent_item_id", "position"}).
+		AddRow(6, "another step", "", false, 1, "000002").
+		AddRow(3, "step", "", true, 1, "000003").
+		AddRow(5, "substep", "", true, 3, "000004")
+	mock.ExpectQuery("WITH RECURSIVE subtree AS \\(SELECT (.+) WHERE ti.parent_item_id = ANY(.+) UNION (.+)\\) "+
+		"SELECT s.\\*, li.position FROM subtree s INNER JOIN lists_items li ON (.+) ORDER BY li.position, s.id").
+		WithArgs(sqlmock.AnyArg(), 2).WillReturnRows(subtasks)
+	expectItemLabels(mock, 1, sqlmock.NewRows([]string{"item_id", "id", "name", "color"}))
+
+	got, _, err := r.GetAll(1, 2, todo.ItemsQuery{Tree: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []todo.TodoItem{
+		{Id: 1, Title: "root", Position: "000001", Subtasks: []todo.TodoItem{
+			{Id: 6, Title: "another step", ParentId: intPointer(1), Position: "000002"},
+			{Id: 3, Title: "step", Done: true, ParentId: intPointer(1), Position: "000003", Subtasks: []todo.TodoItem{
+				{Id: 5, Title: "substep", Done: true, ParentId: intPointer(3), Position: "000004"},
+			}},
+		}},
+	}, got)
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+	todo "todo-app"
+)
+
+var errTrashedParent = fmt.Errorf("%w: the parent item is in the trash, restore it first", todo.ErrConflict)
+
+type TrashPostgres struct {
+	db *sqlx.DB
+}
+
+func NewTrashPostgres(db *sqlx.DB) *TrashPostgres {
+	return &TrashPostgres{db: db}
+}
+
+// GetAll returns the user's trashed lists and the trashed items of the user's other
+// lists, latest first. Subtasks deleted together with their parent are left out.
+func (r *TrashPostgres) GetAll(userId int) ([]todo.TrashEntry, error) {
+	entries := make([]todo.TrashEntry, 0)
+	query := fmt.Sprintf(`SELECT '%s' AS type, tl.id, tl.id AS list_id, tl.title, tl.deleted_at
+								FROM %s tl INNER JOIN %s ul ON ul.list_id = tl.id
+								WHERE ul.user_id = $1 AND tl.deleted_at IS NOT NULL
+								UNION ALL
+								SELECT * FROM (SELECT DISTINCT ON (ti.id) '%s' AS type, ti.id, li.list_id, ti.title, ti.deleted_at
+									FROM %s ti INNER JOIN %s li ON li.item_id = ti.id
+										INNER JOIN %s ul ON ul.list_id = li.list_id INNER JOIN %s tl ON tl.id = li.list_id
+										LEFT JOIN %s p ON p.id = ti.parent_item_id
+									WHERE ul.user_id = $1 AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL
+										AND p.deleted_at IS DISTINCT FROM ti.deleted_at
+									ORDER BY ti.id, li.list_id) i
+								ORDER BY deleted_at DESC, type, id`,
+		todo.TrashEntryList, todoListsTable, usersListsTable,
+		todo.TrashEntryItem, todoItemsTable, listsItemsTable, usersListsTable, todoListsTable, todoItemsTable)
+	err := r.db.Select(&entries, query, userId)
+
+	return entries, err
+}
+
+func (r *TrashPostgres) RestoreList(userId, listId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET deleted_at=NULL WHERE id=$1 AND deleted_at IS NOT NULL", todoListsTable)
+	if err := requireAffected(tx.Exec(query, listId)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := recordActivity(tx, listId, userId, todo.ActivityRestored, todo.ActivityEntityList, listId, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreItem takes the item out of the trash along with the subtasks deleted
+// together with it. An item whose parent is still in the trash cannot be restored.
+func (r *TrashPostgres) RestoreItem(userId, itemId int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := restoreItem(tx, itemId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := recordItemActivity(tx, userId, todo.ActivityRestored, itemId, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func restoreItem(tx *sql.Tx, itemId int) error {
+	var trashedParent bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %[1]s ti INNER JOIN %[1]s p ON p.id=ti.parent_item_id "+
+		"WHERE ti.id=$1 AND p.deleted_at IS NOT NULL)", todoItemsTable)
+	if err := tx.QueryRow(query, itemId).Scan(&trashedParent); err != nil {
+		return err
+	}
+
+	if trashedParent {
+		return errTrashedParent
+	}
+
+	query = fmt.Sprintf("WITH RECURSIVE subtree AS (SELECT id, deleted_at FROM %[1]s WHERE id=$1 AND deleted_at IS NOT NULL "+
+		"UNION SELECT ti.id, ti.deleted_at FROM %[1]s ti INNER JOIN subtree s ON ti.parent_item_id=s.id AND ti.deleted_at=s.deleted_at) "+
+		"UPDATE %[1]s SET deleted_at=NULL WHERE id IN (SELECT id FROM subtree)", todoItemsTable)
+	return requireAffected(tx.Exec(query, itemId))
+}
+
+// Purge permanently removes the lists and items trashed before the given time, along
+// with the items left in no list by removing the lists, and returns how many were removed.
+func (r *TrashPostgres) Purge(before time.Time) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, table := range []string{todoItemsTable, todoListsTable} {
+		query := fmt.Sprintf("DELETE FROM %s WHERE deleted_at < $1", table)
+		result, err := tx.Exec(query, before)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		purged += rows
+	}
+
+	orphans, err := deleteOrphanItems(tx)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return purged + orphans, tx.Commit()
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	"time"
+	todo "todo-app"
+)
+
+func TestTrash_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestTrash_GetAll func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	deletedAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		want         []todo.TrashEntry
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"type", "id", "list_id", "title", "deleted_at"}).
+					AddRow("item", 7, 2, "Buy milk", deletedAt).
+					AddRow("list", 3, 3, "Groceries", deletedAt.Add(-time.Hour))
+				mock.ExpectQuery("SELECT 'list' AS type, (.+) FROM todo_lists tl (.+) WHERE ul.user_id = (.+) AND tl.deleted_at IS NOT NULL " +
+					"UNION ALL SELECT \\* FROM \\(SELECT DISTINCT ON \\(ti.id\\) 'item' AS type, (.+) " +
+					"AND p.deleted_at IS DISTINCT FROM ti.deleted_at (.+) ORDER BY deleted_at DESC, type, id").
+					WithArgs(1).WillReturnRows(rows)
+			},
+			want: []todo.TrashEntry{
+				{Type: "item", Id: 7, ListId: 2, Title: "Buy milk", DeletedAt: deletedAt},
+				{Type: "list", Id: 3, ListId: 3, Title: "Groceries", DeletedAt: deletedAt.Add(-time.Hour)},
+			},
+		},
+		{
+			name: "Empty",
+			mockBehavior: func() {
+				rows := sqlmock.NewRows([]string{"type", "id", "list_id", "title", "deleted_at"})
+				mock.ExpectQuery("SELECT (.+) UNION ALL SELECT (.+)").WithArgs(1).WillReturnRows(rows)
+			},
+			want: []todo.TrashEntry{},
+		},
+		{
+			name: "Failure",
+			mockBehavior: func() {
+				mock.ExpectQuery("SELECT (.+) UNION ALL SELECT (.+)").WithArgs(1).WillReturnError(errors.New("some error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.GetAll(1)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTrash_RestoreList(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestTrash_RestoreList func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE todo_lists SET deleted_at=NULL WHERE id=(.+) AND deleted_at IS NOT NULL").
+		WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectActivity(mock, 3, 1, todo.ActivityRestored, todo.ActivityEntityList, 3, nil)
+	mock.ExpectCommit()
+	assert.NoError(t, r.RestoreList(1, 3))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE todo_lists SET deleted_at=NULL (.+)").
+		WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	assert.ErrorIs(t, r.RestoreList(1, 3), todo.ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrash_RestoreItem(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestTrash_RestoreItem func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM todo_items ti INNER JOIN todo_items p ON (.+) " +
+					"WHERE ti.id=(.+) AND p.deleted_at IS NOT NULL\\)").
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("WITH RECURSIVE subtree AS \\((.+) ON ti.parent_item_id=s.id AND ti.deleted_at=s.deleted_at\\) " +
+					"UPDATE todo_items SET deleted_at=NULL WHERE id IN \\(SELECT id FROM subtree\\)").
+					WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 3))
+				expectItemActivity(mock, 1, todo.ActivityRestored, 7, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Trashed Parent",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS (.+)").
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrConflict,
+		},
+		{
+			name: "Not In Trash",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT EXISTS (.+)").
+					WithArgs(7).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec("WITH RECURSIVE subtree AS (.+) UPDATE todo_items SET deleted_at=NULL").
+					WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := r.RestoreItem(1, 7)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTrash_Purge(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestTrash_Purge func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTrashPostgres(db)
+
+	before := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		want         int64
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM todo_items WHERE deleted_at < (.+)").
+					WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec("DELETE FROM todo_lists WHERE deleted_at < (.+)").
+					WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM todo_items ti WHERE NOT EXISTS \\(SELECT 1 FROM lists_items li WHERE li.item_id=ti.id\\)").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+			want: 7,
+		},
+		{
+			name: "Failure",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM todo_items WHERE deleted_at < (.+)").
+					WithArgs(before).WillReturnError(errors.New("some error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			got, err := r.Purge(before)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	todo "todo-app"
+)
+
+// checkVersion explains a write guarded by the expected version that matched no row:
+// if the row is there under another version the client's copy is stale, else err stands.
+// The caller has checked access to the row, so its version gives nothing away.
+func checkVersion(tx *sql.Tx, table string, id int, version *int, err error) error {
+	if version == nil || !errors.Is(err, todo.ErrNotFound) {
+		return err
+	}
+
+	var current int
+	query := fmt.Sprintf("SELECT version FROM %s WHERE id=$1 AND deleted_at IS NULL", table)
+	if scanErr := tx.QueryRow(query, id).Scan(&current); scanErr != nil {
+		if errors.Is(scanErr, sql.ErrNoRows) {
+			return err
+		}
+		return scanErr
+	}
+
+	if current != *version {
+		return fmt.Errorf("%w: version is %d", todo.ErrPreconditionFailed, current)
+	}
+
+	return err
+}
+
+// bumpChangedItems wraps a data-modifying query that returns the item_id of the items it
+// changes into a statement that bumps the version of those items too. It is for what an
+// item's representation shows from outside its row, its labels and its comment count,
+// so that the item's ETag changes with them. The statement affects the bumped items.
+func bumpChangedItems(query string) string {
+	return fmt.Sprintf("WITH changed AS (%s) UPDATE %s SET version=version+1 WHERE id IN (SELECT item_id FROM changed)",
+		query, todoItemsTable)
+}
+
+// bumpListVersion bumps the version of the list for changes of what its representation
+// shows from users_lists, the caller's role and position, so that its ETag changes too.
+func bumpListVersion(tx *sql.Tx, listId int) error {
+	query := fmt.Sprintf("UPDATE %s SET version=version+1 WHERE id=$1", todoListsTable)
+	_, err := tx.Exec(query, listId)
+	return err
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	sqlmock "github.com/zhashkevych/go-sqlxmock"
+	"log"
+	"testing"
+	todo "todo-app"
+)
+
+func expectVersion(mock sqlmock.Sqlmock, table string, id int, version interface{}) {
+	query := mock.ExpectQuery("SELECT version FROM " + table + " WHERE id=(.+) AND deleted_at IS NULL").WithArgs(id)
+	if version == nil {
+		query.WillReturnError(sql.ErrNoRows)
+		return
+	}
+	query.WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(version))
+}
+
+func TestItem_Version(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestItem_Version func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoItemRepository(db)
+
+	testTable := []struct {
+		name         string
+		run          func() error
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name: "Update Matching Version",
+			run: func() error {
+				return r.Update(1, 2, todo.UpdateItemInput{Title: stringPointer("new title"), Version: intPointer(3)})
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET title=\\$1, version=ti.version\\+1 FROM (.+) AND ti.version=\\$4").
+					WithArgs("new title", 1, 2, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectItemActivity(mock, 1, todo.ActivityUpdated, 2, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Update Stale Version",
+			run: func() error {
+				return r.Update(1, 2, todo.UpdateItemInput{Title: stringPointer("new title"), Version: intPointer(3)})
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				expectLockItem(mock, 2, false)
+				mock.ExpectExec("UPDATE todo_items ti SET title=\\$1, version=ti.version\\+1 FROM (.+) AND ti.version=\\$4").
+					WithArgs("new title", 1, 2, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, "todo_items", 2, 4)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrPreconditionFailed,
+		},
+		{
+			name: "Delete Stale Version",
+			run: func() error {
+				return r.Delete(1, 2, intPointer(3))
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("WITH RECURSIVE subtree AS \\((.+) AND \\(\\$3::int IS NULL OR ti.version=\\$3\\) (.+)\\) UPDATE todo_items").
+					WithArgs(1, 2, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, "todo_items", 2, 4)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrPreconditionFailed,
+		},
+		{
+			name: "Delete Missing Item",
+			run: func() error {
+				return r.Delete(1, 2, intPointer(3))
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("WITH RECURSIVE subtree AS (.+) UPDATE todo_items").
+					WithArgs(1, 2, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, "todo_items", 2, nil)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := testCase.run()
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestList_Version(t *testing.T) {
+	db, mock, err := sqlmock.Newx()
+	if err != nil {
+		log.Fatalf("error on testing TestList_Version func: %v", err)
+	}
+	defer db.Close()
+
+	r := NewTodoListPostgres(db)
+
+	testTable := []struct {
+		name         string
+		run          func() error
+		mockBehavior func()
+		wantErr      error
+	}{
+		{
+			name: "Update Stale Version",
+			run: func() error {
+				return r.Update(1, 2, todo.UpdateListInput{Title: stringPointer("new title"), Version: intPointer(3)})
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT title, description FROM todo_lists WHERE id=(.+) FOR UPDATE").
+					WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"title", "description"}).AddRow("title", "description"))
+				mock.ExpectExec("UPDATE todo_lists tl SET title=\\$1, version=tl.version\\+1 FROM (.+) AND tl.version=\\$4").
+					WithArgs("new title", 2, 1, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+				expectVersion(mock, "todo_lists", 2, 5)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrPreconditionFailed,
+		},
+		{
+			name: "Delete Matching Version",
+			run: func() error {
+				return r.Delete(1, 2, intPointer(3))
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE todo_lists tl SET deleted_at=now\\(\\) (.+) AND \\(\\$3::int IS NULL OR tl.version=\\$3\\) (.+)").
+					WithArgs(1, 2, 3).WillReturnRows(sqlmock.NewRows([]string{"title", "description"}).AddRow("title", "description"))
+				expectActivity(mock, 2, 1, todo.ActivityDeleted, todo.ActivityEntityList, 2, nil)
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Delete Stale Version",
+			run: func() error {
+				return r.Delete(1, 2, intPointer(3))
+			},
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery("UPDATE todo_lists tl SET deleted_at=now\\(\\) (.+)").
+					WithArgs(1, 2, 3).WillReturnError(sql.ErrNoRows)
+				expectVersion(mock, "todo_lists", 2, 5)
+				mock.ExpectRollback()
+			},
+			wantErr: todo.ErrPreconditionFailed,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			err := testCase.run()
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectBumpList expects the version of the list to be bumped.
+func expectBumpList(mock sqlmock.Sqlmock, listId int) {
+	mock.ExpectExec("UPDATE todo_lists SET version=version\\+1 WHERE id=(.+)").WithArgs(listId).WillReturnResult(sqlmock.NewResult(0, 1))
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	todo "todo-app"
+	"todo-app/pkg/repository"
+)
+
+type ActivityService struct {
+	repo        repository.Activity
+	membersRepo repository.ListMember
+}
+
+func NewActivityService(repo repository.Activity, membersRepo repository.ListMember) *ActivityService {
+	return &ActivityService{repo: repo, membersRepo: membersRepo}
+}
+
+func (s *ActivityService) GetAll(userId, listId int, input todo.ActivityQuery) ([]todo.Activity, string, error) {
+	if err := input.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleViewer); err != nil {
+		return nil, "", err
+	}
+
+	return s.repo.GetAll(listId, input)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	todo "todo-app"
+	"todo-app/pkg/repository"
+	"todo-app/pkg/storage"
+)
+
+const (
+	DefaultAttachmentMaxSize = 25 << 20
+	DefaultAttachmentQuota   = 1 << 30
+
+	// sniffLength is how much of the content http.DetectContentType looks at.
+	sniffLength       = 512
+	detachedBatchSize = 100
+	storageKeySize    = 24
+)
+
+// AttachmentConfig limits the size of a single file and the total size of the files
+// each user uploads.
+type AttachmentConfig struct {
+	MaxSize int64
+	Quota   int64
+}
+
+type AttachmentService struct {
+	repo        repository.Attachment
+	membersRepo repository.ListMember
+	blobs       storage.BlobStore
+	maxSize     int64
+	quota       int64
+}
+
+func NewAttachmentService(repo repository.Attachment, membersRepo repository.ListMember, blobs storage.BlobStore,
+	cfg AttachmentConfig) *AttachmentService {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = DefaultAttachmentMaxSize
+	}
+	if cfg.Quota == 0 {
+		cfg.Quota = DefaultAttachmentQuota
+	}
+
+	return &AttachmentService{repo: repo, membersRepo: membersRepo, blobs: blobs, maxSize: cfg.MaxSize, quota: cfg.Quota}
+}
+
+// Upload stores the file and attaches it to the item. The content type is sniffed from
+// the content instead of trusting the client, and the checksum is taken while storing.
+func (s *AttachmentService) Upload(userId, itemId int, filename string, size int64, r io.Reader) (int, error) {
+	name, err := todo.AttachmentName(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	if size <= 0 {
+		return 0, fmt.Errorf("%w: file must not be empty", todo.ErrValidation)
+	}
+
+	if size > s.maxSize {
+		return 0, fmt.Errorf("%w: files must be at most %d bytes", todo.ErrTooLarge, s.maxSize)
+	}
+
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleEditor); err != nil {
+		return 0, err
+	}
+
+	// checked again when the attachment is created, this spares storing files that are
+	// clearly over the quota
+	usage, err := s.repo.GetUsage(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if usage+size > s.quota {
+		return 0, fmt.Errorf("%w: storage quota of %d bytes exceeded", todo.ErrTooLarge, s.quota)
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+	head = head[:n]
+
+	key, err := randomString(storageKeySize)
+	if err != nil {
+		return 0, err
+	}
+
+	hash := sha256.New()
+	content := io.TeeReader(io.MultiReader(bytes.NewReader(head), r), hash)
+	contentType := http.DetectContentType(head)
+	if err := s.blobs.Put(key, content, size, contentType); err != nil {
+		return 0, err
+	}
+
+	id, err := s.repo.Create(userId, todo.Attachment{
+		ItemId:      itemId,
+		UserId:      userId,
+		Filename:    name,
+		Size:        size,
+		ContentType: contentType,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+	}, s.quota)
+	if err != nil {
+		s.blobs.Delete(key)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *AttachmentService) GetAll(userId, itemId int) ([]todo.Attachment, error) {
+	return s.repo.GetAll(userId, itemId)
+}
+
+// Get returns the attachment and its content, which the caller has to close.
+func (s *AttachmentService) Get(userId, attachmentId int) (todo.Attachment, io.ReadCloser, error) {
+	attachment, err := s.repo.GetById(userId, attachmentId)
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	content, err := s.blobs.Get(attachment.StorageKey)
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// Delete lets editors remove attachments. The attachment is detached first, so a blob
+// that cannot be removed right away is retried by DeleteDetached.
+func (s *AttachmentService) Delete(userId, attachmentId int) error {
+	attachment, err := s.repo.GetById(userId, attachmentId)
+	if err != nil {
+		return err
+	}
+
+	if err := requireItemRole(s.membersRepo, userId, attachment.ItemId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	if err := s.repo.Detach(attachmentId); err != nil {
+		return err
+	}
+
+	return s.remove(attachment)
+}
+
+// DeleteDetached removes the blobs and the metadata of the attachments that belong to
+// no item anymore, which is what removing items from the trash leaves behind.
+func (s *AttachmentService) DeleteDetached() (int, error) {
+	removed := 0
+	for {
+		attachments, err := s.repo.GetDetached(detachedBatchSize)
+		if err != nil {
+			return removed, err
+		}
+
+		for _, attachment := range attachments {
+			if err := s.remove(attachment); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+
+		if len(attachments) < detachedBatchSize {
+			return removed, nil
+		}
+	}
+}
+
+func (s *AttachmentService) remove(attachment todo.Attachment) error {
+	if err := s.blobs.Delete(attachment.StorageKey); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(attachment.Id)
+}
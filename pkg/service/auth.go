@@ -1,77 +1,342 @@
 package service
 
 import (
-	"crypto/sha1"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"strconv"
 	"time"
 	todo "todo-app"
 	"todo-app/pkg/repository"
 )
 
 const (
-	salt       = "lkdjflji387joidjk"
-	signingKey = "kjlsdj#%9(*&%lkjkdo"
-	tokenTTL   = 12 * time.Hour
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultTokenIssuer     = "todo-app"
+	defaultTokenAudience   = "todo-app"
 )
 
+type TokenConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	Issuer     string
+	Audience   string
+	Leeway     time.Duration
+	Keys       []KeyConfig
+}
+
+// TokenClaims identify the user by the standard "sub" claim; "sid" is the refresh token family
+// the access token was issued for.
 type TokenClaims struct {
-	jwt.StandardClaims
-	UserId int `json:"user_id"`
+	jwt.RegisteredClaims
+	SessionId string   `json:"sid"`
+	Scopes    []string `json:"scopes,omitempty"`
 }
 
+var (
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotValidYet = errors.New("token is not valid yet")
+	ErrTokenAudience    = errors.New("token has invalid audience")
+	ErrTokenIssuer      = errors.New("token has invalid issuer")
+	ErrTokenInvalid     = errors.New("invalid token")
+	ErrSessionRevoked   = errors.New("session has been revoked")
+
+	ErrIncorrectCredentials = errors.New("incorrect login or password")
+)
+
+var (
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+)
+
 type AuthService struct {
-	repo repository.Authorization
+	repo          repository.Authorization
+	refreshTokens repository.RefreshToken
+	hasher        PasswordHasher
+	keys          *KeyRing
+	parser        *jwt.Parser
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+	issuer        string
+	audience      string
 }
 
-func NewAuthService(repo repository.Authorization) *AuthService {
-	return &AuthService{repo: repo}
+func NewAuthService(repo repository.Authorization, refreshTokens repository.RefreshToken, hasher PasswordHasher,
+	keys *KeyRing, cfg TokenConfig) *AuthService {
+	if cfg.AccessTTL == 0 {
+		cfg.AccessTTL = defaultAccessTokenTTL
+	}
+	if cfg.RefreshTTL == 0 {
+		cfg.RefreshTTL = defaultRefreshTokenTTL
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = defaultTokenIssuer
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = defaultTokenAudience
+	}
+
+	return &AuthService{
+		repo:          repo,
+		refreshTokens: refreshTokens,
+		hasher:        hasher,
+		keys:          keys,
+		parser: jwt.NewParser(
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithLeeway(cfg.Leeway),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+	}
 }
 
 func (s *AuthService) CreateUser(user todo.User) (int, error) {
-	user.Password = generatePasswordHash(user.Password)
+	if user.TimeZone == "" {
+		user.TimeZone = "UTC"
+	}
+	if _, err := time.LoadLocation(user.TimeZone); err != nil {
+		return 0, fmt.Errorf("%w: unknown time zone %q", todo.ErrValidation, user.TimeZone)
+	}
+
+	hash, err := s.hasher.Hash(user.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	user.Password = hash
 	return s.repo.CreateUser(user)
 }
 
-func (s *AuthService) GenerateToken(username, password string) (string, error) {
-	user, err := s.repo.GetUser(username, generatePasswordHash(password))
+// GenerateToken signs the user in and starts a new session, i.e. a new refresh token family.
+func (s *AuthService) GenerateToken(username, password string) (todo.Tokens, error) {
+	user, err := s.authenticate(username, password)
 	if err != nil {
-		return "", err
+		return todo.Tokens{}, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(12 * time.Hour).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-		user.Id})
+	familyId, err := randomString(16)
+	if err != nil {
+		return todo.Tokens{}, err
+	}
+
+	refreshToken, record, err := newRefreshToken(user.Id, familyId, s.refreshTTL)
+	if err != nil {
+		return todo.Tokens{}, err
+	}
+
+	if err := s.refreshTokens.Create(record); err != nil {
+		return todo.Tokens{}, err
+	}
 
-	return token.SignedString([]byte(signingKey))
+	return s.issueTokens(user.Id, familyId, refreshToken)
 }
 
-func (s *AuthService) ParseToken(accessToken string) (int, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method")
+// RefreshToken exchanges a refresh token for a new token pair. Every refresh token can be used
+// only once: presenting an already rotated token revokes the whole family, since either the
+// client or an attacker holds a stolen copy.
+func (s *AuthService) RefreshToken(refreshToken string) (todo.Tokens, error) {
+	stored, err := s.refreshTokens.GetByHash(hashRefreshToken(refreshToken))
+	if err != nil {
+		return todo.Tokens{}, errInvalidRefreshToken
+	}
+
+	if stored.UsedAt != nil && stored.RevokedAt == nil {
+		return todo.Tokens{}, s.revokeReusedFamily(stored.FamilyId)
+	}
+
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return todo.Tokens{}, errInvalidRefreshToken
+	}
+
+	next, record, err := newRefreshToken(stored.UserId, stored.FamilyId, s.refreshTTL)
+	if err != nil {
+		return todo.Tokens{}, err
+	}
+
+	if err := s.refreshTokens.Rotate(stored.Id, record); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenUsed) {
+			return todo.Tokens{}, s.revokeReusedFamily(stored.FamilyId)
 		}
-		return []byte(signingKey), nil
-	})
+		return todo.Tokens{}, err
+	}
+
+	return s.issueTokens(stored.UserId, stored.FamilyId, next)
+}
+
+func (s *AuthService) Logout(refreshToken string) error {
+	stored, err := s.refreshTokens.GetByHash(hashRefreshToken(refreshToken))
 	if err != nil {
-		return 0, err
+		return errInvalidRefreshToken
+	}
+
+	return s.refreshTokens.RevokeFamily(stored.FamilyId)
+}
+
+func (s *AuthService) LogoutAll(userId int) error {
+	return s.refreshTokens.RevokeAll(userId)
+}
+
+// ParseToken validates the signature and the standard claims of an access token. Validation
+// failures are reported as one of the ErrToken* errors.
+func (s *AuthService) ParseToken(accessToken string) (todo.Principal, error) {
+	token, err := s.parser.ParseWithClaims(accessToken, &TokenClaims{}, s.keys.Keyfunc)
+	if err != nil {
+		return todo.Principal{}, tokenError(err)
 	}
 
 	claims, ok := token.Claims.(*TokenClaims)
 	if !ok {
-		return 0, errors.New("token claims are not of type *TokenClaims")
+		return todo.Principal{}, ErrTokenInvalid
 	}
 
-	return claims.UserId, nil
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil || userId <= 0 || claims.ID == "" {
+		return todo.Principal{}, ErrTokenInvalid
+	}
+
+	if claims.SessionId == "" {
+		return todo.Principal{}, ErrSessionRevoked
+	}
+
+	active, err := s.refreshTokens.IsFamilyActive(claims.SessionId)
+	if err != nil {
+		return todo.Principal{}, err
+	}
+	if !active {
+		return todo.Principal{}, ErrSessionRevoked
+	}
+
+	return todo.Principal{
+		UserId:    userId,
+		TokenId:   claims.ID,
+		SessionId: claims.SessionId,
+		Scopes:    claims.Scopes,
+	}, nil
+}
+
+func (s *AuthService) JWKS() todo.JSONWebKeySet {
+	return s.keys.JWKS()
+}
+
+func (s *AuthService) issueTokens(userId int, sessionId, refreshToken string) (todo.Tokens, error) {
+	tokenId, err := randomString(16)
+	if err != nil {
+		return todo.Tokens{}, err
+	}
+
+	now := time.Now()
+	accessToken, err := s.keys.Sign(&TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenId,
+			Issuer:    s.issuer,
+			Subject:   strconv.Itoa(userId),
+			Audience:  jwt.ClaimStrings{s.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		SessionId: sessionId,
+	})
+	if err != nil {
+		return todo.Tokens{}, err
+	}
+
+	return todo.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
-func generatePasswordHash(password string) string {
-	hash := sha1.New()
-	hash.Write([]byte(password))
+func (s *AuthService) revokeReusedFamily(familyId string) error {
+	if err := s.refreshTokens.RevokeFamily(familyId); err != nil {
+		return err
+	}
+
+	logrus.Warnf("refresh token reuse detected, revoked session %s", familyId)
+	return errRefreshTokenReused
+}
+
+// authenticate verifies the password in Go and transparently upgrades hashes
+// produced by an outdated algorithm or with outdated cost parameters.
+func (s *AuthService) authenticate(username, password string) (todo.User, error) {
+	user, err := s.repo.GetUser(username)
+	if errors.Is(err, todo.ErrNotFound) {
+		return todo.User{}, ErrIncorrectCredentials
+	}
+	if err != nil {
+		return todo.User{}, err
+	}
+
+	ok, err := s.hasher.Verify(password, user.Password)
+	if err != nil {
+		return todo.User{}, err
+	}
+	if !ok {
+		return todo.User{}, ErrIncorrectCredentials
+	}
+
+	if s.hasher.NeedsRehash(user.Password) {
+		hash, err := s.hasher.Hash(password)
+		if err == nil {
+			err = s.repo.UpdatePasswordHash(user.Id, hash)
+		}
+		if err != nil {
+			logrus.Errorf("failed to rehash password of user %d: %s", user.Id, err.Error())
+		}
+	}
+
+	return user, nil
+}
+
+func tokenError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return ErrTokenNotValidYet
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return ErrTokenAudience
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return ErrTokenIssuer
+	default:
+		return ErrTokenInvalid
+	}
+}
+
+// newRefreshToken returns an opaque token for the client and the record to store.
+// Only a SHA-256 digest of the token ever reaches the database.
+func newRefreshToken(userId int, familyId string, ttl time.Duration) (string, todo.RefreshToken, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", todo.RefreshToken{}, err
+	}
+
+	return token, todo.RefreshToken{
+		UserId:    userId,
+		FamilyId:  familyId,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
 
-	return fmt.Sprintf("%x", hash.Sum([]byte(salt)))
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	todo "todo-app"
+)
+
+type refreshTokenRepositoryStub struct {
+	activeFamilies map[string]bool
+}
+
+func (r *refreshTokenRepositoryStub) Create(token todo.RefreshToken) error {
+	return nil
+}
+
+func (r *refreshTokenRepositoryStub) GetByHash(tokenHash string) (todo.RefreshToken, error) {
+	return todo.RefreshToken{}, nil
+}
+
+func (r *refreshTokenRepositoryStub) Rotate(usedId int, next todo.RefreshToken) error {
+	return nil
+}
+
+func (r *refreshTokenRepositoryStub) IsFamilyActive(familyId string) (bool, error) {
+	return r.activeFamilies[familyId], nil
+}
+
+func (r *refreshTokenRepositoryStub) RevokeFamily(familyId string) error {
+	return nil
+}
+
+func (r *refreshTokenRepositoryStub) RevokeAll(userId int) error {
+	return nil
+}
+
+func TestAuthService_ParseToken(t *testing.T) {
+	t.Setenv("TEST_JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	ring, err := NewKeyRing([]KeyConfig{
+		{Kid: "hmac", Algorithm: "HS256", Status: KeyStatusActive, SecretEnv: "TEST_JWT_SECRET"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewAuthService(nil, &refreshTokenRepositoryStub{activeFamilies: map[string]bool{"session": true}}, nil, ring,
+		TokenConfig{Issuer: "todo-app", Audience: "todo-app", Leeway: 5 * time.Second})
+
+	now := time.Now()
+	claims := func(modify func(c *TokenClaims)) *TokenClaims {
+		c := &TokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "jti",
+				Issuer:    "todo-app",
+				Subject:   "7",
+				Audience:  jwt.ClaimStrings{"todo-app"},
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+				NotBefore: jwt.NewNumericDate(now),
+				IssuedAt:  jwt.NewNumericDate(now),
+			},
+			SessionId: "session",
+		}
+		modify(c)
+		return c
+	}
+
+	testTable := []struct {
+		name    string
+		claims  *TokenClaims
+		want    todo.Principal
+		wantErr error
+	}{
+		{
+			name:   "OK",
+			claims: claims(func(c *TokenClaims) {}),
+			want:   todo.Principal{UserId: 7, TokenId: "jti", SessionId: "session"},
+		},
+		{
+			name: "Expired",
+			claims: claims(func(c *TokenClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			}),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name: "Expired Within Leeway",
+			claims: claims(func(c *TokenClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * time.Second))
+			}),
+			want: todo.Principal{UserId: 7, TokenId: "jti", SessionId: "session"},
+		},
+		{
+			name: "Not Valid Yet",
+			claims: claims(func(c *TokenClaims) {
+				c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+			}),
+			wantErr: ErrTokenNotValidYet,
+		},
+		{
+			name: "Wrong Audience",
+			claims: claims(func(c *TokenClaims) {
+				c.Audience = jwt.ClaimStrings{"billing"}
+			}),
+			wantErr: ErrTokenAudience,
+		},
+		{
+			name: "Wrong Issuer",
+			claims: claims(func(c *TokenClaims) {
+				c.Issuer = "someone-else"
+			}),
+			wantErr: ErrTokenIssuer,
+		},
+		{
+			name: "Missing Expiration",
+			claims: claims(func(c *TokenClaims) {
+				c.ExpiresAt = nil
+			}),
+			wantErr: ErrTokenInvalid,
+		},
+		{
+			name: "Missing Token Id",
+			claims: claims(func(c *TokenClaims) {
+				c.ID = ""
+			}),
+			wantErr: ErrTokenInvalid,
+		},
+		{
+			name: "Revoked Session",
+			claims: claims(func(c *TokenClaims) {
+				c.SessionId = "revoked"
+			}),
+			wantErr: ErrSessionRevoked,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			token, err := ring.Sign(testCase.claims)
+			assert.NoError(t, err)
+
+			got, err := s.ParseToken(token)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthService_issueTokens(t *testing.T) {
+	t.Setenv("TEST_JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	ring, err := NewKeyRing([]KeyConfig{
+		{Kid: "hmac", Algorithm: "HS256", Status: KeyStatusActive, SecretEnv: "TEST_JWT_SECRET"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewAuthService(nil, &refreshTokenRepositoryStub{activeFamilies: map[string]bool{"session": true}}, nil, ring,
+		TokenConfig{})
+
+	first, err := s.issueTokens(7, "session", "refresh")
+	assert.NoError(t, err)
+	second, err := s.issueTokens(7, "session", "refresh")
+	assert.NoError(t, err)
+
+	firstPrincipal, err := s.ParseToken(first.AccessToken)
+	assert.NoError(t, err)
+	secondPrincipal, err := s.ParseToken(second.AccessToken)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 7, firstPrincipal.UserId)
+	assert.NotEqual(t, firstPrincipal.TokenId, secondPrincipal.TokenId)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	todo "todo-app"
+	"todo-app/pkg/repository"
+)
+
+type CommentService struct {
+	repo        repository.Comment
+	membersRepo repository.ListMember
+}
+
+func NewCommentService(repo repository.Comment, membersRepo repository.ListMember) *CommentService {
+	return &CommentService{repo: repo, membersRepo: membersRepo}
+}
+
+// Create lets every member of a list the item belongs to comment on it.
+func (s *CommentService) Create(userId, itemId int, input todo.CommentInput) (int, error) {
+	if err := input.Validate(); err != nil {
+		return 0, err
+	}
+
+	return s.repo.Create(userId, itemId, input)
+}
+
+func (s *CommentService) GetAll(userId, itemId int) ([]todo.Comment, error) {
+	return s.repo.GetAll(userId, itemId)
+}
+
+// Update lets only the author edit a comment.
+func (s *CommentService) Update(userId, commentId int, input todo.CommentInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	comment, err := s.repo.GetById(userId, commentId)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserId != userId {
+		return todo.ErrForbidden
+	}
+
+	return s.repo.Update(commentId, input)
+}
+
+// Delete lets the author remove a comment and the owners of the item's lists moderate it.
+func (s *CommentService) Delete(userId, commentId int) error {
+	comment, err := s.repo.GetById(userId, commentId)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserId != userId {
+		if err := requireItemRole(s.membersRepo, userId, comment.ItemId, todo.RoleOwner); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Delete(commentId)
+}
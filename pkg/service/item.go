@@ -1,40 +1,415 @@
 package service
 
 import (
+	"fmt"
+	"time"
 	todo "todo-app"
 	"todo-app/pkg/repository"
 )
 
 type TodoItemService struct {
-	repo     repository.TodoItem
-	listRepo repository.TodoList
+	repo        repository.TodoItem
+	membersRepo repository.ListMember
+	usersRepo   repository.Authorization
 }
 
-func NewTodoItemService(repo repository.TodoItem, listRepo repository.TodoList) *TodoItemService {
-	return &TodoItemService{repo: repo, listRepo: listRepo}
+func NewTodoItemService(repo repository.TodoItem, membersRepo repository.ListMember,
+	usersRepo repository.Authorization) *TodoItemService {
+	return &TodoItemService{repo: repo, membersRepo: membersRepo, usersRepo: usersRepo}
 }
 
 func (s *TodoItemService) CreateItem(userId, listId int, item todo.TodoItem) (int, error) {
-	_, err := s.listRepo.GetById(userId, listId)
-	if err != nil {
+	if err := prepareItem(&item); err != nil {
+		return 0, err
+	}
+
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleEditor); err != nil {
 		return 0, err
 	}
 
-	return s.repo.CreateItem(listId, item)
+	return s.repo.CreateItem(userId, listId, item)
 }
 
-func (s *TodoItemService) GetAll(userId, listId int) ([]todo.TodoItem, error) {
-	return s.repo.GetAll(userId, listId)
+// CreateSubtask adds the item under the parent item, in the parent's list.
+func (s *TodoItemService) CreateSubtask(userId, parentId int, item todo.TodoItem) (int, error) {
+	if err := prepareItem(&item); err != nil {
+		return 0, err
+	}
+
+	if err := requireItemRole(s.membersRepo, userId, parentId, todo.RoleEditor); err != nil {
+		return 0, err
+	}
+
+	return s.repo.CreateSubtask(userId, parentId, item)
+}
+
+func prepareItem(item *todo.TodoItem) error {
+	if item.Priority == "" {
+		item.Priority = todo.PriorityNone
+	}
+	if err := item.Priority.Validate(); err != nil {
+		return err
+	}
+
+	recurrence, err := normalizeRecurrence(item.Recurrence)
+	if err != nil {
+		return err
+	}
+	item.Recurrence = recurrence
+
+	return nil
+}
+
+// normalizeRecurrence stores every rule in the same canonical form.
+func normalizeRecurrence(recurrence todo.Recurrence) (todo.Recurrence, error) {
+	if recurrence == "" {
+		return "", nil
+	}
+
+	rule, err := recurrence.Rule()
+	if err != nil {
+		return "", err
+	}
+
+	return todo.Recurrence(rule.String()), nil
+}
+
+func (s *TodoItemService) GetAll(userId, listId int, input todo.ItemsQuery) ([]todo.TodoItem, string, error) {
+	if err := input.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleViewer); err != nil {
+		return nil, "", err
+	}
+
+	return s.repo.GetAll(userId, listId, input)
 }
 
 func (s *TodoItemService) GetById(userId, itemId int) (todo.TodoItem, error) {
 	return s.repo.GetById(userId, itemId)
 }
 
+func (s *TodoItemService) GetSubtasks(userId, itemId int) ([]todo.TodoItem, error) {
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetSubtasks(userId, itemId)
+}
+
+// GetAssigned returns the user's open items across all their lists that are assigned to them.
+func (s *TodoItemService) GetAssigned(userId int) ([]todo.TodoItem, error) {
+	return s.repo.GetAssigned(userId)
+}
+
+// GetDue returns the user's open items in the due window: overdue, due today or due this week.
+func (s *TodoItemService) GetDue(userId int, window string, input todo.DueQuery) ([]todo.TodoItem, error) {
+	loc, err := time.LoadLocation(input.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", todo.ErrValidation, input.TimeZone)
+	}
+
+	from, to, err := dueRange(window, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetDue(userId, from, to)
+}
+
+// Update applies the input to the item. Marking a recurring item done adds its next
+// occurrence to the list, due by the rule in the user's time zone.
 func (s *TodoItemService) Update(userId, itemId int, input todo.UpdateItemInput) error {
-	return s.repo.Update(userId, itemId, input)
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if input.Recurrence != nil {
+		recurrence, err := normalizeRecurrence(*input.Recurrence)
+		if err != nil {
+			return err
+		}
+		input.Recurrence = &recurrence
+	}
+
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	if input.Done == nil || !*input.Done {
+		return s.repo.Update(userId, itemId, input)
+	}
+
+	item, err := s.repo.GetById(userId, itemId)
+	if err != nil {
+		return err
+	}
+
+	if item.Done {
+		return s.repo.Update(userId, itemId, input)
+	}
+
+	timeZone, err := s.usersRepo.GetTimeZone(userId)
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return err
+	}
+
+	next, ok, err := nextOccurrence(applyItemUpdate(item, input), time.Now().In(loc))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return s.repo.Update(userId, itemId, input)
+	}
+
+	return s.repo.CompleteOccurrence(userId, itemId, input, next)
+}
+
+func (s *TodoItemService) Move(userId, itemId int, input todo.MoveInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.requireSourceRole(userId, itemId, input.ListId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	return s.repo.Move(userId, itemId, input)
+}
+
+// GetLists returns the lists of the user the item belongs to.
+func (s *TodoItemService) GetLists(userId, itemId int) ([]todo.TodoList, error) {
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetLists(userId, itemId)
+}
+
+// Transfer moves the item to another list. The user must be an editor of both lists.
+func (s *TodoItemService) Transfer(userId, itemId int, input todo.TransferItemInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.requireSourceRole(userId, itemId, input.FromListId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	if err := requireListRole(s.membersRepo, userId, input.ToListId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	return s.repo.Transfer(userId, itemId, input)
 }
 
-func (s *TodoItemService) Delete(userId, itemId int) error {
-	return s.repo.Delete(userId, itemId)
+// Copy copies the item into a list the user is an editor of.
+func (s *TodoItemService) Copy(userId, itemId int, input todo.CopyItemInput) (int, error) {
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleViewer); err != nil {
+		return 0, err
+	}
+
+	if err := requireListRole(s.membersRepo, userId, input.ListId, todo.RoleEditor); err != nil {
+		return 0, err
+	}
+
+	return s.repo.Copy(userId, itemId, input)
+}
+
+// Link adds the item to another list, which shares it with the members of that list.
+func (s *TodoItemService) Link(userId, itemId, listId int) error {
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	return s.repo.Link(userId, itemId, listId)
+}
+
+func (s *TodoItemService) Unlink(userId, itemId, listId int) error {
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	return s.repo.Unlink(userId, itemId, listId)
+}
+
+// requireSourceRole checks the role of the user on the list an item is moved in or
+// out of, or on the item when the list is left to the repository to pick.
+func (s *TodoItemService) requireSourceRole(userId, itemId, listId int, minRole string) error {
+	if listId == 0 {
+		return requireItemRole(s.membersRepo, userId, itemId, minRole)
+	}
+
+	return requireListRole(s.membersRepo, userId, listId, minRole)
+}
+
+func (s *TodoItemService) Delete(userId, itemId int, version *int) error {
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(userId, itemId, version)
+}
+
+// Batch runs the operations on the items of the list in one transaction. Every operation
+// is checked before the batch starts; completing recurring items schedules their next
+// occurrence in the user's time zone, as Update does.
+func (s *TodoItemService) Batch(userId, listId int, input todo.BatchInput) ([]todo.BatchResult, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	operations := make([]todo.BatchOperation, len(input.Operations))
+	for index, op := range input.Operations {
+		if err := prepareBatchOperation(&op); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", index, err)
+		}
+		operations[index] = op
+	}
+	input.Operations = operations
+
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	timeZone, err := s.usersRepo.GetTimeZone(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(loc)
+	return s.repo.Batch(userId, listId, input, func(item todo.TodoItem) (todo.TodoItem, bool, error) {
+		return nextOccurrence(item, now)
+	})
+}
+
+// prepareBatchOperation copies the item or the update of the operation in the form
+// CreateItem and Update store them.
+func prepareBatchOperation(op *todo.BatchOperation) error {
+	if op.Item != nil {
+		item := *op.Item
+		if err := prepareItem(&item); err != nil {
+			return err
+		}
+		op.Item = &item
+	}
+
+	if op.Update != nil && op.Update.Recurrence != nil {
+		update := *op.Update
+		recurrence, err := normalizeRecurrence(*update.Recurrence)
+		if err != nil {
+			return err
+		}
+		update.Recurrence = &recurrence
+		op.Update = &update
+	}
+
+	return nil
+}
+
+// applyItemUpdate returns the item as the input leaves it, apart from Done.
+func applyItemUpdate(item todo.TodoItem, input todo.UpdateItemInput) todo.TodoItem {
+	if input.Title != nil {
+		item.Title = *input.Title
+	}
+	if input.Description != nil {
+		item.Description = *input.Description
+	}
+	if input.DueAt.Set {
+		item.DueAt = input.DueAt.Time
+	}
+	if input.RemindAt.Set {
+		item.RemindAt = input.RemindAt.Time
+	}
+	if input.Priority != nil {
+		item.Priority = *input.Priority
+	}
+	if input.ParentId.Set {
+		item.ParentId = input.ParentId.Value
+	}
+	if input.Recurrence != nil {
+		item.Recurrence = *input.Recurrence
+	}
+	if input.AssigneeId.Set {
+		item.AssigneeId = input.AssigneeId.Value
+	}
+
+	return item
+}
+
+// nextOccurrence returns the occurrence of a recurring item that follows its due date,
+// or now, in now's location, for items without a due date. ok is false for items that
+// do not repeat or whose schedule has ended.
+func nextOccurrence(item todo.TodoItem, now time.Time) (todo.TodoItem, bool, error) {
+	if item.Recurrence == "" {
+		return todo.TodoItem{}, false, nil
+	}
+
+	rule, err := item.Recurrence.Rule()
+	if err != nil {
+		return todo.TodoItem{}, false, err
+	}
+
+	after := now
+	if item.DueAt != nil {
+		after = item.DueAt.In(now.Location())
+	}
+
+	dueAt, rest, ok := rule.Next(after)
+	if !ok {
+		return todo.TodoItem{}, false, nil
+	}
+
+	next := todo.TodoItem{
+		Title:       item.Title,
+		Description: item.Description,
+		DueAt:       &dueAt,
+		Priority:    item.Priority,
+		ParentId:    item.ParentId,
+		AssigneeId:  item.AssigneeId,
+		Recurrence:  todo.Recurrence(rest.String()),
+	}
+
+	// the reminder keeps its distance to the due date
+	if item.DueAt != nil && item.RemindAt != nil {
+		remindAt := dueAt.Add(item.RemindAt.Sub(*item.DueAt))
+		next.RemindAt = &remindAt
+	}
+
+	return next, true, nil
+}
+
+// dueRange returns the [from, to) due date range of the window relative to now.
+// Days and weeks start at midnight in now's location, weeks on Monday.
+func dueRange(window string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch window {
+	case todo.DueOverdue:
+		return time.Time{}, now, nil
+	case todo.DueToday:
+		return today, today.AddDate(0, 0, 1), nil
+	case todo.DueThisWeek:
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		return monday, monday.AddDate(0, 0, 7), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: due window must be one of %s, %s, %s",
+		todo.ErrValidation, todo.DueOverdue, todo.DueToday, todo.DueThisWeek)
 }
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+	todo "todo-app"
+)
+
+func TestDueRange(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Monday 01:00 in Moscow is still Sunday in UTC.
+	now := time.Date(2023, 5, 1, 1, 0, 0, 0, moscow)
+
+	testTable := []struct {
+		name     string
+		window   string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{
+			name:   "Overdue",
+			window: todo.DueOverdue,
+			wantTo: now,
+		},
+		{
+			name:     "Today",
+			window:   todo.DueToday,
+			wantFrom: time.Date(2023, 5, 1, 0, 0, 0, 0, moscow),
+			wantTo:   time.Date(2023, 5, 2, 0, 0, 0, 0, moscow),
+		},
+		{
+			name:     "This Week",
+			window:   todo.DueThisWeek,
+			wantFrom: time.Date(2023, 5, 1, 0, 0, 0, 0, moscow),
+			wantTo:   time.Date(2023, 5, 8, 0, 0, 0, 0, moscow),
+		},
+		{
+			name:    "Unknown Window",
+			window:  "month",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			from, to, err := dueRange(testCase.window, now)
+			if testCase.wantErr {
+				assert.ErrorIs(t, err, todo.ErrValidation)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, testCase.wantFrom.Equal(from), "from: %s", from)
+			assert.True(t, testCase.wantTo.Equal(to), "to: %s", to)
+		})
+	}
+}
+
+func TestTodoItemService_CreateItem_UnknownPriority(t *testing.T) {
+	s := NewTodoItemService(nil, nil, nil)
+
+	_, err := s.CreateItem(1, 2, todo.TodoItem{Title: "title", Priority: "critical"})
+	assert.ErrorIs(t, err, todo.ErrValidation)
+}
+
+func TestTodoItemService_Batch_Invalid(t *testing.T) {
+	s := NewTodoItemService(nil, nil, nil)
+
+	testTable := []struct {
+		name  string
+		input todo.BatchInput
+	}{
+		{
+			name:  "No Operations",
+			input: todo.BatchInput{},
+		},
+		{
+			name:  "Unknown Mode",
+			input: todo.BatchInput{Mode: "eventual", Operations: []todo.BatchOperation{{Op: todo.BatchCompleteAll}}},
+		},
+		{
+			name:  "Unknown Operation",
+			input: todo.BatchInput{Operations: []todo.BatchOperation{{Op: "archive"}}},
+		},
+		{
+			name:  "Update Without Id",
+			input: todo.BatchInput{Operations: []todo.BatchOperation{{Op: todo.BatchUpdate, Update: &todo.UpdateItemInput{}}}},
+		},
+		{
+			name: "Unknown Priority",
+			input: todo.BatchInput{Operations: []todo.BatchOperation{
+				{Op: todo.BatchCreate, Item: &todo.TodoItem{Title: "title", Priority: "critical"}},
+			}},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := s.Batch(1, 2, testCase.input)
+			assert.ErrorIs(t, err, todo.ErrValidation)
+		})
+	}
+}
+
+func TestPrepareBatchOperation(t *testing.T) {
+	recurrence := todo.Recurrence("rrule:freq=weekly;byday=th,mo")
+	op := todo.BatchOperation{
+		Op:     todo.BatchUpdate,
+		ItemId: 3,
+		Update: &todo.UpdateItemInput{Recurrence: &recurrence},
+	}
+	input := op
+
+	assert.NoError(t, prepareBatchOperation(&op))
+	assert.Equal(t, todo.Recurrence("FREQ=WEEKLY;BYDAY=MO,TH"), *op.Update.Recurrence)
+	assert.Equal(t, todo.Recurrence("rrule:freq=weekly;byday=th,mo"), *input.Update.Recurrence)
+}
+
+func TestNextOccurrence(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := func(loc *time.Location, year int, month time.Month, day, hour int) *time.Time {
+		value := time.Date(year, month, day, hour, 0, 0, 0, loc)
+		return &value
+	}
+	now := time.Date(2023, 5, 2, 15, 0, 0, 0, moscow)
+
+	testTable := []struct {
+		name       string
+		item       todo.TodoItem
+		now        time.Time
+		wantDueAt  *time.Time
+		wantRemind *time.Time
+		wantRule   todo.Recurrence
+		wantOk     bool
+	}{
+		{
+			name:      "Weekly By Day",
+			item:      todo.TodoItem{Recurrence: "FREQ=WEEKLY;BYDAY=MO,TH", DueAt: at(moscow, 2023, 5, 4, 9)},
+			now:       now,
+			wantDueAt: at(moscow, 2023, 5, 8, 9),
+			wantRule:  "FREQ=WEEKLY;BYDAY=MO,TH",
+			wantOk:    true,
+		},
+		{
+			name:      "Every Other Week",
+			item:      todo.TodoItem{Recurrence: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", DueAt: at(moscow, 2023, 5, 4, 9)},
+			now:       now,
+			wantDueAt: at(moscow, 2023, 5, 15, 9),
+			wantRule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			wantOk:    true,
+		},
+		{
+			name:      "Due Date In User Time Zone",
+			item:      todo.TodoItem{Recurrence: "FREQ=WEEKLY;BYDAY=MO", DueAt: at(time.UTC, 2023, 5, 7, 22)},
+			now:       now,
+			wantDueAt: at(moscow, 2023, 5, 15, 1),
+			wantRule:  "FREQ=WEEKLY;BYDAY=MO",
+			wantOk:    true,
+		},
+		{
+			name:      "Monthly Skips Short Months",
+			item:      todo.TodoItem{Recurrence: "FREQ=MONTHLY", DueAt: at(moscow, 2023, 1, 31, 9)},
+			now:       now,
+			wantDueAt: at(moscow, 2023, 3, 31, 9),
+			wantRule:  "FREQ=MONTHLY",
+			wantOk:    true,
+		},
+		{
+			name:      "Keeps Wall Clock Across DST",
+			item:      todo.TodoItem{Recurrence: "FREQ=DAILY", DueAt: at(berlin, 2023, 3, 25, 9)},
+			now:       time.Date(2023, 3, 25, 10, 0, 0, 0, berlin),
+			wantDueAt: at(berlin, 2023, 3, 26, 9),
+			wantRule:  "FREQ=DAILY",
+			wantOk:    true,
+		},
+		{
+			name: "Reminder Moves Along",
+			item: todo.TodoItem{
+				Recurrence: "FREQ=DAILY;COUNT=3",
+				DueAt:      at(moscow, 2023, 5, 4, 9),
+				RemindAt:   at(moscow, 2023, 5, 4, 8),
+			},
+			now:        now,
+			wantDueAt:  at(moscow, 2023, 5, 5, 9),
+			wantRemind: at(moscow, 2023, 5, 5, 8),
+			wantRule:   "FREQ=DAILY;COUNT=2",
+			wantOk:     true,
+		},
+		{
+			name:      "Without Due Date",
+			item:      todo.TodoItem{Recurrence: "FREQ=DAILY"},
+			now:       now,
+			wantDueAt: at(moscow, 2023, 5, 3, 15),
+			wantRule:  "FREQ=DAILY",
+			wantOk:    true,
+		},
+		{
+			name: "Last Occurrence",
+			item: todo.TodoItem{Recurrence: "FREQ=DAILY;COUNT=1", DueAt: at(moscow, 2023, 5, 4, 9)},
+			now:  now,
+		},
+		{
+			name: "Past Until",
+			item: todo.TodoItem{Recurrence: "FREQ=DAILY;UNTIL=20230504", DueAt: at(moscow, 2023, 5, 4, 9)},
+			now:  now,
+		},
+		{
+			name: "Not Recurring",
+			item: todo.TodoItem{DueAt: at(moscow, 2023, 5, 4, 9)},
+			now:  now,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			next, ok, err := nextOccurrence(testCase.item, testCase.now)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.wantOk, ok)
+			if testCase.wantOk {
+				assert.True(t, testCase.wantDueAt.Equal(*next.DueAt), "due at %s", next.DueAt)
+				assert.Equal(t, testCase.wantRule, next.Recurrence)
+				if testCase.wantRemind != nil {
+					assert.True(t, testCase.wantRemind.Equal(*next.RemindAt), "remind at %s", next.RemindAt)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeRecurrence(t *testing.T) {
+	testTable := []struct {
+		name    string
+		input   todo.Recurrence
+		want    todo.Recurrence
+		wantErr bool
+	}{
+		{
+			name:  "Canonical Form",
+			input: "rrule:freq=weekly;byday=th,mo,th;interval=1",
+			want:  "FREQ=WEEKLY;BYDAY=MO,TH",
+		},
+		{
+			name:  "Until Instant",
+			input: "FREQ=DAILY;UNTIL=20231231T200000Z;INTERVAL=3",
+			want:  "FREQ=DAILY;INTERVAL=3;UNTIL=20231231T200000Z",
+		},
+		{
+			name:    "Missing Freq",
+			input:   "INTERVAL=2",
+			wantErr: true,
+		},
+		{
+			name:    "Until And Count",
+			input:   "FREQ=DAILY;UNTIL=20231231;COUNT=3",
+			wantErr: true,
+		},
+		{
+			name:    "Monthly By Day",
+			input:   "FREQ=MONTHLY;BYDAY=MO",
+			wantErr: true,
+		},
+		{
+			name:    "Ordinal Weekday",
+			input:   "FREQ=WEEKLY;BYDAY=1MO",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported Part",
+			input:   "FREQ=MONTHLY;BYMONTHDAY=15",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := normalizeRecurrence(testCase.input)
+			if testCase.wantErr {
+				assert.ErrorIs(t, err, todo.ErrValidation)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.want, got)
+			}
+		})
+	}
+}
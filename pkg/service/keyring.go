@@ -0,0 +1,243 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	todo "todo-app"
+)
+
+const (
+	// KeyStatusActive marks the single key used to sign new tokens.
+	KeyStatusActive = "active"
+	// KeyStatusVerify keys only verify tokens: a key being rolled in before activation
+	// or a previous key kept until the tokens it signed have expired.
+	KeyStatusVerify = "verify"
+	// KeyStatusRetired keys are ignored entirely.
+	KeyStatusRetired = "retired"
+)
+
+type KeyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"alg"`
+	Status         string `mapstructure:"status"`
+	SecretEnv      string `mapstructure:"secret_env"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeyRing holds every non-retired JWT key, indexed by kid.
+type KeyRing struct {
+	active *jwtKey
+	keys   map[string]*jwtKey
+	order  []string
+}
+
+func NewKeyRing(cfgs []KeyConfig) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]*jwtKey)}
+
+	for _, cfg := range cfgs {
+		if cfg.Kid == "" {
+			return nil, errors.New("jwt key without kid")
+		}
+		if _, ok := ring.keys[cfg.Kid]; ok {
+			return nil, fmt.Errorf("duplicate jwt key %q", cfg.Kid)
+		}
+
+		switch cfg.Status {
+		case KeyStatusRetired:
+			continue
+		case KeyStatusActive, KeyStatusVerify:
+		default:
+			return nil, fmt.Errorf("jwt key %q has unknown status %q", cfg.Kid, cfg.Status)
+		}
+
+		key, err := loadJWTKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", cfg.Kid, err)
+		}
+
+		if cfg.Status == KeyStatusActive {
+			if ring.active != nil {
+				return nil, fmt.Errorf("jwt keys %q and %q are both active", ring.active.kid, cfg.Kid)
+			}
+			if key.signKey == nil {
+				return nil, fmt.Errorf("jwt key %q is active but has no private key", cfg.Kid)
+			}
+			ring.active = key
+		}
+
+		ring.keys[cfg.Kid] = key
+		ring.order = append(ring.order, cfg.Kid)
+	}
+
+	if ring.active == nil {
+		return nil, errors.New("no active jwt signing key configured")
+	}
+
+	return ring, nil
+}
+
+func (k *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.method, claims)
+	token.Header["kid"] = k.active.kid
+
+	return token.SignedString(k.active.signKey)
+}
+
+// Keyfunc resolves the verification key from the token's kid header. The algorithm
+// is pinned to the key, so a token cannot pick e.g. HS256 to be checked with a public key.
+func (k *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public halves of the asymmetric keys. Shared HMAC secrets are never published.
+func (k *KeyRing) JWKS() todo.JSONWebKeySet {
+	set := todo.JSONWebKeySet{Keys: make([]todo.JSONWebKey, 0)}
+
+	for _, kid := range k.order {
+		key := k.keys[kid]
+
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, todo.JSONWebKey{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, todo.JSONWebKey{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+
+	return set
+}
+
+func loadJWTKey(cfg KeyConfig) (*jwtKey, error) {
+	key := &jwtKey{kid: cfg.Kid}
+
+	switch cfg.Algorithm {
+	case "HS256":
+		secret := os.Getenv(cfg.SecretEnv)
+		if cfg.SecretEnv == "" || len(secret) < 32 {
+			return nil, errors.New("HS256 secret must be set via secret_env and be at least 32 bytes")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.signKey = []byte(secret)
+		key.verifyKey = []byte(secret)
+	case "RS256":
+		key.method = jwt.SigningMethodRS256
+		if err := loadAsymmetricKey(cfg, key); err != nil {
+			return nil, err
+		}
+		if _, ok := key.verifyKey.(*rsa.PublicKey); !ok {
+			return nil, errors.New("RS256 requires an RSA key")
+		}
+	case "EdDSA":
+		key.method = jwt.SigningMethodEdDSA
+		if err := loadAsymmetricKey(cfg, key); err != nil {
+			return nil, err
+		}
+		if _, ok := key.verifyKey.(ed25519.PublicKey); !ok {
+			return nil, errors.New("EdDSA requires an Ed25519 key")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	return key, nil
+}
+
+func loadAsymmetricKey(cfg KeyConfig, key *jwtKey) error {
+	if cfg.PrivateKeyFile != "" {
+		block, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+
+		privateKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			if privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				return fmt.Errorf("parse private key: %w", err)
+			}
+		}
+
+		switch privateKey := privateKey.(type) {
+		case *rsa.PrivateKey:
+			key.signKey, key.verifyKey = privateKey, &privateKey.PublicKey
+		case ed25519.PrivateKey:
+			key.signKey, key.verifyKey = privateKey, privateKey.Public()
+		default:
+			return errors.New("unsupported private key type")
+		}
+
+		return nil
+	}
+
+	if cfg.PublicKeyFile == "" {
+		return errors.New("private_key_file or public_key_file is required")
+	}
+
+	block, err := readPEM(cfg.PublicKeyFile)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		if publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+			return fmt.Errorf("parse public key: %w", err)
+		}
+	}
+	key.verifyKey = publicKey
+
+	return nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s contains no PEM data", path)
+	}
+
+	return block, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePrivateKey(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func testClaims() *TokenClaims {
+	return &TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+		SessionId: "session",
+	}
+}
+
+func parseWith(ring *KeyRing, token string) error {
+	_, err := jwt.ParseWithClaims(token, &TokenClaims{}, ring.Keyfunc)
+	return err
+}
+
+func TestKeyRing_Rotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	rsaFile := writePrivateKey(t, rsaKey)
+	edFile := writePrivateKey(t, edKey)
+
+	oldRing, err := NewKeyRing([]KeyConfig{
+		{Kid: "rsa", Algorithm: "RS256", Status: KeyStatusActive, PrivateKeyFile: rsaFile},
+		{Kid: "hmac", Algorithm: "HS256", Status: KeyStatusVerify, SecretEnv: "TEST_JWT_SECRET"},
+	})
+	assert.NoError(t, err)
+
+	rotatedRing, err := NewKeyRing([]KeyConfig{
+		{Kid: "ed", Algorithm: "EdDSA", Status: KeyStatusActive, PrivateKeyFile: edFile},
+		{Kid: "rsa", Algorithm: "RS256", Status: KeyStatusVerify, PrivateKeyFile: rsaFile},
+	})
+	assert.NoError(t, err)
+
+	retiredRing, err := NewKeyRing([]KeyConfig{
+		{Kid: "ed", Algorithm: "EdDSA", Status: KeyStatusActive, PrivateKeyFile: edFile},
+		{Kid: "rsa", Algorithm: "RS256", Status: KeyStatusRetired, PrivateKeyFile: rsaFile},
+	})
+	assert.NoError(t, err)
+
+	oldToken, err := oldRing.Sign(testClaims())
+	assert.NoError(t, err)
+	newToken, err := rotatedRing.Sign(testClaims())
+	assert.NoError(t, err)
+
+	assert.NoError(t, parseWith(oldRing, oldToken))
+	assert.NoError(t, parseWith(rotatedRing, oldToken), "verify-only keys must still accept their tokens")
+	assert.NoError(t, parseWith(rotatedRing, newToken))
+	assert.Error(t, parseWith(retiredRing, oldToken), "retired keys must not verify")
+	assert.Error(t, parseWith(oldRing, newToken), "unknown kid must not verify")
+
+	jwks := rotatedRing.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "ed", jwks.Keys[0].Kid)
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, "rsa", jwks.Keys[1].Kid)
+		assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	}
+	assert.Len(t, oldRing.JWKS().Keys, 1, "HMAC secrets must not be published")
+}
+
+func TestKeyRing_AlgorithmPinned(t *testing.T) {
+	t.Setenv("TEST_JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	ring, err := NewKeyRing([]KeyConfig{
+		{Kid: "hmac", Algorithm: "HS256", Status: KeyStatusActive, SecretEnv: "TEST_JWT_SECRET"},
+	})
+	assert.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, testClaims())
+	token.Header["kid"] = "hmac"
+	signed, err := token.SignedString([]byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+
+	assert.Error(t, parseWith(ring, signed))
+}
+
+func TestNewKeyRing_Invalid(t *testing.T) {
+	t.Setenv("TEST_JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	testTable := []struct {
+		name string
+		cfgs []KeyConfig
+	}{
+		{
+			name: "No Active Key",
+			cfgs: []KeyConfig{{Kid: "hmac", Algorithm: "HS256", Status: KeyStatusVerify, SecretEnv: "TEST_JWT_SECRET"}},
+		},
+		{
+			name: "Two Active Keys",
+			cfgs: []KeyConfig{
+				{Kid: "a", Algorithm: "HS256", Status: KeyStatusActive, SecretEnv: "TEST_JWT_SECRET"},
+				{Kid: "b", Algorithm: "HS256", Status: KeyStatusActive, SecretEnv: "TEST_JWT_SECRET"},
+			},
+		},
+		{
+			name: "Short Secret",
+			cfgs: []KeyConfig{{Kid: "hmac", Algorithm: "HS256", Status: KeyStatusActive, SecretEnv: "TEST_JWT_MISSING"}},
+		},
+		{
+			name: "Unknown Algorithm",
+			cfgs: []KeyConfig{{Kid: "x", Algorithm: "none", Status: KeyStatusActive}},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewKeyRing(testCase.cfgs)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	todo "todo-app"
+	"todo-app/pkg/repository"
+)
+
+type LabelService struct {
+	repo        repository.Label
+	itemsRepo   repository.TodoItem
+	membersRepo repository.ListMember
+}
+
+func NewLabelService(repo repository.Label, itemsRepo repository.TodoItem, membersRepo repository.ListMember) *LabelService {
+	return &LabelService{repo: repo, itemsRepo: itemsRepo, membersRepo: membersRepo}
+}
+
+func (s *LabelService) Create(userId int, label todo.Label) (int, error) {
+	if err := label.Validate(); err != nil {
+		return 0, err
+	}
+
+	if label.Color == "" {
+		label.Color = todo.DefaultLabelColor
+	}
+
+	return s.repo.Create(userId, label)
+}
+
+func (s *LabelService) GetAll(userId int) ([]todo.Label, error) {
+	return s.repo.GetAll(userId)
+}
+
+func (s *LabelService) GetById(userId, labelId int) (todo.Label, error) {
+	return s.repo.GetById(userId, labelId)
+}
+
+func (s *LabelService) Update(userId, labelId int, input todo.UpdateLabelInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	return s.repo.Update(userId, labelId, input)
+}
+
+func (s *LabelService) Delete(userId, labelId int) error {
+	return s.repo.Delete(userId, labelId)
+}
+
+// GetItems returns the items of all the user's lists tagged with the label.
+func (s *LabelService) GetItems(userId, labelId int) ([]todo.TodoItem, error) {
+	if _, err := s.repo.GetById(userId, labelId); err != nil {
+		return nil, err
+	}
+
+	return s.itemsRepo.GetByLabel(userId, labelId)
+}
+
+// Attach tags an item the user can see. Labels are private, so viewers may tag items too.
+func (s *LabelService) Attach(userId, itemId, labelId int) error {
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleViewer); err != nil {
+		return err
+	}
+
+	return s.repo.Attach(userId, itemId, labelId)
+}
+
+func (s *LabelService) Detach(userId, itemId, labelId int) error {
+	if err := requireItemRole(s.membersRepo, userId, itemId, todo.RoleViewer); err != nil {
+		return err
+	}
+
+	return s.repo.Detach(userId, itemId, labelId)
+}
@@ -1,24 +1,32 @@
 package service
 
 import (
+	"time"
 	todo "todo-app"
 	"todo-app/pkg/repository"
 )
 
 type TodoListService struct {
-	repo repository.TodoList
+	repo        repository.TodoList
+	membersRepo repository.ListMember
+	usersRepo   repository.Authorization
 }
 
-func NewTodoListService(repo repository.TodoList) *TodoListService {
-	return &TodoListService{repo: repo}
+func NewTodoListService(repo repository.TodoList, membersRepo repository.ListMember,
+	usersRepo repository.Authorization) *TodoListService {
+	return &TodoListService{repo: repo, membersRepo: membersRepo, usersRepo: usersRepo}
 }
 
 func (s *TodoListService) CreateList(userId int, list todo.TodoList) (int, error) {
 	return s.repo.CreateList(userId, list)
 }
 
-func (s *TodoListService) GetAll(userId int) ([]todo.TodoList, error) {
-	return s.repo.GetAll(userId)
+func (s *TodoListService) GetAll(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error) {
+	if err := input.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return s.repo.GetAll(userId, input)
 }
 
 func (s *TodoListService) GetById(userId int, listId int) (todo.TodoList, error) {
@@ -30,9 +38,68 @@ func (s *TodoListService) Update(userId, listId int, input todo.UpdateListInput)
 		return err
 	}
 
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleEditor); err != nil {
+		return err
+	}
+
 	return s.repo.Update(userId, listId, input)
 }
 
-func (s *TodoListService) Delete(userId int, listId int) error {
-	return s.repo.Delete(userId, listId)
+// Move reorders the user's own lists, so any member may move a list.
+func (s *TodoListService) Move(userId, listId int, input todo.MoveInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	return s.repo.Move(userId, listId, input)
+}
+
+func (s *TodoListService) Delete(userId int, listId int, version *int) error {
+	if err := requireListRole(s.membersRepo, userId, listId, todo.RoleOwner); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(userId, listId, version)
+}
+
+// Clone copies a list the user is a member of, so any member may clone it.
+func (s *TodoListService) Clone(userId, listId int, input todo.CloneListInput) (int, error) {
+	return s.repo.Clone(userId, listId, input)
+}
+
+// GetTemplates returns one page of the user's templates.
+func (s *TodoListService) GetTemplates(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error) {
+	input.Template = true
+	return s.GetAll(userId, input)
+}
+
+// Instantiate creates a list from the template. The date variable defaults to today
+// in the user's time zone.
+func (s *TodoListService) Instantiate(userId, templateId int, input todo.InstantiateTemplateInput) (int, error) {
+	if _, ok := input.Variables["date"]; !ok {
+		timeZone, err := s.usersRepo.GetTimeZone(userId)
+		if err != nil {
+			return 0, err
+		}
+
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return 0, err
+		}
+
+		input.Variables = withVariable(input.Variables, "date", time.Now().In(loc).Format(todo.TemplateDateLayout))
+	}
+
+	return s.repo.Instantiate(userId, templateId, input)
+}
+
+// withVariable returns a copy of variables with name set to value.
+func withVariable(variables map[string]string, name, value string) map[string]string {
+	result := make(map[string]string, len(variables)+1)
+	for k, v := range variables {
+		result[k] = v
+	}
+	result[name] = value
+
+	return result
 }
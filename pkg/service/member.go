@@ -0,0 +1,86 @@
+package service
+
+import (
+	todo "todo-app"
+	"todo-app/pkg/repository"
+)
+
+type ListMemberService struct {
+	repo repository.ListMember
+}
+
+func NewListMemberService(repo repository.ListMember) *ListMemberService {
+	return &ListMemberService{repo: repo}
+}
+
+func (s *ListMemberService) GetAll(userId, listId int) ([]todo.ListMember, error) {
+	if err := requireListRole(s.repo, userId, listId, todo.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAll(listId)
+}
+
+func (s *ListMemberService) Add(userId, listId int, input todo.AddMemberInput) (int, error) {
+	if err := todo.ValidateRole(input.Role); err != nil {
+		return 0, err
+	}
+
+	if err := requireListRole(s.repo, userId, listId, todo.RoleOwner); err != nil {
+		return 0, err
+	}
+
+	return s.repo.Add(userId, listId, input)
+}
+
+func (s *ListMemberService) UpdateRole(userId, listId, memberId int, input todo.UpdateMemberInput) error {
+	if err := todo.ValidateRole(input.Role); err != nil {
+		return err
+	}
+
+	if err := requireListRole(s.repo, userId, listId, todo.RoleOwner); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateRole(userId, listId, memberId, input.Role)
+}
+
+// Remove lets owners remove anyone and every member leave the list on their own.
+func (s *ListMemberService) Remove(userId, listId, memberId int) error {
+	minRole := todo.RoleOwner
+	if userId == memberId {
+		minRole = todo.RoleViewer
+	}
+
+	if err := requireListRole(s.repo, userId, listId, minRole); err != nil {
+		return err
+	}
+
+	return s.repo.Remove(userId, listId, memberId)
+}
+
+func requireListRole(repo repository.ListMember, userId, listId int, minRole string) error {
+	role, err := repo.GetRole(userId, listId)
+	if err != nil {
+		return err
+	}
+
+	if !todo.RoleAtLeast(role, minRole) {
+		return todo.ErrForbidden
+	}
+
+	return nil
+}
+
+func requireItemRole(repo repository.ListMember, userId, itemId int, minRole string) error {
+	role, err := repo.GetItemRole(userId, itemId)
+	if err != nil {
+		return err
+	}
+
+	if !todo.RoleAtLeast(role, minRole) {
+		return todo.ErrForbidden
+	}
+
+	return nil
+}
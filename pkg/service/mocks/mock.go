@@ -5,6 +5,7 @@
 package mock_service
 
 import (
+	io "io"
 	reflect "reflect"
 	todo "todo-app"
 
@@ -50,10 +51,10 @@ func (mr *MockAuthorizationMockRecorder) CreateUser(user interface{}) *gomock.Ca
 }
 
 // GenerateToken mocks base method.
-func (m *MockAuthorization) GenerateToken(username, password string) (string, error) {
+func (m *MockAuthorization) GenerateToken(username, password string) (todo.Tokens, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GenerateToken", username, password)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(todo.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -64,11 +65,53 @@ func (mr *MockAuthorizationMockRecorder) GenerateToken(username, password interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), username, password)
 }
 
+// JWKS mocks base method.
+func (m *MockAuthorization) JWKS() todo.JSONWebKeySet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(todo.JSONWebKeySet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthorizationMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuthorization)(nil).JWKS))
+}
+
+// Logout mocks base method.
+func (m *MockAuthorization) Logout(refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockAuthorizationMockRecorder) Logout(refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAuthorization)(nil).Logout), refreshToken)
+}
+
+// LogoutAll mocks base method.
+func (m *MockAuthorization) LogoutAll(userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogoutAll", userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogoutAll indicates an expected call of LogoutAll.
+func (mr *MockAuthorizationMockRecorder) LogoutAll(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogoutAll", reflect.TypeOf((*MockAuthorization)(nil).LogoutAll), userId)
+}
+
 // ParseToken mocks base method.
-func (m *MockAuthorization) ParseToken(token string) (int, error) {
+func (m *MockAuthorization) ParseToken(token string) (todo.Principal, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ParseToken", token)
-	ret0, _ := ret[0].(int)
+	ret0, _ := ret[0].(todo.Principal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -79,6 +122,21 @@ func (mr *MockAuthorizationMockRecorder) ParseToken(token interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockAuthorization)(nil).ParseToken), token)
 }
 
+// RefreshToken mocks base method.
+func (m *MockAuthorization) RefreshToken(refreshToken string) (todo.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", refreshToken)
+	ret0, _ := ret[0].(todo.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockAuthorizationMockRecorder) RefreshToken(refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockAuthorization)(nil).RefreshToken), refreshToken)
+}
+
 // MockTodoList is a mock of TodoList interface.
 type MockTodoList struct {
 	ctrl     *gomock.Controller
@@ -102,6 +160,21 @@ func (m *MockTodoList) EXPECT() *MockTodoListMockRecorder {
 	return m.recorder
 }
 
+// Clone mocks base method.
+func (m *MockTodoList) Clone(userId, listId int, input todo.CloneListInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clone", userId, listId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Clone indicates an expected call of Clone.
+func (mr *MockTodoListMockRecorder) Clone(userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockTodoList)(nil).Clone), userId, listId, input)
+}
+
 // CreateList mocks base method.
 func (m *MockTodoList) CreateList(userId int, list todo.TodoList) (int, error) {
 	m.ctrl.T.Helper()
@@ -118,32 +191,33 @@ func (mr *MockTodoListMockRecorder) CreateList(userId, list interface{}) *gomock
 }
 
 // Delete mocks base method.
-func (m *MockTodoList) Delete(userId, listId int) error {
+func (m *MockTodoList) Delete(userId, listId int, version *int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userId, listId)
+	ret := m.ctrl.Call(m, "Delete", userId, listId, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTodoListMockRecorder) Delete(userId, listId interface{}) *gomock.Call {
+func (mr *MockTodoListMockRecorder) Delete(userId, listId, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoList)(nil).Delete), userId, listId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoList)(nil).Delete), userId, listId, version)
 }
 
 // GetAll mocks base method.
-func (m *MockTodoList) GetAll(userId int) ([]todo.TodoList, error) {
+func (m *MockTodoList) GetAll(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", userId)
+	ret := m.ctrl.Call(m, "GetAll", userId, input)
 	ret0, _ := ret[0].([]todo.TodoList)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockTodoListMockRecorder) GetAll(userId interface{}) *gomock.Call {
+func (mr *MockTodoListMockRecorder) GetAll(userId, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoList)(nil).GetAll), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoList)(nil).GetAll), userId, input)
 }
 
 // GetById mocks base method.
@@ -161,6 +235,51 @@ func (mr *MockTodoListMockRecorder) GetById(userId, listId interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoList)(nil).GetById), userId, listId)
 }
 
+// GetTemplates mocks base method.
+func (m *MockTodoList) GetTemplates(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTemplates", userId, input)
+	ret0, _ := ret[0].([]todo.TodoList)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTemplates indicates an expected call of GetTemplates.
+func (mr *MockTodoListMockRecorder) GetTemplates(userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplates", reflect.TypeOf((*MockTodoList)(nil).GetTemplates), userId, input)
+}
+
+// Instantiate mocks base method.
+func (m *MockTodoList) Instantiate(userId, templateId int, input todo.InstantiateTemplateInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Instantiate", userId, templateId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Instantiate indicates an expected call of Instantiate.
+func (mr *MockTodoListMockRecorder) Instantiate(userId, templateId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Instantiate", reflect.TypeOf((*MockTodoList)(nil).Instantiate), userId, templateId, input)
+}
+
+// Move mocks base method.
+func (m *MockTodoList) Move(userId, listId int, input todo.MoveInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", userId, listId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockTodoListMockRecorder) Move(userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockTodoList)(nil).Move), userId, listId, input)
+}
+
 // Update mocks base method.
 func (m *MockTodoList) Update(userId, listId int, input todo.UpdateListInput) error {
 	m.ctrl.T.Helper()
@@ -175,6 +294,87 @@ func (mr *MockTodoListMockRecorder) Update(userId, listId, input interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoList)(nil).Update), userId, listId, input)
 }
 
+// MockListMember is a mock of ListMember interface.
+type MockListMember struct {
+	ctrl     *gomock.Controller
+	recorder *MockListMemberMockRecorder
+}
+
+// MockListMemberMockRecorder is the mock recorder for MockListMember.
+type MockListMemberMockRecorder struct {
+	mock *MockListMember
+}
+
+// NewMockListMember creates a new mock instance.
+func NewMockListMember(ctrl *gomock.Controller) *MockListMember {
+	mock := &MockListMember{ctrl: ctrl}
+	mock.recorder = &MockListMemberMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockListMember) EXPECT() *MockListMemberMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockListMember) Add(userId, listId int, input todo.AddMemberInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", userId, listId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockListMemberMockRecorder) Add(userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockListMember)(nil).Add), userId, listId, input)
+}
+
+// GetAll mocks base method.
+func (m *MockListMember) GetAll(userId, listId int) ([]todo.ListMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId, listId)
+	ret0, _ := ret[0].([]todo.ListMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockListMemberMockRecorder) GetAll(userId, listId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockListMember)(nil).GetAll), userId, listId)
+}
+
+// Remove mocks base method.
+func (m *MockListMember) Remove(userId, listId, memberId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", userId, listId, memberId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockListMemberMockRecorder) Remove(userId, listId, memberId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockListMember)(nil).Remove), userId, listId, memberId)
+}
+
+// UpdateRole mocks base method.
+func (m *MockListMember) UpdateRole(userId, listId, memberId int, input todo.UpdateMemberInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", userId, listId, memberId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockListMemberMockRecorder) UpdateRole(userId, listId, memberId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockListMember)(nil).UpdateRole), userId, listId, memberId, input)
+}
+
 // MockTodoItem is a mock of TodoItem interface.
 type MockTodoItem struct {
 	ctrl     *gomock.Controller
@@ -198,6 +398,36 @@ func (m *MockTodoItem) EXPECT() *MockTodoItemMockRecorder {
 	return m.recorder
 }
 
+// Batch mocks base method.
+func (m *MockTodoItem) Batch(userId, listId int, input todo.BatchInput) ([]todo.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", userId, listId, input)
+	ret0, _ := ret[0].([]todo.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Batch indicates an expected call of Batch.
+func (mr *MockTodoItemMockRecorder) Batch(userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockTodoItem)(nil).Batch), userId, listId, input)
+}
+
+// Copy mocks base method.
+func (m *MockTodoItem) Copy(userId, itemId int, input todo.CopyItemInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Copy", userId, itemId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Copy indicates an expected call of Copy.
+func (mr *MockTodoItemMockRecorder) Copy(userId, itemId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Copy", reflect.TypeOf((*MockTodoItem)(nil).Copy), userId, itemId, input)
+}
+
 // CreateItem mocks base method.
 func (m *MockTodoItem) CreateItem(userId, listId int, item todo.TodoItem) (int, error) {
 	m.ctrl.T.Helper()
@@ -213,33 +443,64 @@ func (mr *MockTodoItemMockRecorder) CreateItem(userId, listId, item interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateItem", reflect.TypeOf((*MockTodoItem)(nil).CreateItem), userId, listId, item)
 }
 
+// CreateSubtask mocks base method.
+func (m *MockTodoItem) CreateSubtask(userId, parentId int, item todo.TodoItem) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSubtask", userId, parentId, item)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSubtask indicates an expected call of CreateSubtask.
+func (mr *MockTodoItemMockRecorder) CreateSubtask(userId, parentId, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSubtask", reflect.TypeOf((*MockTodoItem)(nil).CreateSubtask), userId, parentId, item)
+}
+
 // Delete mocks base method.
-func (m *MockTodoItem) Delete(userId, itemId int) error {
+func (m *MockTodoItem) Delete(userId, itemId int, version *int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", userId, itemId)
+	ret := m.ctrl.Call(m, "Delete", userId, itemId, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockTodoItemMockRecorder) Delete(userId, itemId interface{}) *gomock.Call {
+func (mr *MockTodoItemMockRecorder) Delete(userId, itemId, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoItem)(nil).Delete), userId, itemId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockTodoItem)(nil).Delete), userId, itemId, version)
 }
 
 // GetAll mocks base method.
-func (m *MockTodoItem) GetAll(userId, listId int) ([]todo.TodoItem, error) {
+func (m *MockTodoItem) GetAll(userId, listId int, input todo.ItemsQuery) ([]todo.TodoItem, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll", userId, listId)
+	ret := m.ctrl.Call(m, "GetAll", userId, listId, input)
+	ret0, _ := ret[0].([]todo.TodoItem)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockTodoItemMockRecorder) GetAll(userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoItem)(nil).GetAll), userId, listId, input)
+}
+
+// GetAssigned mocks base method.
+func (m *MockTodoItem) GetAssigned(userId int) ([]todo.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssigned", userId)
 	ret0, _ := ret[0].([]todo.TodoItem)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAll indicates an expected call of GetAll.
-func (mr *MockTodoItemMockRecorder) GetAll(userId, listId interface{}) *gomock.Call {
+// GetAssigned indicates an expected call of GetAssigned.
+func (mr *MockTodoItemMockRecorder) GetAssigned(userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTodoItem)(nil).GetAll), userId, listId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssigned", reflect.TypeOf((*MockTodoItem)(nil).GetAssigned), userId)
 }
 
 // GetById mocks base method.
@@ -257,6 +518,107 @@ func (mr *MockTodoItemMockRecorder) GetById(userId, itemId interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockTodoItem)(nil).GetById), userId, itemId)
 }
 
+// GetDue mocks base method.
+func (m *MockTodoItem) GetDue(userId int, window string, input todo.DueQuery) ([]todo.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDue", userId, window, input)
+	ret0, _ := ret[0].([]todo.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDue indicates an expected call of GetDue.
+func (mr *MockTodoItemMockRecorder) GetDue(userId, window, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDue", reflect.TypeOf((*MockTodoItem)(nil).GetDue), userId, window, input)
+}
+
+// GetLists mocks base method.
+func (m *MockTodoItem) GetLists(userId, itemId int) ([]todo.TodoList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLists", userId, itemId)
+	ret0, _ := ret[0].([]todo.TodoList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLists indicates an expected call of GetLists.
+func (mr *MockTodoItemMockRecorder) GetLists(userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLists", reflect.TypeOf((*MockTodoItem)(nil).GetLists), userId, itemId)
+}
+
+// GetSubtasks mocks base method.
+func (m *MockTodoItem) GetSubtasks(userId, itemId int) ([]todo.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSubtasks", userId, itemId)
+	ret0, _ := ret[0].([]todo.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSubtasks indicates an expected call of GetSubtasks.
+func (mr *MockTodoItemMockRecorder) GetSubtasks(userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSubtasks", reflect.TypeOf((*MockTodoItem)(nil).GetSubtasks), userId, itemId)
+}
+
+// Link mocks base method.
+func (m *MockTodoItem) Link(userId, itemId, listId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Link", userId, itemId, listId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Link indicates an expected call of Link.
+func (mr *MockTodoItemMockRecorder) Link(userId, itemId, listId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Link", reflect.TypeOf((*MockTodoItem)(nil).Link), userId, itemId, listId)
+}
+
+// Move mocks base method.
+func (m *MockTodoItem) Move(userId, itemId int, input todo.MoveInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", userId, itemId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockTodoItemMockRecorder) Move(userId, itemId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockTodoItem)(nil).Move), userId, itemId, input)
+}
+
+// Transfer mocks base method.
+func (m *MockTodoItem) Transfer(userId, itemId int, input todo.TransferItemInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", userId, itemId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockTodoItemMockRecorder) Transfer(userId, itemId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockTodoItem)(nil).Transfer), userId, itemId, input)
+}
+
+// Unlink mocks base method.
+func (m *MockTodoItem) Unlink(userId, itemId, listId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlink", userId, itemId, listId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlink indicates an expected call of Unlink.
+func (mr *MockTodoItemMockRecorder) Unlink(userId, itemId, listId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlink", reflect.TypeOf((*MockTodoItem)(nil).Unlink), userId, itemId, listId)
+}
+
 // Update mocks base method.
 func (m *MockTodoItem) Update(userId, itemId int, input todo.UpdateItemInput) error {
 	m.ctrl.T.Helper()
@@ -270,3 +632,518 @@ func (mr *MockTodoItemMockRecorder) Update(userId, itemId, input interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockTodoItem)(nil).Update), userId, itemId, input)
 }
+
+// MockLabel is a mock of Label interface.
+type MockLabel struct {
+	ctrl     *gomock.Controller
+	recorder *MockLabelMockRecorder
+}
+
+// MockLabelMockRecorder is the mock recorder for MockLabel.
+type MockLabelMockRecorder struct {
+	mock *MockLabel
+}
+
+// NewMockLabel creates a new mock instance.
+func NewMockLabel(ctrl *gomock.Controller) *MockLabel {
+	mock := &MockLabel{ctrl: ctrl}
+	mock.recorder = &MockLabelMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLabel) EXPECT() *MockLabelMockRecorder {
+	return m.recorder
+}
+
+// Attach mocks base method.
+func (m *MockLabel) Attach(userId, itemId, labelId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attach", userId, itemId, labelId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Attach indicates an expected call of Attach.
+func (mr *MockLabelMockRecorder) Attach(userId, itemId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attach", reflect.TypeOf((*MockLabel)(nil).Attach), userId, itemId, labelId)
+}
+
+// Create mocks base method.
+func (m *MockLabel) Create(userId int, label todo.Label) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, label)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockLabelMockRecorder) Create(userId, label interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockLabel)(nil).Create), userId, label)
+}
+
+// Delete mocks base method.
+func (m *MockLabel) Delete(userId, labelId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userId, labelId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockLabelMockRecorder) Delete(userId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockLabel)(nil).Delete), userId, labelId)
+}
+
+// Detach mocks base method.
+func (m *MockLabel) Detach(userId, itemId, labelId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Detach", userId, itemId, labelId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Detach indicates an expected call of Detach.
+func (mr *MockLabelMockRecorder) Detach(userId, itemId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Detach", reflect.TypeOf((*MockLabel)(nil).Detach), userId, itemId, labelId)
+}
+
+// GetAll mocks base method.
+func (m *MockLabel) GetAll(userId int) ([]todo.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId)
+	ret0, _ := ret[0].([]todo.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockLabelMockRecorder) GetAll(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockLabel)(nil).GetAll), userId)
+}
+
+// GetById mocks base method.
+func (m *MockLabel) GetById(userId, labelId int) (todo.Label, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetById", userId, labelId)
+	ret0, _ := ret[0].(todo.Label)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetById indicates an expected call of GetById.
+func (mr *MockLabelMockRecorder) GetById(userId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetById", reflect.TypeOf((*MockLabel)(nil).GetById), userId, labelId)
+}
+
+// GetItems mocks base method.
+func (m *MockLabel) GetItems(userId, labelId int) ([]todo.TodoItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItems", userId, labelId)
+	ret0, _ := ret[0].([]todo.TodoItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItems indicates an expected call of GetItems.
+func (mr *MockLabelMockRecorder) GetItems(userId, labelId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItems", reflect.TypeOf((*MockLabel)(nil).GetItems), userId, labelId)
+}
+
+// Update mocks base method.
+func (m *MockLabel) Update(userId, labelId int, input todo.UpdateLabelInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", userId, labelId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockLabelMockRecorder) Update(userId, labelId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockLabel)(nil).Update), userId, labelId, input)
+}
+
+// MockSearch is a mock of Search interface.
+type MockSearch struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchMockRecorder
+}
+
+// MockSearchMockRecorder is the mock recorder for MockSearch.
+type MockSearchMockRecorder struct {
+	mock *MockSearch
+}
+
+// NewMockSearch creates a new mock instance.
+func NewMockSearch(ctrl *gomock.Controller) *MockSearch {
+	mock := &MockSearch{ctrl: ctrl}
+	mock.recorder = &MockSearchMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearch) EXPECT() *MockSearchMockRecorder {
+	return m.recorder
+}
+
+// Search mocks base method.
+func (m *MockSearch) Search(userId int, input todo.SearchQuery) ([]todo.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", userId, input)
+	ret0, _ := ret[0].([]todo.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockSearchMockRecorder) Search(userId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockSearch)(nil).Search), userId, input)
+}
+
+// MockTrash is a mock of Trash interface.
+type MockTrash struct {
+	ctrl     *gomock.Controller
+	recorder *MockTrashMockRecorder
+}
+
+// MockTrashMockRecorder is the mock recorder for MockTrash.
+type MockTrashMockRecorder struct {
+	mock *MockTrash
+}
+
+// NewMockTrash creates a new mock instance.
+func NewMockTrash(ctrl *gomock.Controller) *MockTrash {
+	mock := &MockTrash{ctrl: ctrl}
+	mock.recorder = &MockTrashMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTrash) EXPECT() *MockTrashMockRecorder {
+	return m.recorder
+}
+
+// GetAll mocks base method.
+func (m *MockTrash) GetAll(userId int) ([]todo.TrashEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId)
+	ret0, _ := ret[0].([]todo.TrashEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockTrashMockRecorder) GetAll(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockTrash)(nil).GetAll), userId)
+}
+
+// Purge mocks base method.
+func (m *MockTrash) Purge() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockTrashMockRecorder) Purge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockTrash)(nil).Purge))
+}
+
+// Restore mocks base method.
+func (m *MockTrash) Restore(userId int, entryType string, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", userId, entryType, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockTrashMockRecorder) Restore(userId, entryType, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockTrash)(nil).Restore), userId, entryType, id)
+}
+
+// MockOrphans is a mock of Orphans interface.
+type MockOrphans struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrphansMockRecorder
+}
+
+// MockOrphansMockRecorder is the mock recorder for MockOrphans.
+type MockOrphansMockRecorder struct {
+	mock *MockOrphans
+}
+
+// NewMockOrphans creates a new mock instance.
+func NewMockOrphans(ctrl *gomock.Controller) *MockOrphans {
+	mock := &MockOrphans{ctrl: ctrl}
+	mock.recorder = &MockOrphansMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrphans) EXPECT() *MockOrphansMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method.
+func (m *MockOrphans) Count() (todo.Orphans, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count")
+	ret0, _ := ret[0].(todo.Orphans)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockOrphansMockRecorder) Count() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockOrphans)(nil).Count))
+}
+
+// Delete mocks base method.
+func (m *MockOrphans) Delete() (todo.Orphans, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete")
+	ret0, _ := ret[0].(todo.Orphans)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockOrphansMockRecorder) Delete() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrphans)(nil).Delete))
+}
+
+// MockActivity is a mock of Activity interface.
+type MockActivity struct {
+	ctrl     *gomock.Controller
+	recorder *MockActivityMockRecorder
+}
+
+// MockActivityMockRecorder is the mock recorder for MockActivity.
+type MockActivityMockRecorder struct {
+	mock *MockActivity
+}
+
+// NewMockActivity creates a new mock instance.
+func NewMockActivity(ctrl *gomock.Controller) *MockActivity {
+	mock := &MockActivity{ctrl: ctrl}
+	mock.recorder = &MockActivityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockActivity) EXPECT() *MockActivityMockRecorder {
+	return m.recorder
+}
+
+// GetAll mocks base method.
+func (m *MockActivity) GetAll(userId, listId int, input todo.ActivityQuery) ([]todo.Activity, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId, listId, input)
+	ret0, _ := ret[0].([]todo.Activity)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockActivityMockRecorder) GetAll(userId, listId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockActivity)(nil).GetAll), userId, listId, input)
+}
+
+// MockComment is a mock of Comment interface.
+type MockComment struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommentMockRecorder
+}
+
+// MockCommentMockRecorder is the mock recorder for MockComment.
+type MockCommentMockRecorder struct {
+	mock *MockComment
+}
+
+// NewMockComment creates a new mock instance.
+func NewMockComment(ctrl *gomock.Controller) *MockComment {
+	mock := &MockComment{ctrl: ctrl}
+	mock.recorder = &MockCommentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockComment) EXPECT() *MockCommentMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockComment) Create(userId, itemId int, input todo.CommentInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", userId, itemId, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCommentMockRecorder) Create(userId, itemId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockComment)(nil).Create), userId, itemId, input)
+}
+
+// Delete mocks base method.
+func (m *MockComment) Delete(userId, commentId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userId, commentId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCommentMockRecorder) Delete(userId, commentId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockComment)(nil).Delete), userId, commentId)
+}
+
+// GetAll mocks base method.
+func (m *MockComment) GetAll(userId, itemId int) ([]todo.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId, itemId)
+	ret0, _ := ret[0].([]todo.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockCommentMockRecorder) GetAll(userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockComment)(nil).GetAll), userId, itemId)
+}
+
+// Update mocks base method.
+func (m *MockComment) Update(userId, commentId int, input todo.CommentInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", userId, commentId, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCommentMockRecorder) Update(userId, commentId, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockComment)(nil).Update), userId, commentId, input)
+}
+
+// MockAttachment is a mock of Attachment interface.
+type MockAttachment struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttachmentMockRecorder
+}
+
+// MockAttachmentMockRecorder is the mock recorder for MockAttachment.
+type MockAttachmentMockRecorder struct {
+	mock *MockAttachment
+}
+
+// NewMockAttachment creates a new mock instance.
+func NewMockAttachment(ctrl *gomock.Controller) *MockAttachment {
+	mock := &MockAttachment{ctrl: ctrl}
+	mock.recorder = &MockAttachmentMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttachment) EXPECT() *MockAttachmentMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockAttachment) Delete(userId, attachmentId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", userId, attachmentId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAttachmentMockRecorder) Delete(userId, attachmentId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAttachment)(nil).Delete), userId, attachmentId)
+}
+
+// DeleteDetached mocks base method.
+func (m *MockAttachment) DeleteDetached() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDetached")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDetached indicates an expected call of DeleteDetached.
+func (mr *MockAttachmentMockRecorder) DeleteDetached() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDetached", reflect.TypeOf((*MockAttachment)(nil).DeleteDetached))
+}
+
+// Get mocks base method.
+func (m *MockAttachment) Get(userId, attachmentId int) (todo.Attachment, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", userId, attachmentId)
+	ret0, _ := ret[0].(todo.Attachment)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAttachmentMockRecorder) Get(userId, attachmentId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAttachment)(nil).Get), userId, attachmentId)
+}
+
+// GetAll mocks base method.
+func (m *MockAttachment) GetAll(userId, itemId int) ([]todo.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", userId, itemId)
+	ret0, _ := ret[0].([]todo.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll.
+func (mr *MockAttachmentMockRecorder) GetAll(userId, itemId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockAttachment)(nil).GetAll), userId, itemId)
+}
+
+// Upload mocks base method.
+func (m *MockAttachment) Upload(userId, itemId int, filename string, size int64, r io.Reader) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upload", userId, itemId, filename, size, r)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upload indicates an expected call of Upload.
+func (mr *MockAttachmentMockRecorder) Upload(userId, itemId, filename, size, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockAttachment)(nil).Upload), userId, itemId, filename, size, r)
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	todo "todo-app"
+	"todo-app/pkg/repository"
+)
+
+type OrphanService struct {
+	repo repository.Orphans
+}
+
+func NewOrphanService(repo repository.Orphans) *OrphanService {
+	return &OrphanService{repo: repo}
+}
+
+func (s *OrphanService) Count() (todo.Orphans, error) {
+	return s.repo.Count()
+}
+
+func (s *OrphanService) Delete() (todo.Orphans, error) {
+	return s.repo.Delete()
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+
+	// legacySalt was appended to SHA-1 digests before the hasher was made pluggable.
+	// It is only used to verify (and then rehash) passwords stored in that format.
+	legacySalt = "lkdjflji387joidjk"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var errUnknownPasswordHash = errors.New("unknown password hash format")
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encodedHash string) (bool, error)
+	NeedsRehash(encodedHash string) bool
+}
+
+type PasswordConfig struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+}
+
+func NewPasswordHasher(cfg PasswordConfig) (PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case PasswordAlgorithmBcrypt:
+		if cfg.BcryptCost == 0 {
+			cfg.BcryptCost = bcrypt.DefaultCost
+		}
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return &BcryptHasher{cost: cfg.BcryptCost}, nil
+	case "", PasswordAlgorithmArgon2id:
+		if cfg.Argon2Time == 0 {
+			cfg.Argon2Time = 3
+		}
+		if cfg.Argon2Memory == 0 {
+			cfg.Argon2Memory = 64 * 1024
+		}
+		if cfg.Argon2Threads == 0 {
+			cfg.Argon2Threads = 2
+		}
+		return &Argon2idHasher{time: cfg.Argon2Time, memory: cfg.Argon2Memory, threads: cfg.Argon2Threads}, nil
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm %q", cfg.Algorithm)
+	}
+}
+
+type BcryptHasher struct {
+	cost int
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(password, encodedHash string) (bool, error) {
+	return verifyPassword(password, encodedHash)
+}
+
+func (h *BcryptHasher) NeedsRehash(encodedHash string) bool {
+	if !isBcryptHash(encodedHash) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost != h.cost
+}
+
+// Argon2idHasher encodes hashes in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+type Argon2idHasher struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+type argon2Params struct {
+	version int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.threads, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(password, encodedHash string) (bool, error) {
+	return verifyPassword(password, encodedHash)
+}
+
+func (h *Argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, err := decodeArgon2Hash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return params.version != argon2.Version || params.memory != h.memory ||
+		params.time != h.time || params.threads != h.threads
+}
+
+// verifyPassword checks a password against a hash in any format the service has ever stored,
+// so users keep being able to sign in after the configured algorithm changes.
+func verifyPassword(password, encodedHash string) (bool, error) {
+	switch {
+	case isBcryptHash(encodedHash):
+		err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		params, err := decodeArgon2Hash(encodedHash)
+		if err != nil {
+			return false, err
+		}
+		key := argon2.IDKey([]byte(password), params.salt, params.time, params.memory, params.threads, uint32(len(params.key)))
+		return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+	case !strings.HasPrefix(encodedHash, "$"):
+		return subtle.ConstantTimeCompare([]byte(legacyPasswordHash(password)), []byte(encodedHash)) == 1, nil
+	default:
+		return false, errUnknownPasswordHash
+	}
+}
+
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") || strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+func decodeArgon2Hash(encodedHash string) (argon2Params, error) {
+	var params argon2Params
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, errUnknownPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &params.version); err != nil {
+		return params, errUnknownPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, errUnknownPasswordHash
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, errUnknownPasswordHash
+	}
+
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.key) == 0 {
+		return params, errUnknownPasswordHash
+	}
+
+	return params, nil
+}
+
+func legacyPasswordHash(password string) string {
+	hash := sha1.New()
+	hash.Write([]byte(password))
+
+	return fmt.Sprintf("%x", hash.Sum([]byte(legacySalt)))
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	todo "todo-app"
+)
+
+func TestPasswordHasher_HashAndVerify(t *testing.T) {
+	testTable := []struct {
+		name string
+		cfg  PasswordConfig
+	}{
+		{
+			name: "Bcrypt",
+			cfg:  PasswordConfig{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: 4},
+		},
+		{
+			name: "Argon2id",
+			cfg:  PasswordConfig{Algorithm: PasswordAlgorithmArgon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			hasher, err := NewPasswordHasher(testCase.cfg)
+			assert.NoError(t, err)
+
+			first, err := hasher.Hash("qwerty")
+			assert.NoError(t, err)
+			second, err := hasher.Hash("qwerty")
+			assert.NoError(t, err)
+			assert.NotEqual(t, first, second, "every hash must use its own random salt")
+
+			ok, err := hasher.Verify("qwerty", first)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = hasher.Verify("qwertyu", first)
+			assert.NoError(t, err)
+			assert.False(t, ok)
+
+			assert.False(t, hasher.NeedsRehash(first))
+		})
+	}
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	bcryptHasher, _ := NewPasswordHasher(PasswordConfig{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: 4})
+	argonHasher, _ := NewPasswordHasher(PasswordConfig{Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1})
+	strongerArgonHasher, _ := NewPasswordHasher(PasswordConfig{Argon2Time: 2, Argon2Memory: 1024, Argon2Threads: 1})
+
+	bcryptHash, _ := bcryptHasher.Hash("qwerty")
+	argonHash, _ := argonHasher.Hash("qwerty")
+
+	assert.True(t, argonHasher.NeedsRehash(legacyPasswordHash("qwerty")))
+	assert.True(t, argonHasher.NeedsRehash(bcryptHash))
+	assert.True(t, bcryptHasher.NeedsRehash(argonHash))
+	assert.True(t, strongerArgonHasher.NeedsRehash(argonHash))
+
+	ok, err := strongerArgonHasher.Verify("qwerty", argonHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = bcryptHasher.Verify("qwerty", legacyPasswordHash("qwerty"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNewPasswordHasher_Invalid(t *testing.T) {
+	_, err := NewPasswordHasher(PasswordConfig{Algorithm: "md5"})
+	assert.Error(t, err)
+
+	_, err = NewPasswordHasher(PasswordConfig{Algorithm: PasswordAlgorithmBcrypt, BcryptCost: 100})
+	assert.Error(t, err)
+}
+
+type authRepositoryStub struct {
+	user        todo.User
+	getErr      error
+	updatedHash string
+}
+
+func (r *authRepositoryStub) CreateUser(user todo.User) (int, error) {
+	return 1, nil
+}
+
+func (r *authRepositoryStub) GetUser(username string) (todo.User, error) {
+	return r.user, r.getErr
+}
+
+func (r *authRepositoryStub) UpdatePasswordHash(userId int, passwordHash string) error {
+	r.updatedHash = passwordHash
+	return nil
+}
+
+func (r *authRepositoryStub) GetTimeZone(userId int) (string, error) {
+	return r.user.TimeZone, nil
+}
+
+var errDatabase = errors.New("connection refused")
+
+func TestAuthService_authenticate(t *testing.T) {
+	hasher, _ := NewPasswordHasher(PasswordConfig{Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1})
+	currentHash, _ := hasher.Hash("qwerty")
+
+	testTable := []struct {
+		name       string
+		storedHash string
+		password   string
+		getErr     error
+		wantRehash bool
+		wantErr    error
+	}{
+		{
+			name:       "OK",
+			storedHash: currentHash,
+			password:   "qwerty",
+		},
+		{
+			name:       "Legacy Hash Upgraded",
+			storedHash: legacyPasswordHash("qwerty"),
+			password:   "qwerty",
+			wantRehash: true,
+		},
+		{
+			name:       "Wrong Password",
+			storedHash: legacyPasswordHash("qwerty"),
+			password:   "qwertyu",
+			wantErr:    ErrIncorrectCredentials,
+		},
+		{
+			name:     "Unknown User",
+			password: "qwerty",
+			getErr:   todo.ErrNotFound,
+			wantErr:  ErrIncorrectCredentials,
+		},
+		{
+			name:     "Database Error",
+			password: "qwerty",
+			getErr:   errDatabase,
+			wantErr:  errDatabase,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := &authRepositoryStub{user: todo.User{Id: 1, Username: "test", Password: testCase.storedHash}, getErr: testCase.getErr}
+			s := NewAuthService(repo, nil, hasher, nil, TokenConfig{})
+
+			_, err := s.authenticate("test", testCase.password)
+			if testCase.wantErr != nil {
+				assert.ErrorIs(t, err, testCase.wantErr)
+				assert.Empty(t, repo.updatedHash)
+				return
+			}
+
+			assert.NoError(t, err)
+			if testCase.wantRehash {
+				assert.False(t, hasher.NeedsRehash(repo.updatedHash))
+			} else {
+				assert.Empty(t, repo.updatedHash)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	todo "todo-app"
+	"todo-app/pkg/repository"
+)
+
+type SearchService struct {
+	repo repository.Search
+}
+
+func NewSearchService(repo repository.Search) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+func (s *SearchService) Search(userId int, input todo.SearchQuery) ([]todo.SearchResult, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Search(userId, input)
+}
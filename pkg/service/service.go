@@ -1,44 +1,157 @@
 package service
 
 import (
+	"io"
 	todo "todo-app"
 	"todo-app/pkg/repository"
+	"todo-app/pkg/storage"
 )
 
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 
 type Authorization interface {
 	CreateUser(user todo.User) (int, error)
-	GenerateToken(username, password string) (string, error)
-	ParseToken(token string) (int, error)
+	GenerateToken(username, password string) (todo.Tokens, error)
+	RefreshToken(refreshToken string) (todo.Tokens, error)
+	Logout(refreshToken string) error
+	LogoutAll(userId int) error
+	ParseToken(token string) (todo.Principal, error)
+	JWKS() todo.JSONWebKeySet
 }
 
 type TodoList interface {
 	CreateList(userId int, list todo.TodoList) (int, error)
-	GetAll(userId int) ([]todo.TodoList, error)
+	GetAll(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error)
 	GetById(userId int, listId int) (todo.TodoList, error)
 	Update(userId, listId int, input todo.UpdateListInput) error
-	Delete(userId, listId int) error
+	Move(userId, listId int, input todo.MoveInput) error
+	Delete(userId, listId int, version *int) error
+	Clone(userId, listId int, input todo.CloneListInput) (int, error)
+	GetTemplates(userId int, input todo.ListsQuery) ([]todo.TodoList, string, error)
+	Instantiate(userId, templateId int, input todo.InstantiateTemplateInput) (int, error)
+}
+
+type ListMember interface {
+	GetAll(userId, listId int) ([]todo.ListMember, error)
+	Add(userId, listId int, input todo.AddMemberInput) (int, error)
+	UpdateRole(userId, listId, memberId int, input todo.UpdateMemberInput) error
+	Remove(userId, listId, memberId int) error
 }
 
 type TodoItem interface {
 	CreateItem(userId, listId int, item todo.TodoItem) (int, error)
-	GetAll(userId, listId int) ([]todo.TodoItem, error)
+	CreateSubtask(userId, parentId int, item todo.TodoItem) (int, error)
+	GetAll(userId, listId int, input todo.ItemsQuery) ([]todo.TodoItem, string, error)
 	GetById(userId, itemId int) (todo.TodoItem, error)
+	GetSubtasks(userId, itemId int) ([]todo.TodoItem, error)
+	GetLists(userId, itemId int) ([]todo.TodoList, error)
+	GetDue(userId int, window string, input todo.DueQuery) ([]todo.TodoItem, error)
+	GetAssigned(userId int) ([]todo.TodoItem, error)
 	Update(userId, itemId int, input todo.UpdateItemInput) error
-	Delete(userId, itemId int) error
+	Move(userId, itemId int, input todo.MoveInput) error
+	Transfer(userId, itemId int, input todo.TransferItemInput) error
+	Copy(userId, itemId int, input todo.CopyItemInput) (int, error)
+	Link(userId, itemId, listId int) error
+	Unlink(userId, itemId, listId int) error
+	Delete(userId, itemId int, version *int) error
+	Batch(userId, listId int, input todo.BatchInput) ([]todo.BatchResult, error)
+}
+
+type Label interface {
+	Create(userId int, label todo.Label) (int, error)
+	GetAll(userId int) ([]todo.Label, error)
+	GetById(userId, labelId int) (todo.Label, error)
+	Update(userId, labelId int, input todo.UpdateLabelInput) error
+	Delete(userId, labelId int) error
+	GetItems(userId, labelId int) ([]todo.TodoItem, error)
+	Attach(userId, itemId, labelId int) error
+	Detach(userId, itemId, labelId int) error
+}
+
+type Search interface {
+	Search(userId int, input todo.SearchQuery) ([]todo.SearchResult, error)
+}
+
+type Trash interface {
+	GetAll(userId int) ([]todo.TrashEntry, error)
+	Restore(userId int, entryType string, id int) error
+	Purge() (int64, error)
+}
+
+// Orphans finds and removes the lists and items nobody can reach anymore.
+type Orphans interface {
+	Count() (todo.Orphans, error)
+	Delete() (todo.Orphans, error)
+}
+
+type Activity interface {
+	GetAll(userId, listId int, input todo.ActivityQuery) ([]todo.Activity, string, error)
+}
+
+type Comment interface {
+	Create(userId, itemId int, input todo.CommentInput) (int, error)
+	GetAll(userId, itemId int) ([]todo.Comment, error)
+	Update(userId, commentId int, input todo.CommentInput) error
+	Delete(userId, commentId int) error
+}
+
+type Attachment interface {
+	Upload(userId, itemId int, filename string, size int64, r io.Reader) (int, error)
+	GetAll(userId, itemId int) ([]todo.Attachment, error)
+	Get(userId, attachmentId int) (todo.Attachment, io.ReadCloser, error)
+	Delete(userId, attachmentId int) error
+	DeleteDetached() (int, error)
 }
 
 type Service struct {
 	Authorization
 	TodoList
+	ListMember
 	TodoItem
+	Label
+	Search
+	Trash
+	Orphans
+	Activity
+	Comment
+	Attachment
 }
 
-func NewService(repos *repository.Repository) *Service {
-	return &Service{
-		Authorization: NewAuthService(repos.Authorization),
-		TodoList:      NewTodoListService(repos.TodoList),
-		TodoItem:      NewTodoItemService(repos.TodoItem, repos.TodoList),
+type Config struct {
+	Password    PasswordConfig
+	Token       TokenConfig
+	Trash       TrashConfig
+	Attachments AttachmentConfig
+	Storage     storage.Config
+}
+
+func NewService(repos *repository.Repository, cfg Config) (*Service, error) {
+	hasher, err := NewPasswordHasher(cfg.Password)
+	if err != nil {
+		return nil, err
 	}
+
+	keys, err := NewKeyRing(cfg.Token.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := storage.NewBlobStore(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		Authorization: NewAuthService(repos.Authorization, repos.RefreshToken, hasher, keys, cfg.Token),
+		TodoList:      NewTodoListService(repos.TodoList, repos.ListMember, repos.Authorization),
+		ListMember:    NewListMemberService(repos.ListMember),
+		TodoItem:      NewTodoItemService(repos.TodoItem, repos.ListMember, repos.Authorization),
+		Label:         NewLabelService(repos.Label, repos.TodoItem, repos.ListMember),
+		Search:        NewSearchService(repos.Search),
+		Trash:         NewTrashService(repos.Trash, repos.ListMember, cfg.Trash),
+		Orphans:       NewOrphanService(repos.Orphans),
+		Activity:      NewActivityService(repos.Activity, repos.ListMember),
+		Comment:       NewCommentService(repos.Comment, repos.ListMember),
+		Attachment:    NewAttachmentService(repos.Attachment, repos.ListMember, blobs, cfg.Attachments),
+	}, nil
 }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"time"
+	todo "todo-app"
+	"todo-app/pkg/repository"
+)
+
+// DefaultTrashRetention is how long deleted lists and items are kept when no
+// retention is configured.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+type TrashConfig struct {
+	Retention time.Duration
+}
+
+type TrashService struct {
+	repo        repository.Trash
+	membersRepo repository.ListMember
+	retention   time.Duration
+}
+
+func NewTrashService(repo repository.Trash, membersRepo repository.ListMember, cfg TrashConfig) *TrashService {
+	if cfg.Retention == 0 {
+		cfg.Retention = DefaultTrashRetention
+	}
+
+	return &TrashService{repo: repo, membersRepo: membersRepo, retention: cfg.Retention}
+}
+
+func (s *TrashService) GetAll(userId int) ([]todo.TrashEntry, error) {
+	return s.repo.GetAll(userId)
+}
+
+// Restore takes a list or an item out of the trash. Lists are restored by their
+// owners, as only owners can delete them, and items by editors.
+func (s *TrashService) Restore(userId int, entryType string, id int) error {
+	if err := todo.ValidateTrashEntryType(entryType); err != nil {
+		return err
+	}
+
+	if entryType == todo.TrashEntryList {
+		if err := requireListRole(s.membersRepo, userId, id, todo.RoleOwner); err != nil {
+			return err
+		}
+
+		return s.repo.RestoreList(userId, id)
+	}
+
+	if err := requireItemRole(s.membersRepo, userId, id, todo.RoleEditor); err != nil {
+		return err
+	}
+
+	return s.repo.RestoreItem(userId, id)
+}
+
+// Purge permanently removes what has been in the trash for longer than the retention.
+func (s *TrashService) Purge() (int64, error) {
+	return s.repo.Purge(time.Now().Add(-s.retention))
+}
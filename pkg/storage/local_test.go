@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	todo "todo-app"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatalf("error creating local store: %v", err)
+	}
+
+	assert.NoError(t, s.Put("abc", strings.NewReader("hello"), 5, "text/plain"))
+
+	r, err := s.Get("abc")
+	if assert.NoError(t, err) {
+		content, err := io.ReadAll(r)
+		r.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(content))
+	}
+
+	assert.Error(t, s.Put("short", strings.NewReader("hi"), 5, "text/plain"))
+	_, err = s.Get("short")
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	for _, key := range []string{"", "..", "../abc", "a/b", ".upload-1"} {
+		assert.Error(t, s.Put(key, strings.NewReader("x"), 1, "text/plain"), key)
+	}
+
+	assert.NoError(t, s.Delete("abc"))
+	assert.NoError(t, s.Delete("abc"))
+	_, err = s.Get("abc")
+	assert.ErrorIs(t, err, todo.ErrNotFound)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// BlobStore keeps the contents of attachments. Keys are chosen by the caller and are
+// safe to use as file names and URL path segments. Get fails with todo.ErrNotFound for
+// unknown keys and Delete succeeds for them.
+type BlobStore interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+type Config struct {
+	Driver string
+	Dir    string
+	S3     S3Config
+}
+
+// NewBlobStore returns the store of the configured driver, the local filesystem by default.
+func NewBlobStore(cfg Config) (BlobStore, error) {
+	switch cfg.Driver {
+	case DriverLocal, "":
+		return NewLocalStore(cfg.Dir)
+	case DriverS3:
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown blob store driver %q", cfg.Driver)
+	}
+}
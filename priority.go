@@ -0,0 +1,67 @@
+package todo
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Priority tells how important an item is. It is stored as its level, so the
+// database can order items by priority.
+type Priority string
+
+const (
+	PriorityNone   Priority = "none"
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+var priorities = []Priority{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
+func (p Priority) Validate() error {
+	if _, ok := p.level(); !ok {
+		return fmt.Errorf("%w: priority must be one of none, low, medium, high, urgent", ErrValidation)
+	}
+
+	return nil
+}
+
+// Level ranks the priority from 0 (none) to 4 (urgent).
+func (p Priority) Level() int {
+	level, _ := p.level()
+	return level
+}
+
+func (p Priority) level() (int, bool) {
+	for level, priority := range priorities {
+		if p == priority {
+			return level, true
+		}
+	}
+
+	return 0, false
+}
+
+func (p Priority) Value() (driver.Value, error) {
+	if p == "" {
+		return int64(0), nil
+	}
+
+	level, ok := p.level()
+	if !ok {
+		return nil, fmt.Errorf("unknown priority %q", string(p))
+	}
+
+	return int64(level), nil
+}
+
+func (p *Priority) Scan(src interface{}) error {
+	level, ok := src.(int64)
+	if !ok || level < 0 || level >= int64(len(priorities)) {
+		return fmt.Errorf("cannot scan %v into priority", src)
+	}
+	*p = priorities[level]
+
+	return nil
+}
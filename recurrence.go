@@ -0,0 +1,321 @@
+package todo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+const (
+	rruleUntilTimeLayout = "20060102T150405Z"
+	rruleUntilDateLayout = "20060102"
+)
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Recurrence is the schedule of a repeating item as an RFC 5545 RRULE, e.g.
+// FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;UNTIL=20231231. An empty value means the item
+// does not repeat.
+type Recurrence string
+
+func (r Recurrence) Validate() error {
+	_, err := r.Rule()
+	return err
+}
+
+func (r Recurrence) Rule() (RRule, error) {
+	return ParseRRule(string(r))
+}
+
+func (r Recurrence) Value() (driver.Value, error) {
+	if r == "" {
+		return nil, nil
+	}
+
+	return string(r), nil
+}
+
+func (r *Recurrence) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*r = ""
+	case string:
+		*r = Recurrence(src)
+	case []byte:
+		*r = Recurrence(src)
+	default:
+		return fmt.Errorf("cannot scan %v into recurrence", src)
+	}
+
+	return nil
+}
+
+// RRule is a parsed Recurrence. Count is the number of occurrences left including
+// the current one, zero when unlimited. UntilDate tells an UNTIL date, which includes
+// the whole day in the user's time zone, from an UNTIL instant.
+type RRule struct {
+	Freq      string
+	Interval  int
+	ByDay     []time.Weekday
+	Until     time.Time
+	UntilDate bool
+	Count     int
+}
+
+// ParseRRule parses the FREQ, INTERVAL, BYDAY, UNTIL and COUNT parts of an RRULE.
+// BYDAY takes plain weekdays and is only supported by DAILY and WEEKLY rules.
+func ParseRRule(value string) (RRule, error) {
+	rule := RRule{Interval: 1}
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, arg, ok := strings.Cut(part, "=")
+		if !ok || arg == "" {
+			return RRule{}, invalidRecurrence("malformed part %q", part)
+		}
+		if seen[name] {
+			return RRule{}, invalidRecurrence("%s is given twice", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch arg {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				rule.Freq = arg
+			default:
+				return RRule{}, invalidRecurrence("FREQ must be one of DAILY, WEEKLY, MONTHLY, YEARLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return RRule{}, invalidRecurrence("INTERVAL must be a positive number")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return RRule{}, invalidRecurrence("COUNT must be a positive number")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := time.Parse(rruleUntilTimeLayout, arg)
+			if err != nil {
+				if until, err = time.Parse(rruleUntilDateLayout, arg); err != nil {
+					return RRule{}, invalidRecurrence("UNTIL must look like 20231231 or 20231231T235959Z")
+				}
+				rule.UntilDate = true
+			}
+			rule.Until = until
+		case "BYDAY":
+			days, err := parseWeekdays(arg)
+			if err != nil {
+				return RRule{}, err
+			}
+			rule.ByDay = days
+		default:
+			return RRule{}, invalidRecurrence("%s is not supported", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return RRule{}, invalidRecurrence("FREQ is required")
+	}
+
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return RRule{}, invalidRecurrence("UNTIL and COUNT cannot be combined")
+	}
+
+	if len(rule.ByDay) > 0 && rule.Freq != FreqDaily && rule.Freq != FreqWeekly {
+		return RRule{}, invalidRecurrence("BYDAY is only supported with DAILY and WEEKLY")
+	}
+
+	return rule, nil
+}
+
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	set := make(map[time.Weekday]bool)
+	for _, code := range strings.Split(value, ",") {
+		day := -1
+		for i, weekdayCode := range weekdayCodes {
+			if code == weekdayCode {
+				day = i
+			}
+		}
+		if day < 0 {
+			return nil, invalidRecurrence("BYDAY must list weekdays such as MO,WE,FR")
+		}
+		set[time.Weekday(day)] = true
+	}
+
+	days := make([]time.Weekday, 0, len(set))
+	for day := range set {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return daysSinceMonday(days[i]) < daysSinceMonday(days[j])
+	})
+
+	return days, nil
+}
+
+func invalidRecurrence(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: invalid recurrence: %s", ErrValidation, fmt.Sprintf(format, args...))
+}
+
+// String formats the rule in the canonical form stored for items.
+func (r RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = weekdayCodes[day]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(rruleUntilDateLayout))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(rruleUntilTimeLayout))
+		}
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the occurrence following after and the rule of that occurrence, whose
+// Count is one less. The calendar arithmetic is done in after's location, so an item
+// due at 09:00 stays due at 09:00 across daylight saving changes. ok is false once
+// the schedule has ended.
+func (r RRule) Next(after time.Time) (next time.Time, rest RRule, ok bool) {
+	if r.Count == 1 {
+		return time.Time{}, r, false
+	}
+
+	next = r.step(after)
+	if next.IsZero() || r.ended(next) {
+		return time.Time{}, r, false
+	}
+
+	if r.Count > 0 {
+		r.Count--
+	}
+
+	return next, r, true
+}
+
+func (r RRule) step(after time.Time) time.Time {
+	y, m, d := after.Date()
+	hour, minute, sec := after.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, sec, after.Nanosecond(), after.Location())
+	}
+
+	switch r.Freq {
+	case FreqDaily:
+		// a week of steps visits every weekday the interval can reach
+		for i := 1; i <= 7; i++ {
+			next := at(y, m, d+i*r.Interval)
+			if r.onDay(next.Weekday()) {
+				return next
+			}
+		}
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return at(y, m, d+7*r.Interval)
+		}
+
+		// the remaining days of after's week, then the first matching day interval weeks
+		// later; weeks start on Monday
+		offset := daysSinceMonday(after.Weekday())
+		for i := offset + 1; i < 7; i++ {
+			if r.onDay(weekdayFromMonday(i)) {
+				return at(y, m, d-offset+i)
+			}
+		}
+		for i := 0; i < 7; i++ {
+			if r.onDay(weekdayFromMonday(i)) {
+				return at(y, m, d-offset+7*r.Interval+i)
+			}
+		}
+	case FreqMonthly:
+		// months without the day, e.g. the 31st, are skipped
+		for i := 1; i <= 48; i++ {
+			month := m + time.Month(i*r.Interval)
+			if d <= daysIn(y, month) {
+				return at(y, month, d)
+			}
+		}
+	case FreqYearly:
+		// years without the day, i.e. February 29, are skipped
+		for i := 1; i <= 8; i++ {
+			if year := y + i*r.Interval; d <= daysIn(year, m) {
+				return at(year, m, d)
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+func (r RRule) onDay(day time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, byDay := range r.ByDay {
+		if byDay == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r RRule) ended(next time.Time) bool {
+	if r.Until.IsZero() {
+		return false
+	}
+
+	if r.UntilDate {
+		y, m, d := r.Until.Date()
+		return !next.Before(time.Date(y, m, d+1, 0, 0, 0, 0, next.Location()))
+	}
+
+	return next.After(r.Until)
+}
+
+func daysSinceMonday(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func weekdayFromMonday(days int) time.Weekday {
+	return time.Weekday((days + 1) % 7)
+}
+
+// daysIn returns the number of days of the month, which may lie past December.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,15 @@
+CREATE TABLE refresh_tokens
+(
+    id         serial                                      not null unique,
+    user_id    int references users (id) on delete cascade not null,
+    family_id  varchar(64)                                 not null,
+    token_hash varchar(64)                                 not null unique,
+    expires_at timestamp                                   not null,
+    created_at timestamp                                   not null default now(),
+    used_at    timestamp,
+    revoked_at timestamp
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
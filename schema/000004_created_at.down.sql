@@ -0,0 +1,13 @@
+DROP INDEX todo_items_title_idx;
+
+DROP INDEX todo_items_created_at_idx;
+
+DROP INDEX todo_lists_title_idx;
+
+DROP INDEX todo_lists_created_at_idx;
+
+ALTER TABLE todo_items
+    DROP COLUMN created_at;
+
+ALTER TABLE todo_lists
+    DROP COLUMN created_at;
@@ -0,0 +1,13 @@
+ALTER TABLE todo_lists
+    ADD COLUMN created_at timestamp not null default now();
+
+ALTER TABLE todo_items
+    ADD COLUMN created_at timestamp not null default now();
+
+CREATE INDEX todo_lists_created_at_idx ON todo_lists (created_at, id);
+
+CREATE INDEX todo_lists_title_idx ON todo_lists (title, id);
+
+CREATE INDEX todo_items_created_at_idx ON todo_items (created_at, id);
+
+CREATE INDEX todo_items_title_idx ON todo_items (title, id);
@@ -0,0 +1,15 @@
+ALTER TABLE todo_lists
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+        ) STORED;
+
+ALTER TABLE todo_items
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+        ) STORED;
+
+CREATE INDEX todo_lists_search_idx ON todo_lists USING GIN (search_vector);
+
+CREATE INDEX todo_items_search_idx ON todo_items USING GIN (search_vector);
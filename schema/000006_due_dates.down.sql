@@ -0,0 +1,6 @@
+DROP INDEX todo_items_due_at_idx;
+
+ALTER TABLE todo_items
+    DROP COLUMN completed_at,
+    DROP COLUMN remind_at,
+    DROP COLUMN due_at;
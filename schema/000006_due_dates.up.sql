@@ -0,0 +1,8 @@
+ALTER TABLE todo_items
+    ADD COLUMN due_at       timestamptz,
+    ADD COLUMN remind_at    timestamptz,
+    ADD COLUMN completed_at timestamptz;
+
+UPDATE todo_items SET completed_at = now() WHERE done;
+
+CREATE INDEX todo_items_due_at_idx ON todo_items (due_at) WHERE NOT done;
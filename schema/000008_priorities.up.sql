@@ -0,0 +1,2 @@
+ALTER TABLE todo_items
+    ADD COLUMN priority smallint NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 4);
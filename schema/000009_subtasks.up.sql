@@ -0,0 +1,4 @@
+ALTER TABLE todo_items
+    ADD COLUMN parent_item_id int REFERENCES todo_items (id) ON DELETE CASCADE CHECK (parent_item_id <> id);
+
+CREATE INDEX todo_items_parent_item_id_idx ON todo_items (parent_item_id);
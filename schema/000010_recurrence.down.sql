@@ -0,0 +1,5 @@
+ALTER TABLE todo_items
+    DROP COLUMN recurrence;
+
+ALTER TABLE users
+    DROP COLUMN time_zone;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN time_zone varchar(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE todo_items
+    ADD COLUMN recurrence varchar(255);
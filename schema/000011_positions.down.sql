@@ -0,0 +1,8 @@
+DROP INDEX lists_items_position_idx;
+DROP INDEX users_lists_position_idx;
+
+ALTER TABLE lists_items
+    DROP COLUMN position;
+
+ALTER TABLE users_lists
+    DROP COLUMN position;
@@ -0,0 +1,17 @@
+-- positions are base 36 strings ordered byte by byte, see pkg/repository/position.go
+ALTER TABLE users_lists
+    ADD COLUMN position varchar(255) COLLATE "C" NOT NULL DEFAULT '';
+
+ALTER TABLE lists_items
+    ADD COLUMN position varchar(255) COLLATE "C" NOT NULL DEFAULT '';
+
+UPDATE users_lists ul SET position = lpad(to_hex(n.rank), 6, '0')
+FROM (SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY list_id) AS rank FROM users_lists) n
+WHERE n.id = ul.id;
+
+UPDATE lists_items li SET position = lpad(to_hex(n.rank), 6, '0')
+FROM (SELECT id, row_number() OVER (PARTITION BY list_id ORDER BY item_id) AS rank FROM lists_items) n
+WHERE n.id = li.id;
+
+CREATE INDEX users_lists_position_idx ON users_lists (user_id, position);
+CREATE INDEX lists_items_position_idx ON lists_items (list_id, position);
@@ -0,0 +1 @@
+DROP INDEX lists_items_list_id_item_id_idx;
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX lists_items_list_id_item_id_idx ON lists_items (list_id, item_id);
@@ -0,0 +1,11 @@
+DELETE FROM todo_items WHERE deleted_at IS NOT NULL;
+DELETE FROM todo_lists WHERE deleted_at IS NOT NULL;
+
+DROP INDEX todo_items_deleted_at_idx;
+DROP INDEX todo_lists_deleted_at_idx;
+
+ALTER TABLE todo_items
+    DROP COLUMN deleted_at;
+
+ALTER TABLE todo_lists
+    DROP COLUMN deleted_at;
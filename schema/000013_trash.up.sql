@@ -0,0 +1,9 @@
+-- trashed lists and items keep their rows until the trash is purged
+ALTER TABLE todo_lists
+    ADD COLUMN deleted_at timestamptz;
+
+ALTER TABLE todo_items
+    ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX todo_lists_deleted_at_idx ON todo_lists (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX todo_items_deleted_at_idx ON todo_items (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1 @@
+-- purged orphans cannot be brought back
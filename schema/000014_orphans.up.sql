@@ -0,0 +1,4 @@
+-- lists nobody has access to and items left in no list by deleted lists
+DELETE FROM todo_lists tl WHERE NOT EXISTS (SELECT 1 FROM users_lists ul WHERE ul.list_id = tl.id);
+
+DELETE FROM todo_items ti WHERE NOT EXISTS (SELECT 1 FROM lists_items li WHERE li.item_id = ti.id);
@@ -0,0 +1,14 @@
+-- activity outlives its actor, so actor_id does not reference users
+CREATE TABLE activity
+(
+    id          serial                                           not null unique,
+    list_id     int references todo_lists (id) on delete cascade not null,
+    actor_id    int                                              not null,
+    action      varchar(255)                                     not null,
+    entity_type varchar(255)                                     not null,
+    entity_id   int                                              not null,
+    changes     jsonb,
+    created_at  timestamptz                                      not null default now()
+);
+
+CREATE INDEX activity_list_id_idx ON activity (list_id, id);
@@ -0,0 +1,11 @@
+CREATE TABLE item_comments
+(
+    id         serial                                           not null unique,
+    item_id    int references todo_items (id) on delete cascade not null,
+    user_id    int references users (id) on delete cascade      not null,
+    body       text                                             not null,
+    created_at timestamptz                                      not null default now(),
+    updated_at timestamptz
+);
+
+CREATE INDEX item_comments_item_id_idx ON item_comments (item_id, id);
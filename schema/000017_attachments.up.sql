@@ -0,0 +1,18 @@
+-- attachments outlive their item until their blobs are removed, so removing an item
+-- only detaches them
+CREATE TABLE attachments
+(
+    id           serial                                            not null unique,
+    item_id      int references todo_items (id) on delete set null,
+    user_id      int references users (id) on delete cascade       not null,
+    filename     varchar(255)                                      not null,
+    size         bigint                                            not null,
+    content_type varchar(255)                                      not null,
+    sha256       char(64)                                          not null,
+    storage_key  varchar(255)                                      not null unique,
+    created_at   timestamptz                                       not null default now()
+);
+
+CREATE INDEX attachments_item_id_idx ON attachments (item_id);
+CREATE INDEX attachments_user_id_idx ON attachments (user_id);
+CREATE INDEX attachments_detached_idx ON attachments (id) WHERE item_id IS NULL;
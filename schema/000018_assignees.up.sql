@@ -0,0 +1,4 @@
+ALTER TABLE todo_items
+    ADD COLUMN assignee_id int REFERENCES users (id) ON DELETE SET NULL;
+
+CREATE INDEX todo_items_assignee_id_idx ON todo_items (assignee_id) WHERE assignee_id IS NOT NULL;
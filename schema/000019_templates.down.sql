@@ -0,0 +1,2 @@
+ALTER TABLE todo_lists
+    DROP COLUMN is_template;
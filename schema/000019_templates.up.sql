@@ -0,0 +1,2 @@
+ALTER TABLE todo_lists
+    ADD COLUMN is_template boolean not null default false;
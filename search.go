@@ -0,0 +1,46 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SearchResultList = "list"
+	SearchResultItem = "item"
+
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchResult is a list or an item matching a search query. ListId is the list
+// itself for lists and the first accessible list containing items. Snippet is an
+// HTML-escaped excerpt of the title and description with the matched words wrapped
+// in <b></b>.
+type SearchResult struct {
+	Type    string  `json:"type" db:"type"`
+	Id      int     `json:"id" db:"id"`
+	ListId  int     `json:"list_id" db:"list_id"`
+	Title   string  `json:"title" db:"title"`
+	Snippet string  `json:"snippet" db:"snippet"`
+	Rank    float64 `json:"rank" db:"rank"`
+}
+
+// SearchQuery is a web-search style query: quoted phrases, "or" and a leading "-"
+// to exclude words are supported.
+type SearchQuery struct {
+	Query string `form:"q"`
+	Limit int    `form:"limit"`
+}
+
+func (q SearchQuery) Validate() error {
+	if strings.TrimSpace(q.Query) == "" {
+		return fmt.Errorf("%w: search query is empty", ErrValidation)
+	}
+
+	if q.Limit < 0 || q.Limit > MaxSearchLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxSearchLimit)
+	}
+
+	return nil
+}
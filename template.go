@@ -0,0 +1,46 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// TemplateDateLayout is how the {{date}} template variable is filled in by default.
+const TemplateDateLayout = "2006-01-02"
+
+var templateVariable = regexp.MustCompile(`{{\s*([A-Za-z0-9_]+)\s*}}`)
+
+// CloneListInput copies a list with all of its items. Title defaults to the title of
+// the list; with Template set the copy is saved as a template instead of a list.
+type CloneListInput struct {
+	Title    string `json:"title"`
+	Template bool   `json:"template"`
+}
+
+// InstantiateTemplateInput creates a list from a template. Every {{name}} in the titles
+// of the template and its items is replaced with the value of the variable.
+type InstantiateTemplateInput struct {
+	Title     string            `json:"title"`
+	Variables map[string]string `json:"variables"`
+}
+
+// ExpandTemplate replaces the variables in s. Unknown variables are an error rather
+// than left in place, so a typo does not end up in every new list.
+func ExpandTemplate(s string, variables map[string]string) (string, error) {
+	var unknown string
+	expanded := templateVariable.ReplaceAllStringFunc(s, func(match string) string {
+		name := templateVariable.FindStringSubmatch(match)[1]
+		value, ok := variables[name]
+		if !ok && unknown == "" {
+			unknown = name
+		}
+
+		return value
+	})
+
+	if unknown != "" {
+		return "", fmt.Errorf("%w: unknown template variable %q", ErrValidation, unknown)
+	}
+
+	return expanded, nil
+}
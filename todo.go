@@ -1,24 +1,88 @@
 package todo
 
-import "errors"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// RoleAtLeast reports whether role grants at least the permissions of min.
+func RoleAtLeast(role, min string) bool {
+	return roleRanks[role] >= roleRanks[min] && roleRanks[role] > 0
+}
+
+func ValidateRole(role string) error {
+	if _, ok := roleRanks[role]; !ok {
+		return fmt.Errorf("%w: role must be one of owner, editor, viewer", ErrValidation)
+	}
+
+	return nil
+}
 
 type TodoList struct {
-	Id          int    `json:"id" db:"id"`
-	Title       string `json:"title" db:"title" binding:"required"`
-	Description string `json:"description" db:"description"`
+	Id          int        `json:"id" db:"id"`
+	Title       string     `json:"title" db:"title" binding:"required"`
+	Description string     `json:"description" db:"description"`
+	Role        string     `json:"role,omitempty" db:"role"`
+	CreatedAt   *time.Time `json:"created_at,omitempty" db:"created_at"`
+	Position    string     `json:"position,omitempty" db:"position"`
+	Template    bool       `json:"template,omitempty" db:"is_template"`
+	Version     int        `json:"version,omitempty" db:"version"`
 }
 
 type UsersList struct {
 	Id     int
 	UserId int
 	ListId int
+	Role   string
+}
+
+type ListMember struct {
+	UserId   int    `json:"user_id" db:"user_id"`
+	Name     string `json:"name" db:"name"`
+	Username string `json:"username" db:"username"`
+	Role     string `json:"role" db:"role"`
+}
+
+type AddMemberInput struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required"`
+}
+
+type UpdateMemberInput struct {
+	Role string `json:"role" binding:"required"`
 }
 
 type TodoItem struct {
-	Id          int    `json:"id" db:"id"`
-	Title       string `json:"title" db:"title" binding:"required"`
-	Description string `json:"description" db:"description"`
-	Done        bool   `json:"done" db:"done"`
+	Id           int        `json:"id" db:"id"`
+	Title        string     `json:"title" db:"title" binding:"required"`
+	Description  string     `json:"description" db:"description"`
+	Done         bool       `json:"done" db:"done"`
+	CreatedAt    *time.Time `json:"created_at,omitempty" db:"created_at"`
+	DueAt        *time.Time `json:"due_at,omitempty" db:"due_at"`
+	RemindAt     *time.Time `json:"remind_at,omitempty" db:"remind_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	Priority     Priority   `json:"priority,omitempty" db:"priority"`
+	ParentId     *int       `json:"parent_id,omitempty" db:"parent_item_id"`
+	AssigneeId   *int       `json:"assignee_id,omitempty" db:"assignee_id"`
+	Recurrence   Recurrence `json:"recurrence,omitempty" db:"recurrence"`
+	Position     string     `json:"position,omitempty" db:"position"`
+	CommentCount int        `json:"comment_count" db:"comment_count"`
+	Version      int        `json:"version,omitempty" db:"version"`
+	Labels       []Label    `json:"labels,omitempty" db:"-"`
+	Subtasks     []TodoItem `json:"subtasks,omitempty" db:"-"`
 }
 
 type ListsItem struct {
@@ -27,29 +91,157 @@ type ListsItem struct {
 	ItemId int
 }
 
+// UpdateListInput changes the fields that are set. With Version set the update only
+// applies to that version of the list, which the client got as its ETag.
 type UpdateListInput struct {
 	Title       *string `json:"title"`
 	Description *string `json:"description"`
+	Version     *int    `json:"-"`
 }
 
 func (i UpdateListInput) Validate() error {
 	if i.Title == nil && i.Description == nil {
-		return errors.New("update structure has no values")
+		return fmt.Errorf("%w: update structure has no values", ErrValidation)
+	}
+
+	return nil
+}
+
+// MoveInput places a list or an item right after After, right before Before, or
+// between the two. ListId picks the list an item linked into several lists is moved
+// in, which defaults to the first list the item was added to.
+type MoveInput struct {
+	Before *int `json:"before"`
+	After  *int `json:"after"`
+	ListId int  `json:"list_id"`
+}
+
+func (i MoveInput) Validate() error {
+	if i.Before == nil && i.After == nil {
+		return fmt.Errorf("%w: before or after is required", ErrValidation)
+	}
+
+	if i.Before != nil && i.After != nil && *i.Before == *i.After {
+		return fmt.Errorf("%w: before and after must differ", ErrValidation)
+	}
+
+	return nil
+}
+
+// TransferItemInput moves an item with its subtasks from one of its lists to another.
+// FromListId may be left out for items in a single list.
+type TransferItemInput struct {
+	FromListId int `json:"from_list_id"`
+	ToListId   int `json:"to_list_id" binding:"required"`
+}
+
+func (i TransferItemInput) Validate() error {
+	if i.FromListId == i.ToListId {
+		return fmt.Errorf("%w: from_list_id and to_list_id must differ", ErrValidation)
 	}
 
 	return nil
 }
 
+// CopyItemInput copies an item into a list as a new open item, optionally with copies
+// of its subtasks and with the user's own labels.
+type CopyItemInput struct {
+	ListId   int  `json:"list_id" binding:"required"`
+	Subtasks bool `json:"subtasks"`
+	Labels   bool `json:"labels"`
+}
+
+// NullableTime is a field of an update input that tells an omitted value, which keeps
+// the stored one, from an explicit null, which clears it.
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (t *NullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Time = nil
+		return nil
+	}
+
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t.Time = &value
+
+	return nil
+}
+
+// NullableInt is the NullableTime of integer fields.
+type NullableInt struct {
+	Set   bool
+	Value *int
+}
+
+func (i *NullableInt) UnmarshalJSON(data []byte) error {
+	i.Set = true
+	if string(data) == "null" {
+		i.Value = nil
+		return nil
+	}
+
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	i.Value = &value
+
+	return nil
+}
+
+// UpdateItemInput changes the given fields of an item. An empty Recurrence stops
+// the item repeating. With Version set the update only applies to that version of the item.
 type UpdateItemInput struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
-	Done        *bool   `json:"done"`
+	Title       *string      `json:"title"`
+	Description *string      `json:"description"`
+	Done        *bool        `json:"done"`
+	DueAt       NullableTime `json:"due_at"`
+	RemindAt    NullableTime `json:"remind_at"`
+	Priority    *Priority    `json:"priority"`
+	ParentId    NullableInt  `json:"parent_id"`
+	Recurrence  *Recurrence  `json:"recurrence"`
+	AssigneeId  NullableInt  `json:"assignee_id"`
+	Version     *int         `json:"-"`
 }
 
 func (i UpdateItemInput) Validate() error {
-	if i.Title == nil && i.Description == nil && i.Done == nil {
-		return errors.New("update structure has no values")
+	if i.Title == nil && i.Description == nil && i.Done == nil && !i.DueAt.Set && !i.RemindAt.Set &&
+		i.Priority == nil && !i.ParentId.Set && i.Recurrence == nil && !i.AssigneeId.Set {
+		return fmt.Errorf("%w: update structure has no values", ErrValidation)
+	}
+
+	if i.DueAt.Time != nil && i.RemindAt.Time != nil && i.RemindAt.Time.After(*i.DueAt.Time) {
+		return fmt.Errorf("%w: remind_at must not be after due_at", ErrValidation)
+	}
+
+	if i.Priority != nil {
+		if err := i.Priority.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if i.Recurrence != nil && *i.Recurrence != "" {
+		return i.Recurrence.Validate()
 	}
 
 	return nil
 }
+
+const (
+	DueOverdue  = "overdue"
+	DueToday    = "today"
+	DueThisWeek = "week"
+)
+
+// DueQuery selects open items by due date. TimeZone is an IANA name, e.g. Europe/Moscow,
+// that decides where "today" and "this week" start; it defaults to UTC.
+type DueQuery struct {
+	TimeZone string `form:"tz"`
+}
@@ -0,0 +1,37 @@
+package todo
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	TrashEntryList = "list"
+	TrashEntryItem = "item"
+)
+
+// TrashEntry is a list or an item in the trash. ListId is the list itself for lists
+// and the list the item was deleted from for items. Subtasks deleted together with
+// their parent are restored with it and have no entries of their own.
+type TrashEntry struct {
+	Type      string    `json:"type" db:"type"`
+	Id        int       `json:"id" db:"id"`
+	ListId    int       `json:"list_id" db:"list_id"`
+	Title     string    `json:"title" db:"title"`
+	DeletedAt time.Time `json:"deleted_at" db:"deleted_at"`
+}
+
+func ValidateTrashEntryType(entryType string) error {
+	if entryType != TrashEntryList && entryType != TrashEntryItem {
+		return fmt.Errorf("%w: type must be one of %s, %s", ErrValidation, TrashEntryList, TrashEntryItem)
+	}
+
+	return nil
+}
+
+// Orphans counts the lists no user has access to and the items that are in no list.
+// Neither can be reached through the API anymore.
+type Orphans struct {
+	Lists int64 `json:"lists" db:"lists"`
+	Items int64 `json:"items" db:"items"`
+}
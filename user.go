@@ -1,8 +1,51 @@
 package todo
 
+import "time"
+
 type User struct {
 	Id       int    `json:"-" db:"id"`
 	Name     string `json:"name" binding:"required"`
 	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Password string `json:"password" db:"password_hash" binding:"required"`
+	// TimeZone is an IANA name, e.g. Europe/Moscow, used for the user's recurring
+	// items. It defaults to UTC.
+	TimeZone string `json:"time_zone" db:"time_zone"`
+}
+
+type Principal struct {
+	UserId    int
+	TokenId   string
+	SessionId string
+	Scopes    []string
+}
+
+type RefreshToken struct {
+	Id        int        `db:"id"`
+	UserId    int        `db:"user_id"`
+	FamilyId  string     `db:"family_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+type Tokens struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
 }